@@ -2,9 +2,13 @@
 package flags
 
 import (
+	"clip-tagger/renamer"
 	"flag"
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
+	"time"
 )
 
 // Config holds parsed flag values
@@ -14,19 +18,46 @@ type Config struct {
 	CleanMissing bool
 	Preview      bool
 	Help         bool
+	Recursive    bool
+	RecursiveSet bool // --recursive was given, possibly as --recursive=false
+	Include      []string
+	IncludeSet   bool // --include was given, possibly empty to clear saved patterns
+	Exclude      []string
+	ExcludeSet   bool // --exclude was given, possibly empty to clear saved patterns
+	Layout       string
+	Template     string
+	Gap          time.Duration
+	RejectTo     string
+	ForceUnlock  bool
 	Directory    string
+
+	// Undo command
+	Command   string // "undo", or empty for the interactive session
+	UndoBatch int    // Batch to undo; 0 means the latest
+	ListBatch bool   // List batches instead of undoing
 }
 
+// CommandUndo reverses a previously executed rename or copy batch
+const CommandUndo = "undo"
+
 // Parse parses command-line flags and returns config
 func Parse() (*Config, error) {
 	config := &Config{}
 
 	// Define flags
-	flag.StringVar(&config.SortBy, "sort-by", "", "Override default sort order (name, modified, created)")
+	flag.StringVar(&config.SortBy, "sort-by", "", "Override default sort order (name, modified, created, recorded, timecode)")
 	flag.BoolVar(&config.Reset, "reset", false, "Delete existing state and start fresh")
 	flag.BoolVar(&config.CleanMissing, "clean-missing", false, "Remove missing files from state")
 	flag.BoolVar(&config.Preview, "preview", false, "Show what would be renamed without executing")
 	flag.BoolVar(&config.Help, "help", false, "Show usage information")
+	flag.BoolVar(&config.Recursive, "recursive", false, "Scan subdirectories as part of the session")
+	include := flag.String("include", "", "Comma-separated glob patterns a file must match")
+	exclude := flag.String("exclude", "", "Comma-separated glob patterns for files and folders to ignore")
+	flag.StringVar(&config.Layout, "layout", "", "Where recursive renames are placed (flatten, preserve)")
+	flag.StringVar(&config.Template, "template", "", "Filename template for renamed files")
+	flag.DurationVar(&config.Gap, "gap", 0, "Recording gap that suggests a new group")
+	flag.StringVar(&config.RejectTo, "reject-to", "", "Folder rejected clips are moved to, or trash")
+	flag.BoolVar(&config.ForceUnlock, "force-unlock", false, "Remove the session lock left by another instance")
 
 	// Custom usage function
 	flag.Usage = PrintUsage
@@ -42,36 +73,120 @@ func Parse() (*Config, error) {
 
 	// Get directory (required if not --help)
 	args := flag.Args()
+	if len(args) > 0 && args[0] == CommandUndo {
+		var err error
+		if args, err = parseUndo(config, args[1:]); err != nil {
+			return nil, err
+		}
+	}
 	if len(args) < 1 {
 		return nil, fmt.Errorf("directory argument is required")
 	}
 	config.Directory = args[0]
 
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "recursive":
+			config.RecursiveSet = true
+		case "include":
+			config.IncludeSet = true
+		case "exclude":
+			config.ExcludeSet = true
+		}
+	})
+	config.Include = splitPatterns(*include)
+	config.Exclude = splitPatterns(*exclude)
+
 	// Validate sort-by if specified
 	if config.SortBy != "" {
-		valid := config.SortBy == "name" || config.SortBy == "modified" || config.SortBy == "created"
+		valid := config.SortBy == "name" || config.SortBy == "modified" || config.SortBy == "created" ||
+			config.SortBy == "recorded" || config.SortBy == "timecode"
+		if !valid {
+			return nil, fmt.Errorf("invalid sort-by value: %s (must be name, modified, created, recorded, or timecode)", config.SortBy)
+		}
+	}
+
+	// Validate layout if specified
+	if config.Layout != "" {
+		valid := config.Layout == "flatten" || config.Layout == "preserve"
 		if !valid {
-			return nil, fmt.Errorf("invalid sort-by value: %s (must be name, modified, or created)", config.SortBy)
+			return nil, fmt.Errorf("invalid layout value: %s (must be flatten or preserve)", config.Layout)
 		}
 	}
 
+	// Validate template if specified
+	if config.Template != "" {
+		if _, err := renamer.ParseTemplate(config.Template); err != nil {
+			return nil, fmt.Errorf("invalid template: %w", err)
+		}
+	}
+
+	// Validate gap if specified
+	if config.Gap < 0 {
+		return nil, fmt.Errorf("invalid gap: %s (must be positive)", config.Gap)
+	}
+
+	// Validate reject-to if specified: a folder inside the session, or the trash
+	if config.RejectTo != "" && config.RejectTo != "trash" {
+		folder := filepath.Clean(config.RejectTo)
+		if filepath.IsAbs(folder) || folder == "." || folder == ".." || strings.HasPrefix(folder, ".."+string(filepath.Separator)) {
+			return nil, fmt.Errorf("invalid reject-to value: %s (must be trash or a folder inside the directory)", config.RejectTo)
+		}
+		config.RejectTo = filepath.ToSlash(folder)
+	}
+
 	return config, nil
 }
 
+// parseUndo parses the undo command's own flags and returns the remaining arguments
+func parseUndo(config *Config, args []string) ([]string, error) {
+	config.Command = CommandUndo
+
+	undo := flag.NewFlagSet(CommandUndo, flag.ContinueOnError)
+	undo.Usage = PrintUsage
+	undo.IntVar(&config.UndoBatch, "batch", 0, "Batch to undo (default: latest)")
+	undo.BoolVar(&config.ListBatch, "list", false, "List recorded batches")
+	if err := undo.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if config.UndoBatch < 0 {
+		return nil, fmt.Errorf("invalid batch: %d", config.UndoBatch)
+	}
+	return undo.Args(), nil
+}
+
+// splitPatterns splits a comma-separated pattern list, dropping empty entries
+func splitPatterns(value string) []string {
+	var patterns []string
+	for _, p := range strings.Split(value, ",") {
+		p = strings.TrimSpace(p)
+		if p != "" {
+			patterns = append(patterns, p)
+		}
+	}
+	return patterns
+}
+
 // PrintUsage prints usage information
 func PrintUsage() {
 	fmt.Fprintf(os.Stderr, `clip-tagger - Interactive video file classifier and renamer
 
 Usage:
   clip-tagger [OPTIONS] <directory>
+  clip-tagger undo [--batch=<id>] [--list] <directory>
 
 Arguments:
   <directory>    Path to directory containing video files
 
 Options:
   --sort-by=<mode>     Override default sort order
-                       Values: name, modified, created
+                       Values: name, modified, created, recorded, timecode
                        Default: modified
+                       recorded: embedded recording time; clips without
+                         one use their modified time, ties sort by name
+                       timecode: embedded start timecode; clips without
+                         one follow in recorded order
 
   --reset              Delete existing state and start fresh
                        WARNING: This removes all previous classifications
@@ -82,8 +197,67 @@ Options:
   --preview            Show what would be renamed without executing
                        Displays the rename plan and exits
 
+  --recursive          Scan subdirectories (e.g. DCIM/100CANON) as part of
+                       the session. Clips are tracked by their path
+                       relative to <directory>. Use --recursive=false
+                       to turn it off for a saved recursive session
+
+  --include=<globs>    Only tag files matching these comma-separated globs
+                       Example: --include='*.MP4,CLIP/*'
+                       --include= clears the saved patterns
+
+  --exclude=<globs>    Ignore files and folders matching these globs
+                       Hidden folders, renamed_* and the rejects folder
+                       are always ignored
+                       Example: --exclude=THMBNL,PROXY
+                       --exclude= clears the saved patterns
+
+  --layout=<mode>      Where renamed files go in recursive sessions
+                       Values: flatten (session root), preserve (subfolder)
+                       Default: flatten
+
+  --template=<tmpl>    Filename template for renamed files, saved with
+                       the session. The extension is always kept.
+                       Default: '[{seq}_{take}] {group}'
+                       Fields: seq, take, group, stem (original name),
+                         date, camera, counter, rating, stars, circled,
+                         notes, tags
+                       Numbers take a width: {seq:03}
+                       {seq:A} writes sub-groups as letters: 03B
+                       {date} takes a Go layout: {date:2006-01-02}
+                       Markers take their text: {circled:_OK}
+                       {tags} takes a separator: {tags:, }
+                       Modifiers: {group|slug}, |upper, |lower, |title
+                       Example: '{date:2006-01-02}_{seq:03}-{take:02}_{group|slug}'
+
+  --gap=<duration>     Recording gap that suggests a new group, saved
+                       with the session. Clips recorded closer together
+                       are suggested as takes of the same group
+                       Default: 2m
+                       Example: --gap=45s
+
+  --reject-to=<dest>   Where rejected clips are moved when renaming in
+                       place, saved with the session. Never deleted
+                       Values: a folder inside <directory>, or trash
+                       (the desktop trash; Linux only)
+                       Default: _rejected
+
+  --force-unlock       Remove the session lock before starting
+                       Only one clip-tagger may work on a directory at a
+                       time; use this if the other session is gone but
+                       its lock was not released (e.g. a network share)
+
   --help               Show this help message
 
+Undo:
+  Every rename or copy batch is recorded in .clip-tagger-history.json.
+  'undo' restores the original camera names of the latest batch (or the
+  one given by --batch) and updates the session to match. Copies are
+  deleted; the originals were never touched.
+
+  --list               Show recorded batches and exit
+  --batch=<id>         Undo this batch instead of the latest
+
 Examples:
   # Start tagging videos in current directory
   clip-tagger .
@@ -100,6 +274,12 @@ Examples:
   # Preview rename operations without executing
   clip-tagger --preview ./videos
 
+  # Tag a whole camera card, ignoring thumbnail folders
+  clip-tagger --recursive --exclude=THMBNL --layout=preserve /Volumes/CARD
+
+  # Restore the original camera names after a rename
+  clip-tagger undo ./videos
+
 For more information, see the documentation.
 `)
 }
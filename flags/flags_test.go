@@ -5,6 +5,7 @@ import (
 	"flag"
 	"os"
 	"testing"
+	"time"
 )
 
 // resetFlags resets the flag package for testing
@@ -130,6 +131,20 @@ func TestParse_PreviewFlag(t *testing.T) {
 	}
 }
 
+func TestParse_ForceUnlockFlag(t *testing.T) {
+	resetFlags()
+	os.Args = []string{"cmd", "--force-unlock", "/tmp"}
+
+	config, err := Parse()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !config.ForceUnlock {
+		t.Error("expected force-unlock flag to be true")
+	}
+}
+
 func TestParse_MultipleFlags(t *testing.T) {
 	resetFlags()
 	os.Args = []string{"cmd", "--sort-by=name", "--clean-missing", "--preview", "/tmp"}
@@ -172,3 +187,202 @@ func TestParse_DefaultValues(t *testing.T) {
 		t.Error("expected preview flag to be false")
 	}
 }
+
+func TestParse_RecursiveFlags(t *testing.T) {
+	resetFlags()
+	os.Args = []string{"cmd", "--recursive", "--include=*.MP4, CLIP/*", "--exclude=THMBNL,,PROXY", "--layout=preserve", "/tmp"}
+
+	config, err := Parse()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !config.Recursive || !config.RecursiveSet {
+		t.Error("expected recursive flag to be set and true")
+	}
+	if len(config.Include) != 2 || config.Include[0] != "*.MP4" || config.Include[1] != "CLIP/*" {
+		t.Errorf("unexpected include patterns: %v", config.Include)
+	}
+	if len(config.Exclude) != 2 || config.Exclude[0] != "THMBNL" || config.Exclude[1] != "PROXY" {
+		t.Errorf("unexpected exclude patterns: %v", config.Exclude)
+	}
+	if config.Layout != "preserve" {
+		t.Errorf("expected layout 'preserve', got '%s'", config.Layout)
+	}
+}
+
+func TestParse_RecursiveOff(t *testing.T) {
+	resetFlags()
+	os.Args = []string{"cmd", "--recursive=false", "/tmp"}
+
+	config, err := Parse()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if config.Recursive || !config.RecursiveSet {
+		t.Error("expected recursive to be set explicitly to false")
+	}
+
+	resetFlags()
+	os.Args = []string{"cmd", "/tmp"}
+	config, err = Parse()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if config.RecursiveSet || config.IncludeSet || config.ExcludeSet {
+		t.Error("expected scan flags not to be set without the flags")
+	}
+}
+
+func TestParse_ClearPatterns(t *testing.T) {
+	resetFlags()
+	os.Args = []string{"cmd", "--include=", "--exclude=", "/tmp"}
+
+	config, err := Parse()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !config.IncludeSet || !config.ExcludeSet || len(config.Include) != 0 || len(config.Exclude) != 0 {
+		t.Errorf("expected empty patterns set explicitly, got %+v", config)
+	}
+}
+
+func TestParse_InvalidLayout(t *testing.T) {
+	resetFlags()
+	os.Args = []string{"cmd", "--layout=nested", "/tmp"}
+
+	_, err := Parse()
+	if err == nil {
+		t.Fatal("expected error for invalid layout value")
+	}
+}
+
+func TestParse_SortByMetadataModes(t *testing.T) {
+	for _, mode := range []string{"recorded", "timecode"} {
+		resetFlags()
+		os.Args = []string{"cmd", "--sort-by=" + mode, "/tmp"}
+
+		config, err := Parse()
+		if err != nil {
+			t.Fatalf("unexpected error for %s: %v", mode, err)
+		}
+
+		if config.SortBy != mode {
+			t.Errorf("expected sort-by '%s', got '%s'", mode, config.SortBy)
+		}
+	}
+}
+
+func TestParse_UndoCommand(t *testing.T) {
+	resetFlags()
+	os.Args = []string{"cmd", "undo", "--batch=3", "/tmp"}
+
+	config, err := Parse()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if config.Command != CommandUndo {
+		t.Errorf("expected command 'undo', got '%s'", config.Command)
+	}
+	if config.UndoBatch != 3 {
+		t.Errorf("expected batch 3, got %d", config.UndoBatch)
+	}
+	if config.Directory != "/tmp" {
+		t.Errorf("expected directory '/tmp', got '%s'", config.Directory)
+	}
+}
+
+func TestParse_UndoList(t *testing.T) {
+	resetFlags()
+	os.Args = []string{"cmd", "undo", "--list", "/tmp"}
+
+	config, err := Parse()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !config.ListBatch {
+		t.Error("expected list flag to be true")
+	}
+	if config.UndoBatch != 0 {
+		t.Errorf("expected latest batch (0), got %d", config.UndoBatch)
+	}
+}
+
+func TestParse_UndoMissingDirectory(t *testing.T) {
+	resetFlags()
+	os.Args = []string{"cmd", "undo"}
+
+	if _, err := Parse(); err == nil {
+		t.Fatal("expected error for missing directory")
+	}
+}
+
+func TestParse_Template(t *testing.T) {
+	resetFlags()
+	os.Args = []string{"cmd", "--template={date:2006-01-02}_{seq:03}-{take:02}_{group|slug}", "/tmp"}
+
+	config, err := Parse()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if config.Template != "{date:2006-01-02}_{seq:03}-{take:02}_{group|slug}" {
+		t.Errorf("unexpected template: %s", config.Template)
+	}
+}
+
+func TestParse_InvalidTemplate(t *testing.T) {
+	resetFlags()
+	os.Args = []string{"cmd", "--template={scene}_{take}", "/tmp"}
+
+	if _, err := Parse(); err == nil {
+		t.Fatal("expected error for unknown template field")
+	}
+}
+
+func TestParse_Gap(t *testing.T) {
+	resetFlags()
+	os.Args = []string{"cmd", "--gap=45s", "/tmp"}
+
+	config, err := Parse()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if config.Gap != 45*time.Second {
+		t.Errorf("unexpected gap: %v", config.Gap)
+	}
+
+	resetFlags()
+	os.Args = []string{"cmd", "--gap=-1m", "/tmp"}
+	if _, err := Parse(); err == nil {
+		t.Fatal("expected error for a negative gap")
+	}
+}
+
+func TestParse_RejectTo(t *testing.T) {
+	for value, expected := range map[string]string{
+		"trash":         "trash",
+		"_rejected":     "_rejected",
+		"out/rejected/": "out/rejected",
+	} {
+		resetFlags()
+		os.Args = []string{"cmd", "--reject-to=" + value, "/tmp"}
+		config, err := Parse()
+		if err != nil {
+			t.Fatalf("unexpected error for %s: %v", value, err)
+		}
+		if config.RejectTo != expected {
+			t.Errorf("expected %s, got %s", expected, config.RejectTo)
+		}
+	}
+
+	for _, value := range []string{"/tmp/rejected", "..", "../rejected", "."} {
+		resetFlags()
+		os.Args = []string{"cmd", "--reject-to=" + value, "/tmp"}
+		if _, err := Parse(); err == nil {
+			t.Errorf("expected error for %s", value)
+		}
+	}
+}
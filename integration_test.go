@@ -7,6 +7,7 @@ import (
 	"testing"
 	"time"
 
+	"clip-tagger/flags"
 	"clip-tagger/renamer"
 	"clip-tagger/scanner"
 	"clip-tagger/state"
@@ -289,8 +290,21 @@ func TestConflictDetection(t *testing.T) {
 		t.Errorf("expected conflict file to contain 'existing', got '%s'", string(originalContent))
 	}
 
-	// Note: os.Rename will overwrite on Unix systems, which is why we detect conflicts first.
-	// In a real application, the UI would warn the user before proceeding with the rename.
+	// Step 5: Renaming in place must refuse rather than overwrite
+	if err := renamer.RenameInPlace(renames); err == nil {
+		t.Fatal("expected rename to be refused")
+	}
+
+	content, err := os.ReadFile(conflictFile)
+	if err != nil {
+		t.Fatalf("failed to read conflict file: %v", err)
+	}
+	if string(content) != "existing" {
+		t.Errorf("conflict file was overwritten: '%s'", string(content))
+	}
+	if _, err := os.Stat(originalPath); err != nil {
+		t.Errorf("source file should be untouched: %v", err)
+	}
 }
 
 // TestAutoSaveCheckpoints tests that state persists at checkpoints
@@ -373,10 +387,11 @@ func TestAutoSaveCheckpoints(t *testing.T) {
 		t.Fatalf("backup failed: %v", err)
 	}
 
-	backupPath := statePath + ".bak"
-	if _, err := os.Stat(backupPath); os.IsNotExist(err) {
-		t.Error("expected backup file to exist")
+	backups := state.Backups(tmpDir)
+	if len(backups) != 1 {
+		t.Fatalf("expected 1 backup, got %v", backups)
 	}
+	backupPath := backups[0]
 
 	// Verify backup contains same data
 	loadedBackup, err := state.Load(backupPath)
@@ -759,6 +774,126 @@ func TestMultipleGroups(t *testing.T) {
 	}
 }
 
+// TestUndoLatestBatch tests that undo restores camera names and the state
+func TestUndoLatestBatch(t *testing.T) {
+	tmpDir := t.TempDir()
+	createTestVideoFiles(t, tmpDir, []string{"C0001.MP4", "C0002.MP4"})
+
+	// Classify and rename, recording the batch as the completion screen does
+	st := state.NewState(tmpDir, state.SortByName)
+	intro := state.NewGroup("intro", 1)
+	st.Groups = append(st.Groups, intro)
+	st.AddOrUpdateClassification("C0001.MP4", intro.ID)
+	st.AddOrUpdateClassification("C0002.MP4", intro.ID)
+
+	var renames []renamer.Rename
+	for _, c := range st.Classifications {
+		renames = append(renames, renamer.Rename{
+			OriginalPath: filepath.Join(tmpDir, c.File),
+			TargetPath:   renamer.GenerateTargetPath(tmpDir, filepath.Join(tmpDir, c.File), intro.Order, c.TakeNumber, intro.Name),
+		})
+	}
+	if err := renamer.RenameInPlace(renames); err != nil {
+		t.Fatalf("rename failed: %v", err)
+	}
+
+	historyPath := filepath.Join(tmpDir, renamer.HistoryFileName)
+	history, _ := renamer.LoadHistory(historyPath)
+	if _, err := history.Record(renamer.BatchModeRename, tmpDir, "", renames); err != nil {
+		t.Fatalf("record failed: %v", err)
+	}
+	if err := history.Save(historyPath); err != nil {
+		t.Fatal(err)
+	}
+
+	st.RenameFiles(map[string]string{
+		"C0001.MP4": "[01_01] intro.MP4",
+		"C0002.MP4": "[01_02] intro.MP4",
+	})
+	if err := st.Save(state.StateFilePath(tmpDir)); err != nil {
+		t.Fatal(err)
+	}
+
+	// Undo
+	if err := runUndo(tmpDir, &flags.Config{Command: flags.CommandUndo}); err != nil {
+		t.Fatalf("undo failed: %v", err)
+	}
+
+	for _, name := range []string{"C0001.MP4", "C0002.MP4"} {
+		if _, err := os.Stat(filepath.Join(tmpDir, name)); err != nil {
+			t.Errorf("expected %s to be restored", name)
+		}
+	}
+
+	loaded, err := state.Load(state.StateFilePath(tmpDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := loaded.GetClassification("C0001.MP4"); !ok {
+		t.Error("expected state to point back at C0001.MP4")
+	}
+
+	// Nothing left to undo
+	if err := runUndo(tmpDir, &flags.Config{Command: flags.CommandUndo}); err == nil {
+		t.Error("expected error when nothing is left to undo")
+	}
+}
+
+func TestUndoRejectedClips(t *testing.T) {
+	tmpDir := t.TempDir()
+	createTestVideoFiles(t, tmpDir, []string{"C0001.MP4"})
+
+	st := state.NewState(tmpDir, state.SortByName)
+	st.RejectTo = "outtakes"
+	st.Skip("C0001.MP4")
+	st.SetDisposition("C0001.MP4", state.DispositionReject)
+
+	renames := []renamer.Rename{{
+		OriginalPath: filepath.Join(tmpDir, "C0001.MP4"),
+		TargetPath:   st.RejectPath("C0001.MP4"),
+		Reject:       true,
+	}}
+	if err := renamer.RenameInPlace(renames); err != nil {
+		t.Fatalf("reject failed: %v", err)
+	}
+	historyPath := filepath.Join(tmpDir, renamer.HistoryFileName)
+	history, _ := renamer.LoadHistory(historyPath)
+	if _, err := history.Record(renamer.BatchModeRename, tmpDir, "", renames); err != nil {
+		t.Fatalf("record failed: %v", err)
+	}
+	if err := history.Save(historyPath); err != nil {
+		t.Fatal(err)
+	}
+	st.Unskip("C0001.MP4")
+	if err := st.Save(state.StateFilePath(tmpDir)); err != nil {
+		t.Fatal(err)
+	}
+
+	// A rescan never picks up the rejects folder
+	scanned, err := scanner.NewScannerWithOptions(tmpDir, scanner.Options{Recursive: true, Exclude: st.ScanExcludes()}).Scan(scanner.SortByName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(scanned.Files) != 0 {
+		t.Errorf("expected the rejects folder to be excluded, got %v", scanned.Files)
+	}
+
+	if err := runUndo(tmpDir, &flags.Config{Command: flags.CommandUndo}); err != nil {
+		t.Fatalf("undo failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "C0001.MP4")); err != nil {
+		t.Error("expected C0001.MP4 to be restored")
+	}
+
+	loaded, err := state.Load(state.StateFilePath(tmpDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !loaded.IsSkipped("C0001.MP4") || loaded.Disposition("C0001.MP4") != state.DispositionReject {
+		t.Error("expected the restored clip to be rejected again")
+	}
+}
+
 // Helper function to create test video files
 func createTestVideoFiles(t *testing.T, dir string, filenames []string) {
 	t.Helper()
@@ -5,6 +5,7 @@ import (
 	"clip-tagger/renamer"
 	"clip-tagger/state"
 	"clip-tagger/ui"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -20,12 +21,18 @@ var (
 )
 
 func main() {
+	os.Exit(run())
+}
+
+// run executes the command line and returns the process exit code, so
+// deferred cleanup such as releasing the session lock always happens
+func run() int {
 	// Parse command-line flags
 	config, err := flags.Parse()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n\n", err)
 		flags.PrintUsage()
-		os.Exit(1)
+		return 1
 	}
 
 	directory := config.Directory
@@ -33,20 +40,82 @@ func main() {
 	// Validate directory exists
 	if _, err := os.Stat(directory); os.IsNotExist(err) {
 		fmt.Fprintf(os.Stderr, "Error: directory '%s' does not exist\n", directory)
-		os.Exit(1)
+		return 1
+	}
+
+	// Handle --force-unlock flag: remove a lock the user knows is abandoned
+	if config.ForceUnlock {
+		if err := state.ForceUnlock(directory); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		fmt.Println("Session lock removed")
+	}
+
+	// Take the session lock so two instances can't overwrite each other's
+	// state. Preview only reads, so it may run alongside another session.
+	lock, err := state.AcquireLock(directory)
+	var locked *state.LockedError
+	switch {
+	case errors.As(err, &locked):
+		readOnly := config.Preview && !config.Reset && !config.CleanMissing && config.Command == ""
+		if !readOnly {
+			if config.Command != "" || config.Reset || config.CleanMissing {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				fmt.Fprintln(os.Stderr, "Run with --force-unlock if that session is no longer running")
+				return 1
+			}
+			// Let the startup screen explain who holds the lock
+			model := ui.NewModel(state.NewState(directory, state.SortByModifiedTime), directory)
+			model.SetLockStatus(&locked.Holder, nil)
+			return runProgram(model)
+		}
+	case err != nil:
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	default:
+		defer lock.Release()
+	}
+
+	// Roll back a rename batch that was interrupted before it finished
+	journalPath := filepath.Join(directory, renamer.JournalFileName)
+	if lock == nil {
+		// Another session holds the lock; leave its files alone
+	} else if recovered, err := renamer.RecoverJournal(journalPath); err != nil {
+		fmt.Fprintf(os.Stderr, "Error recovering interrupted rename: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Resolve the files listed in %s before continuing\n", journalPath)
+		return 1
+	} else if recovered {
+		fmt.Println("Rolled back an interrupted rename; files are back under their original names")
+	}
+
+	// Handle undo command: restore a previous batch and exit
+	if config.Command == flags.CommandUndo {
+		if err := runUndo(directory, config); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		return 0
 	}
 
 	// Handle --reset flag: delete state file
 	if config.Reset {
 		statePath := state.StateFilePath(directory)
+		if state.StateExists(directory) {
+			// Keep the old session as a backup generation in case reset was a mistake
+			if err := state.BackupState(directory); err != nil {
+				fmt.Fprintf(os.Stderr, "Error backing up state file: %v\n", err)
+				return 1
+			}
+		}
 		if err := os.Remove(statePath); err != nil && !os.IsNotExist(err) {
 			fmt.Fprintf(os.Stderr, "Error deleting state file: %v\n", err)
-			os.Exit(1)
+			return 1
 		}
 		fmt.Println("State reset successfully")
 		// If only reset was requested, exit
 		if !config.CleanMissing && !config.Preview {
-			return
+			return 0
 		}
 	}
 
@@ -60,6 +129,10 @@ func main() {
 			sortBy = state.SortByModifiedTime
 		case "created":
 			sortBy = state.SortByCreatedTime
+		case "recorded":
+			sortBy = state.SortByRecorded
+		case "timecode":
+			sortBy = state.SortByTimecode
 		}
 	}
 
@@ -70,7 +143,16 @@ func main() {
 		appState, err = state.Load(statePath)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error loading state: %v\n", err)
-			os.Exit(1)
+			return 1
+		}
+		if appState.RestoredFrom != "" {
+			fmt.Printf("State file was unreadable; restored %s (unreadable copy kept as %s.corrupt)\n",
+				filepath.Base(appState.RestoredFrom), state.StateFileName)
+		} else if lock != nil {
+			// One backup generation per session, taken before anything changes
+			if err := state.BackupState(directory); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: could not back up state: %v\n", err)
+			}
 		}
 		// Override sort order if flag is set
 		if config.SortBy != "" {
@@ -80,6 +162,29 @@ func main() {
 		appState = state.NewState(directory, sortBy)
 	}
 
+	// Apply scan options; flags override what was saved with the session
+	if config.RecursiveSet {
+		appState.Recursive = config.Recursive
+	}
+	if config.IncludeSet {
+		appState.Include = config.Include
+	}
+	if config.ExcludeSet {
+		appState.Exclude = config.Exclude
+	}
+	if config.Layout != "" {
+		appState.Layout = renamer.Layout(config.Layout)
+	}
+	if config.Template != "" {
+		appState.NameTemplate = config.Template
+	}
+	if config.Gap > 0 {
+		appState.SuggestGap = config.Gap
+	}
+	if config.RejectTo != "" {
+		appState.RejectTo = config.RejectTo
+	}
+
 	// Handle --clean-missing flag: remove files that no longer exist
 	if config.CleanMissing {
 		cleanedCount := cleanMissingFiles(appState)
@@ -87,29 +192,119 @@ func main() {
 		if cleanedCount > 0 {
 			if err := appState.Save(statePath); err != nil {
 				fmt.Fprintf(os.Stderr, "Error saving state: %v\n", err)
-				os.Exit(1)
+				return 1
 			}
 		}
 		// If only clean-missing was requested, exit
 		if !config.Preview {
-			return
+			return 0
 		}
 	}
 
 	// Handle --preview flag: show what would be renamed
 	if config.Preview {
 		showPreview(appState)
-		return
+		return 0
 	}
 
 	// Create and run the Bubbletea program
 	model := ui.NewModel(appState, directory)
+	model.SetLockStatus(nil, lock.Stale)
+	return runProgram(model)
+}
+
+// runProgram runs the TUI and returns the exit code
+func runProgram(model ui.Model) int {
+	// Custom key bindings are checked for conflicts before the TUI starts.
+	// Without a config directory the defaults are used.
+	if path, err := ui.KeymapPath(); err == nil {
+		keys, err := ui.LoadKeymap(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		model.SetKeymap(keys)
+	}
+
 	program := tea.NewProgram(model)
 
 	if _, err := program.Run(); err != nil {
 		fmt.Fprintf(os.Stderr, "Error running program: %v\n", err)
-		os.Exit(1)
+		return 1
+	}
+	return 0
+}
+
+// runUndo lists recorded batches or reverses one, bringing the state back in line
+func runUndo(directory string, config *flags.Config) error {
+	historyPath := filepath.Join(directory, renamer.HistoryFileName)
+	history, err := renamer.LoadHistory(historyPath)
+	if err != nil {
+		return err
+	}
+
+	if config.ListBatch {
+		if len(history.Batches) == 0 {
+			fmt.Println("No batches recorded")
+			return nil
+		}
+		for _, b := range history.Batches {
+			status := ""
+			if b.UndoneAt != nil {
+				status = " (undone)"
+			}
+			fmt.Printf("  %d  %s  %-6s  %d file(s)%s\n",
+				b.ID, b.Time.Format("2006-01-02 15:04:05"), b.Mode, len(b.Entries), status)
+		}
+		return nil
+	}
+
+	batch := history.Latest()
+	if config.UndoBatch != 0 {
+		batch = history.Find(config.UndoBatch)
+		if batch == nil {
+			return fmt.Errorf("no batch %d recorded", config.UndoBatch)
+		}
+	}
+	if batch == nil {
+		return fmt.Errorf("nothing to undo")
 	}
+
+	journalPath := filepath.Join(directory, renamer.JournalFileName)
+	if err := renamer.UndoBatch(directory, batch, journalPath); err != nil {
+		return fmt.Errorf("undo batch %d: %w", batch.ID, err)
+	}
+	if err := history.Save(historyPath); err != nil {
+		return err
+	}
+
+	// Point the session back at the original names
+	if state.StateExists(directory) {
+		statePath := state.StateFilePath(directory)
+		appState, err := state.Load(statePath)
+		if err != nil {
+			return fmt.Errorf("files restored, but loading state failed: %w", err)
+		}
+		appState.RenameFiles(batch.RestoredNames())
+		// Rejected clips come back to the session still rejected
+		for _, file := range batch.Rejected() {
+			appState.Skip(file)
+			appState.SetDisposition(file, state.DispositionReject)
+		}
+		if batch.Mode == renamer.BatchModeCopy {
+			appState.Directory = directory
+		}
+		if err := appState.Save(statePath); err != nil {
+			return fmt.Errorf("files restored, but saving state failed: %w", err)
+		}
+	}
+
+	if batch.Mode == renamer.BatchModeCopy {
+		fmt.Printf("Undid batch %d: removed %d copied file(s)\n", batch.ID, len(batch.Entries))
+	} else {
+		fmt.Printf("Undid batch %d: restored %d original filename(s)\n", batch.ID, len(batch.Entries))
+	}
+	return nil
 }
 
 // cleanMissingFiles removes classifications for files that no longer exist
@@ -139,21 +334,14 @@ func showPreview(appState *state.State) {
 
 	// Build list of rename operations from classifications
 	var renames []renamer.Rename
+	numbers := appState.NameNumbers()
 	for _, classification := range appState.Classifications {
-		group := appState.FindGroupByID(classification.GroupID)
-		if group == nil {
+		targetPath, ok := appState.TargetPath(classification, numbers)
+		if !ok {
 			// Skip if group not found (shouldn't happen)
 			continue
 		}
-
 		originalPath := filepath.Join(appState.Directory, classification.File)
-		targetPath := renamer.GenerateTargetPath(
-			appState.Directory,
-			originalPath,
-			group.Order,
-			classification.TakeNumber,
-			group.Name,
-		)
 
 		renames = append(renames, renamer.Rename{
 			OriginalPath: originalPath,
@@ -0,0 +1,443 @@
+// metadata/isobmff.go
+package metadata
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"time"
+)
+
+// maxMoovSize bounds how much of the movie header is read into memory
+const maxMoovSize = 64 << 20
+
+// mp4Epoch is the reference time for ISO-BMFF creation timestamps
+var mp4Epoch = time.Date(1904, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// box is an ISO-BMFF atom held in memory
+type box struct {
+	kind    string
+	payload []byte
+}
+
+// track collects what is needed from a single trak atom
+type track struct {
+	handler      string
+	width        int
+	height       int
+	timescale    uint32
+	codec        string
+	sampleCount  uint64
+	sampleTotal  uint64
+	chunkOffset  int64
+	hasChunk     bool
+	tcFlags      uint32
+	tcFrames     int
+	tcTimescale  uint32
+	tcFrameDelta uint32
+}
+
+// ReadISOBMFF parses MP4/MOV metadata from the movie header (moov) atom
+func ReadISOBMFF(r io.ReadSeeker) (*Metadata, error) {
+	moov, err := findTopLevelBox(r, "moov")
+	if err != nil {
+		return nil, err
+	}
+
+	md := &Metadata{}
+	var tracks []track
+	var dayText, appleDate string
+
+	for _, child := range parseBoxes(moov) {
+		switch child.kind {
+		case "mvhd":
+			created, duration := parseMvhd(child.payload)
+			if !created.IsZero() {
+				md.RecordedTime = created
+			}
+			md.Duration = duration
+		case "trak":
+			tracks = append(tracks, parseTrak(child.payload))
+		case "udta":
+			day, model := parseUdta(child.payload)
+			if day != "" {
+				dayText = day
+			}
+			if model != "" && md.Camera == "" {
+				md.Camera = model
+			}
+		case "meta":
+			values := parseAppleMeta(child.payload)
+			if date := values["com.apple.quicktime.creationdate"]; date != "" {
+				appleDate = date
+			}
+			if model := values["com.apple.quicktime.model"]; model != "" {
+				md.Camera = model
+			}
+		}
+	}
+
+	// Text dates are preferred over mvhd, which many cameras leave at zero
+	// or fill with local time
+	for _, value := range []string{dayText, appleDate} {
+		if t, ok := parseRecordedTime(value); ok {
+			md.RecordedTime = t
+		}
+	}
+
+	for _, t := range tracks {
+		switch t.handler {
+		case "vide":
+			if md.Width == 0 {
+				md.Width, md.Height = t.width, t.height
+				md.Codec = codecName(t.codec)
+				if t.sampleTotal > 0 && t.timescale > 0 {
+					md.FrameRate = float64(t.sampleCount) * float64(t.timescale) / float64(t.sampleTotal)
+				}
+			}
+		case "tmcd":
+			if md.Timecode == "" && t.hasChunk {
+				md.Timecode = readTimecodeSample(r, t)
+			}
+		}
+	}
+
+	return md, nil
+}
+
+// findTopLevelBox walks the top-level atoms and returns the payload of the first match
+func findTopLevelBox(r io.ReadSeeker, kind string) ([]byte, error) {
+	var offset int64
+	header := make([]byte, 16)
+
+	for {
+		if err := readAt(r, offset, header[:8]); err != nil {
+			if offset == 0 {
+				return nil, ErrUnsupported
+			}
+			return nil, fmt.Errorf("%s atom not found", kind)
+		}
+
+		size := int64(binary.BigEndian.Uint32(header[:4]))
+		boxKind := string(header[4:8])
+		headerLen := int64(8)
+
+		if offset == 0 && !isKnownTopLevel(boxKind) {
+			return nil, ErrUnsupported
+		}
+
+		switch size {
+		case 0:
+			// Box extends to end of file
+			end, err := r.Seek(0, io.SeekEnd)
+			if err != nil {
+				return nil, err
+			}
+			size = end - offset
+		case 1:
+			if err := readAt(r, offset+8, header[8:16]); err != nil {
+				return nil, fmt.Errorf("read large box size: %w", err)
+			}
+			size = int64(binary.BigEndian.Uint64(header[8:16]))
+			headerLen = 16
+		}
+
+		if size < headerLen {
+			return nil, fmt.Errorf("invalid %s atom size", boxKind)
+		}
+
+		if boxKind == kind {
+			payloadLen := size - headerLen
+			if payloadLen > maxMoovSize {
+				return nil, fmt.Errorf("%s atom too large", kind)
+			}
+			payload := make([]byte, payloadLen)
+			if err := readAt(r, offset+headerLen, payload); err != nil {
+				return nil, fmt.Errorf("read %s atom: %w", kind, err)
+			}
+			return payload, nil
+		}
+
+		offset += size
+	}
+}
+
+// isKnownTopLevel reports whether an atom type may start an ISO-BMFF file
+func isKnownTopLevel(kind string) bool {
+	switch kind {
+	case "ftyp", "moov", "mdat", "free", "skip", "wide", "pnot", "uuid":
+		return true
+	default:
+		return false
+	}
+}
+
+// parseBoxes splits a buffer into its child atoms, stopping at the first malformed one
+func parseBoxes(data []byte) []box {
+	var boxes []box
+	for len(data) >= 8 {
+		size := uint64(binary.BigEndian.Uint32(data[:4]))
+		kind := string(data[4:8])
+		headerLen := uint64(8)
+
+		switch size {
+		case 0:
+			size = uint64(len(data))
+		case 1:
+			if len(data) < 16 {
+				return boxes
+			}
+			size = binary.BigEndian.Uint64(data[8:16])
+			headerLen = 16
+		}
+
+		if size < headerLen || size > uint64(len(data)) {
+			return boxes
+		}
+
+		boxes = append(boxes, box{kind: kind, payload: data[headerLen:size]})
+		data = data[size:]
+	}
+	return boxes
+}
+
+// findBox returns the payload of the first child atom of the given type
+func findBox(data []byte, kind string) []byte {
+	for _, b := range parseBoxes(data) {
+		if b.kind == kind {
+			return b.payload
+		}
+	}
+	return nil
+}
+
+// parseMvhd reads the creation time and duration from a movie header
+func parseMvhd(data []byte) (time.Time, time.Duration) {
+	var created, timescale, duration uint64
+
+	if len(data) < 1 {
+		return time.Time{}, 0
+	}
+
+	if data[0] == 1 {
+		if len(data) < 32 {
+			return time.Time{}, 0
+		}
+		created = binary.BigEndian.Uint64(data[4:12])
+		timescale = uint64(binary.BigEndian.Uint32(data[20:24]))
+		duration = binary.BigEndian.Uint64(data[24:32])
+	} else {
+		if len(data) < 20 {
+			return time.Time{}, 0
+		}
+		created = uint64(binary.BigEndian.Uint32(data[4:8]))
+		timescale = uint64(binary.BigEndian.Uint32(data[12:16]))
+		duration = uint64(binary.BigEndian.Uint32(data[16:20]))
+	}
+
+	// Values beyond ~270 years after 1904 are garbage rather than timestamps
+	var createdTime time.Time
+	if created > 0 && created < 1<<33 {
+		createdTime = mp4Epoch.Add(time.Duration(created) * time.Second)
+	}
+
+	var d time.Duration
+	if timescale > 0 {
+		d = time.Duration(float64(duration) / float64(timescale) * float64(time.Second))
+	}
+
+	return createdTime, d
+}
+
+// parseTrak reads the handler, dimensions, codec, sample timing and timecode
+// description from a track atom
+func parseTrak(data []byte) track {
+	var t track
+
+	if tkhd := findBox(data, "tkhd"); len(tkhd) > 0 {
+		// Width and height are 16.16 fixed point at the end of the header
+		offset := 76
+		if tkhd[0] == 1 {
+			offset = 88
+		}
+		if len(tkhd) >= offset+8 {
+			t.width = int(binary.BigEndian.Uint32(tkhd[offset:offset+4]) >> 16)
+			t.height = int(binary.BigEndian.Uint32(tkhd[offset+4:offset+8]) >> 16)
+		}
+	}
+
+	mdia := findBox(data, "mdia")
+	if mdia == nil {
+		return t
+	}
+
+	if mdhd := findBox(mdia, "mdhd"); len(mdhd) > 0 {
+		if mdhd[0] == 1 && len(mdhd) >= 24 {
+			t.timescale = binary.BigEndian.Uint32(mdhd[20:24])
+		} else if len(mdhd) >= 16 {
+			t.timescale = binary.BigEndian.Uint32(mdhd[12:16])
+		}
+	}
+
+	if hdlr := findBox(mdia, "hdlr"); len(hdlr) >= 12 {
+		t.handler = string(hdlr[8:12])
+	}
+
+	stbl := findBox(findBox(mdia, "minf"), "stbl")
+	if stbl == nil {
+		return t
+	}
+
+	if stsd := findBox(stbl, "stsd"); len(stsd) >= 16 {
+		entry := stsd[8:]
+		t.codec = string(entry[4:8])
+		switch t.handler {
+		case "vide":
+			// VisualSampleEntry: 16-byte entry header, 16 bytes pre-defined/reserved, then width/height
+			if len(entry) >= 36 && t.width == 0 {
+				t.width = int(binary.BigEndian.Uint16(entry[32:34]))
+				t.height = int(binary.BigEndian.Uint16(entry[34:36]))
+			}
+		case "tmcd":
+			// TimecodeSampleEntry: reserved(4) flags(4) timescale(4) frameDuration(4) numberOfFrames(1)
+			if len(entry) >= 33 {
+				t.tcFlags = binary.BigEndian.Uint32(entry[20:24])
+				t.tcTimescale = binary.BigEndian.Uint32(entry[24:28])
+				t.tcFrameDelta = binary.BigEndian.Uint32(entry[28:32])
+				t.tcFrames = int(entry[32])
+			}
+		}
+	}
+
+	if stts := findBox(stbl, "stts"); len(stts) >= 8 {
+		count := int(binary.BigEndian.Uint32(stts[4:8]))
+		entries := stts[8:]
+		for i := 0; i < count && len(entries) >= 8; i++ {
+			samples := uint64(binary.BigEndian.Uint32(entries[0:4]))
+			delta := uint64(binary.BigEndian.Uint32(entries[4:8]))
+			t.sampleCount += samples
+			t.sampleTotal += samples * delta
+			entries = entries[8:]
+		}
+	}
+
+	if stco := findBox(stbl, "stco"); len(stco) >= 12 && binary.BigEndian.Uint32(stco[4:8]) > 0 {
+		t.chunkOffset = int64(binary.BigEndian.Uint32(stco[8:12]))
+		t.hasChunk = true
+	} else if co64 := findBox(stbl, "co64"); len(co64) >= 16 && binary.BigEndian.Uint32(co64[4:8]) > 0 {
+		t.chunkOffset = int64(binary.BigEndian.Uint64(co64[8:16]))
+		t.hasChunk = true
+	}
+
+	return t
+}
+
+// readTimecodeSample reads the first frame number of a timecode track and formats it
+func readTimecodeSample(r io.ReadSeeker, t track) string {
+	buf := make([]byte, 4)
+	if err := readAt(r, t.chunkOffset, buf); err != nil {
+		return ""
+	}
+
+	fps := t.tcFrames
+	if fps == 0 && t.tcFrameDelta > 0 {
+		fps = int(float64(t.tcTimescale)/float64(t.tcFrameDelta) + 0.5)
+	}
+
+	return formatTimecode(uint64(binary.BigEndian.Uint32(buf)), fps, t.tcFlags&1 != 0)
+}
+
+// parseUdta returns the ©day recording date and ©mod camera model from a
+// user data atom. Both the QuickTime form (length-prefixed text) and the
+// iTunes form (meta/ilst) are read.
+func parseUdta(data []byte) (day, model string) {
+	for _, b := range parseBoxes(data) {
+		switch b.kind {
+		case "\xa9day":
+			if text := udtaText(b.payload); text != "" {
+				day = text
+			}
+		case "\xa9mod":
+			if text := udtaText(b.payload); text != "" {
+				model = text
+			}
+		case "meta":
+			ilst := findBox(metaChildren(b.payload), "ilst")
+			if item := findBox(ilst, "\xa9day"); item != nil && day == "" {
+				day = dataBoxValue(item)
+			}
+			if item := findBox(ilst, "\xa9mod"); item != nil && model == "" {
+				model = dataBoxValue(item)
+			}
+		}
+	}
+	return day, model
+}
+
+// udtaText decodes a QuickTime user data text atom (length, language, text)
+func udtaText(payload []byte) string {
+	if len(payload) < 4 {
+		return ""
+	}
+	n := int(binary.BigEndian.Uint16(payload[0:2]))
+	if 4+n > len(payload) {
+		return ""
+	}
+	return string(payload[4 : 4+n])
+}
+
+// parseAppleMeta returns the values of a QuickTime meta atom (keys + ilst)
+// by key name, e.g. com.apple.quicktime.creationdate as written by iPhones
+func parseAppleMeta(data []byte) map[string]string {
+	children := metaChildren(data)
+	keys := findBox(children, "keys")
+	ilst := findBox(children, "ilst")
+	if len(keys) < 8 || ilst == nil {
+		return nil
+	}
+
+	// Keys are referenced from ilst by their 1-based index
+	count := int(binary.BigEndian.Uint32(keys[4:8]))
+	entries := keys[8:]
+	names := make(map[int]string)
+	for i := 1; i <= count && len(entries) >= 8; i++ {
+		size := int(binary.BigEndian.Uint32(entries[0:4]))
+		if size < 8 || size > len(entries) {
+			break
+		}
+		names[i] = string(entries[8:size])
+		entries = entries[size:]
+	}
+
+	values := make(map[string]string)
+	for _, item := range parseBoxes(ilst) {
+		if len(item.kind) != 4 {
+			continue
+		}
+		if name, ok := names[int(binary.BigEndian.Uint32([]byte(item.kind)))]; ok {
+			values[name] = dataBoxValue(item.payload)
+		}
+	}
+	return values
+}
+
+// metaChildren returns the child atoms of a meta atom. ISO meta atoms carry
+// a version/flags word before their children; QuickTime ones do not.
+func metaChildren(data []byte) []byte {
+	if len(data) >= 8 && string(data[4:8]) == "hdlr" {
+		return data
+	}
+	if len(data) >= 4 {
+		return data[4:]
+	}
+	return nil
+}
+
+// dataBoxValue returns the text value of the data atom inside an ilst item
+func dataBoxValue(item []byte) string {
+	data := findBox(item, "data")
+	if len(data) < 8 {
+		return ""
+	}
+	return string(data[8:])
+}
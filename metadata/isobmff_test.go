@@ -0,0 +1,238 @@
+// metadata/isobmff_test.go
+package metadata
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// mp4Box builds an atom from its type and concatenated payload parts
+func mp4Box(kind string, parts ...[]byte) []byte {
+	payload := bytes.Join(parts, nil)
+	buf := make([]byte, 8, 8+len(payload))
+	binary.BigEndian.PutUint32(buf[0:4], uint32(8+len(payload)))
+	copy(buf[4:8], kind)
+	return append(buf, payload...)
+}
+
+func u16(v uint16) []byte {
+	b := make([]byte, 2)
+	binary.BigEndian.PutUint16(b, v)
+	return b
+}
+
+func u32(v uint32) []byte {
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint32(b, v)
+	return b
+}
+
+func zeros(n int) []byte {
+	return make([]byte, n)
+}
+
+// buildMP4 creates a minimal MOV with a 1920x1080 H.264 track at 25 fps,
+// a timecode track starting at frame tcFrame, and an optional ©day date
+func buildMP4(created time.Time, day string, tcFrame uint32) []byte {
+	ftyp := mp4Box("ftyp", []byte("qt  "), u32(0), []byte("qt  "))
+
+	build := func(chunkOffset uint32) []byte {
+		mvhd := mp4Box("mvhd",
+			u32(0), // version/flags
+			u32(uint32(created.Sub(mp4Epoch)/time.Second)),
+			u32(0),
+			u32(1000),  // timescale
+			u32(42500), // duration: 42.5s
+			zeros(80),
+		)
+
+		tkhd := mp4Box("tkhd", u32(0), zeros(72), u32(1920<<16), u32(1080<<16))
+		videoTrak := mp4Box("trak",
+			tkhd,
+			mp4Box("mdia",
+				mp4Box("mdhd", u32(0), u32(0), u32(0), u32(12800), u32(0), zeros(4)),
+				mp4Box("hdlr", u32(0), u32(0), []byte("vide"), zeros(12)),
+				mp4Box("minf",
+					mp4Box("stbl",
+						mp4Box("stsd", u32(0), u32(1),
+							mp4Box("avc1", zeros(6), u16(1), zeros(16), u16(1920), u16(1080), zeros(50))),
+						mp4Box("stts", u32(0), u32(1), u32(250), u32(512)),
+					),
+				),
+			),
+		)
+
+		tmcdTrak := mp4Box("trak",
+			mp4Box("tkhd", u32(0), zeros(80)),
+			mp4Box("mdia",
+				mp4Box("mdhd", u32(0), u32(0), u32(0), u32(25), u32(0), zeros(4)),
+				mp4Box("hdlr", u32(0), u32(0), []byte("tmcd"), zeros(12)),
+				mp4Box("minf",
+					mp4Box("stbl",
+						mp4Box("stsd", u32(0), u32(1),
+							mp4Box("tmcd", zeros(6), u16(1), u32(0), u32(0), u32(25), u32(1), []byte{25, 0})),
+						mp4Box("stco", u32(0), u32(1), u32(chunkOffset)),
+					),
+				),
+			),
+		)
+
+		parts := [][]byte{mvhd, videoTrak, tmcdTrak}
+		if day != "" {
+			parts = append(parts, mp4Box("udta", mp4Box("\xa9day", u16(uint16(len(day))), u16(0), []byte(day))))
+		}
+		return mp4Box("moov", parts...)
+	}
+
+	moov := build(0)
+	offset := uint32(len(ftyp) + len(moov) + 8)
+	moov = build(offset)
+
+	mdat := mp4Box("mdat", u32(tcFrame))
+	return bytes.Join([][]byte{ftyp, moov, mdat}, nil)
+}
+
+func TestReadISOBMFF(t *testing.T) {
+	created := time.Date(2026, 1, 12, 9, 30, 0, 0, time.UTC)
+	// 10:00:00:12 at 25 fps
+	frame := uint32((10*3600)*25 + 12)
+	data := buildMP4(created, "", frame)
+
+	md, err := ReadISOBMFF(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("read failed: %v", err)
+	}
+
+	if !md.RecordedTime.Equal(created) {
+		t.Errorf("expected recorded time %v, got %v", created, md.RecordedTime)
+	}
+	if md.Duration != 42500*time.Millisecond {
+		t.Errorf("expected duration 42.5s, got %v", md.Duration)
+	}
+	if md.Width != 1920 || md.Height != 1080 {
+		t.Errorf("expected 1920x1080, got %dx%d", md.Width, md.Height)
+	}
+	if md.FrameRate != 25 {
+		t.Errorf("expected 25 fps, got %v", md.FrameRate)
+	}
+	if md.Codec != "H.264" {
+		t.Errorf("expected codec H.264, got %s", md.Codec)
+	}
+	if md.Timecode != "10:00:00:12" {
+		t.Errorf("expected timecode 10:00:00:12, got %s", md.Timecode)
+	}
+}
+
+func TestReadISOBMFF_DayOverridesMvhd(t *testing.T) {
+	created := time.Date(2026, 1, 12, 9, 30, 0, 0, time.UTC)
+	data := buildMP4(created, "2026-01-12T10:30:00+0100", 0)
+
+	md, err := ReadISOBMFF(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("read failed: %v", err)
+	}
+
+	expected := time.Date(2026, 1, 12, 9, 30, 0, 0, time.UTC)
+	if !md.RecordedTime.Equal(expected) {
+		t.Errorf("expected recorded time %v, got %v", expected, md.RecordedTime)
+	}
+	if md.RecordedTime.Format("-0700") != "+0100" {
+		t.Errorf("expected ©day zone to be kept, got %s", md.RecordedTime.Format("-0700"))
+	}
+}
+
+func TestReadISOBMFF_AppleCreationDate(t *testing.T) {
+	modelKey := []byte("com.apple.quicktime.model")
+	dateKey := []byte("com.apple.quicktime.creationdate")
+	keys := mp4Box("keys", u32(0), u32(2),
+		u32(uint32(8+len(modelKey))), []byte("mdta"), modelKey,
+		u32(uint32(8+len(dateKey))), []byte("mdta"), dateKey)
+	value := "2026-01-12T18:04:05-0800"
+	ilst := mp4Box("ilst",
+		mp4Box(string(u32(2)), mp4Box("data", u32(1), u32(0), []byte(value))),
+		mp4Box(string(u32(1)), mp4Box("data", u32(1), u32(0), []byte("iPhone 15 Pro"))))
+	meta := mp4Box("meta", mp4Box("hdlr", u32(0), u32(0), []byte("mdta"), zeros(12)), keys, ilst)
+
+	data := bytes.Join([][]byte{
+		mp4Box("ftyp", []byte("qt  ")),
+		mp4Box("moov", meta),
+	}, nil)
+
+	md, err := ReadISOBMFF(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("read failed: %v", err)
+	}
+
+	expected := time.Date(2026, 1, 13, 2, 4, 5, 0, time.UTC)
+	if !md.RecordedTime.Equal(expected) {
+		t.Errorf("expected recorded time %v, got %v", expected, md.RecordedTime)
+	}
+	if md.Camera != "iPhone 15 Pro" {
+		t.Errorf("expected camera 'iPhone 15 Pro', got %q", md.Camera)
+	}
+}
+
+func TestReadISOBMFF_UdtaModel(t *testing.T) {
+	model := "ILCE-7SM3"
+	udta := mp4Box("udta", mp4Box("\xa9mod", u16(uint16(len(model))), u16(0), []byte(model)))
+	data := bytes.Join([][]byte{
+		mp4Box("ftyp", []byte("qt  ")),
+		mp4Box("moov", udta),
+	}, nil)
+
+	md, err := ReadISOBMFF(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("read failed: %v", err)
+	}
+	if md.Camera != model {
+		t.Errorf("expected camera %q, got %q", model, md.Camera)
+	}
+}
+
+func TestReadISOBMFF_NotAContainer(t *testing.T) {
+	_, err := ReadISOBMFF(bytes.NewReader([]byte("this is just some text, not a movie")))
+	if err != ErrUnsupported {
+		t.Errorf("expected ErrUnsupported, got %v", err)
+	}
+}
+
+func TestReadISOBMFF_MissingMoov(t *testing.T) {
+	data := bytes.Join([][]byte{
+		mp4Box("ftyp", []byte("isom")),
+		mp4Box("mdat", zeros(16)),
+	}, nil)
+
+	if _, err := ReadISOBMFF(bytes.NewReader(data)); err == nil {
+		t.Error("expected error when moov is missing")
+	}
+}
+
+func TestRead_FromFile(t *testing.T) {
+	tmpDir := t.TempDir()
+	created := time.Date(2026, 1, 12, 9, 30, 0, 0, time.UTC)
+
+	path := filepath.Join(tmpDir, "clip.MOV")
+	if err := os.WriteFile(path, buildMP4(created, "", 0), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	md, err := Read(path)
+	if err != nil {
+		t.Fatalf("read failed: %v", err)
+	}
+	if !md.RecordedTime.Equal(created) {
+		t.Errorf("expected recorded time %v, got %v", created, md.RecordedTime)
+	}
+
+	avi := filepath.Join(tmpDir, "clip.avi")
+	if err := os.WriteFile(avi, []byte("RIFF"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Read(avi); err != ErrUnsupported {
+		t.Errorf("expected ErrUnsupported for avi, got %v", err)
+	}
+}
@@ -0,0 +1,318 @@
+// metadata/matroska.go
+package metadata
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"time"
+)
+
+// EBML element IDs used by the Matroska reader
+const (
+	idEBML            = 0x1A45DFA3
+	idSegment         = 0x18538067
+	idInfo            = 0x1549A966
+	idTimestampScale  = 0x2AD7B1
+	idDuration        = 0x4489
+	idDateUTC         = 0x4461
+	idTracks          = 0x1654AE6B
+	idTrackEntry      = 0xAE
+	idTrackType       = 0x83
+	idCodecID         = 0x86
+	idDefaultDuration = 0x23E383
+	idVideo           = 0xE0
+	idPixelWidth      = 0xB0
+	idPixelHeight     = 0xBA
+	idTags            = 0x1254C367
+	idTag             = 0x7373
+	idSimpleTag       = 0x67C8
+	idTagName         = 0x45A3
+	idTagString       = 0x4487
+	idCluster         = 0x1F43B675
+)
+
+// maxElementSize bounds how much of a single metadata element is read into memory
+const maxElementSize = 16 << 20
+
+// unknownSize marks an EBML element whose size was not written
+const unknownSize = ^uint64(0)
+
+// matroskaEpoch is the reference time for Matroska DateUTC values
+var matroskaEpoch = time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// element is an EBML element held in memory
+type element struct {
+	id   uint64
+	data []byte
+}
+
+// ReadMatroska parses MKV/WebM metadata from the segment Info, Tracks and Tags elements
+func ReadMatroska(r io.ReadSeeker) (*Metadata, error) {
+	id, size, headerLen, err := readElementHeader(r, 0)
+	if err != nil || id != idEBML {
+		return nil, ErrUnsupported
+	}
+
+	// Locate the Segment after the EBML header
+	offset := headerLen + int64(size)
+	id, size, headerLen, err = readElementHeader(r, offset)
+	if err != nil || id != idSegment {
+		return nil, fmt.Errorf("segment not found")
+	}
+
+	segmentEnd := int64(math.MaxInt64)
+	if size != unknownSize {
+		segmentEnd = offset + headerLen + int64(size)
+	}
+
+	md := &Metadata{}
+	timestampScale := uint64(1000000)
+	var duration float64
+	var recordedTag string
+
+	offset += headerLen
+	for offset < segmentEnd {
+		id, size, headerLen, err = readElementHeader(r, offset)
+		if err != nil {
+			break // End of file
+		}
+		if size == unknownSize {
+			// Only clusters are written with unknown size in practice and
+			// metadata never follows them, so there is nothing left to read
+			break
+		}
+
+		switch id {
+		case idInfo, idTracks, idTags:
+			if size > maxElementSize {
+				return nil, fmt.Errorf("element 0x%X too large", id)
+			}
+			data := make([]byte, size)
+			if err := readAt(r, offset+headerLen, data); err != nil {
+				return nil, fmt.Errorf("read element 0x%X: %w", id, err)
+			}
+
+			switch id {
+			case idInfo:
+				for _, e := range parseElements(data) {
+					switch e.id {
+					case idTimestampScale:
+						timestampScale = readUint(e.data)
+					case idDuration:
+						duration = readFloat(e.data)
+					case idDateUTC:
+						ns := int64(readUint(e.data))
+						md.RecordedTime = matroskaEpoch.Add(time.Duration(ns))
+					}
+				}
+			case idTracks:
+				applyMatroskaTracks(md, data)
+			case idTags:
+				timecode, recorded := parseMatroskaTags(data)
+				if md.Timecode == "" {
+					md.Timecode = timecode
+				}
+				if recorded != "" {
+					recordedTag = recorded
+				}
+			}
+		}
+
+		offset += headerLen + int64(size)
+	}
+
+	if duration > 0 {
+		md.Duration = time.Duration(duration * float64(timestampScale))
+	}
+	if md.RecordedTime.IsZero() {
+		if t, ok := parseRecordedTime(recordedTag); ok {
+			md.RecordedTime = t
+		}
+	}
+
+	return md, nil
+}
+
+// applyMatroskaTracks fills video properties from the first video TrackEntry
+func applyMatroskaTracks(md *Metadata, data []byte) {
+	for _, entry := range parseElements(data) {
+		if entry.id != idTrackEntry {
+			continue
+		}
+
+		var trackType uint64
+		var codec string
+		var defaultDuration uint64
+		var width, height int
+
+		for _, e := range parseElements(entry.data) {
+			switch e.id {
+			case idTrackType:
+				trackType = readUint(e.data)
+			case idCodecID:
+				codec = strings.TrimRight(string(e.data), "\x00")
+			case idDefaultDuration:
+				defaultDuration = readUint(e.data)
+			case idVideo:
+				for _, v := range parseElements(e.data) {
+					switch v.id {
+					case idPixelWidth:
+						width = int(readUint(v.data))
+					case idPixelHeight:
+						height = int(readUint(v.data))
+					}
+				}
+			}
+		}
+
+		if trackType != 1 {
+			continue
+		}
+
+		md.Width, md.Height = width, height
+		md.Codec = codecName(codec)
+		if defaultDuration > 0 {
+			md.FrameRate = float64(time.Second) / float64(defaultDuration)
+		}
+		return
+	}
+}
+
+// parseMatroskaTags returns the TIMECODE and DATE_RECORDED simple tag values
+func parseMatroskaTags(data []byte) (timecode, recorded string) {
+	for _, tag := range parseElements(data) {
+		if tag.id != idTag {
+			continue
+		}
+		for _, simple := range parseElements(tag.data) {
+			if simple.id != idSimpleTag {
+				continue
+			}
+			var name, value string
+			for _, e := range parseElements(simple.data) {
+				switch e.id {
+				case idTagName:
+					name = strings.ToUpper(strings.TrimRight(string(e.data), "\x00"))
+				case idTagString:
+					value = strings.TrimRight(string(e.data), "\x00")
+				}
+			}
+			switch name {
+			case "TIMECODE":
+				if timecode == "" {
+					timecode = value
+				}
+			case "DATE_RECORDED":
+				if recorded == "" {
+					recorded = value
+				}
+			}
+		}
+	}
+	return timecode, recorded
+}
+
+// readElementHeader reads an element ID and size at offset
+func readElementHeader(r io.ReadSeeker, offset int64) (id, size uint64, headerLen int64, err error) {
+	buf := make([]byte, 12)
+	if _, err := r.Seek(offset, io.SeekStart); err != nil {
+		return 0, 0, 0, err
+	}
+	n, err := io.ReadFull(r, buf)
+	if n == 0 {
+		if err == nil {
+			err = io.EOF
+		}
+		return 0, 0, 0, err
+	}
+
+	id, idLen, ok := readVint(buf[:n], true)
+	if !ok {
+		return 0, 0, 0, fmt.Errorf("invalid element id")
+	}
+	size, sizeLen, ok := readVint(buf[idLen:n], false)
+	if !ok {
+		return 0, 0, 0, fmt.Errorf("invalid element size")
+	}
+	return id, size, int64(idLen + sizeLen), nil
+}
+
+// parseElements splits a buffer into its child elements, stopping at the first malformed one
+func parseElements(data []byte) []element {
+	var elements []element
+	for len(data) > 0 {
+		id, idLen, ok := readVint(data, true)
+		if !ok {
+			return elements
+		}
+		size, sizeLen, ok := readVint(data[idLen:], false)
+		if !ok {
+			return elements
+		}
+		start := idLen + sizeLen
+		if size == unknownSize || size > uint64(len(data)-start) {
+			return elements
+		}
+		end := start + int(size)
+		elements = append(elements, element{id: id, data: data[start:end]})
+		data = data[end:]
+	}
+	return elements
+}
+
+// readVint decodes an EBML variable-length integer. IDs keep their length
+// marker bit; sizes drop it, and an all-ones size means unknown.
+func readVint(data []byte, keepMarker bool) (uint64, int, bool) {
+	if len(data) == 0 || data[0] == 0 {
+		return 0, 0, false
+	}
+
+	length := 1
+	for mask := byte(0x80); data[0]&mask == 0; mask >>= 1 {
+		length++
+	}
+	if length > 8 || len(data) < length {
+		return 0, 0, false
+	}
+
+	value := uint64(data[0])
+	if !keepMarker {
+		value &= uint64(0xFF >> length)
+	}
+	allOnes := value == uint64(0xFF>>length)
+	for i := 1; i < length; i++ {
+		value = value<<8 | uint64(data[i])
+		if data[i] != 0xFF {
+			allOnes = false
+		}
+	}
+
+	if !keepMarker && allOnes {
+		return unknownSize, length, true
+	}
+	return value, length, true
+}
+
+// readUint decodes a big-endian unsigned integer of up to 8 bytes
+func readUint(data []byte) uint64 {
+	var v uint64
+	for _, b := range data {
+		v = v<<8 | uint64(b)
+	}
+	return v
+}
+
+// readFloat decodes a 4- or 8-byte big-endian IEEE float
+func readFloat(data []byte) float64 {
+	switch len(data) {
+	case 4:
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(data)))
+	case 8:
+		return math.Float64frombits(binary.BigEndian.Uint64(data))
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,142 @@
+// metadata/matroska_test.go
+package metadata
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+	"time"
+)
+
+// ebml builds an element with an 8-byte size field from its ID and payload parts
+func ebml(id uint64, parts ...[]byte) []byte {
+	payload := bytes.Join(parts, nil)
+
+	var idBytes []byte
+	for shift := 24; shift >= 0; shift -= 8 {
+		if b := byte(id >> uint(shift)); b != 0 || len(idBytes) > 0 {
+			idBytes = append(idBytes, b)
+		}
+	}
+
+	size := make([]byte, 8)
+	binary.BigEndian.PutUint64(size, uint64(len(payload)))
+	size[0] = 0x01
+
+	return bytes.Join([][]byte{idBytes, size, payload}, nil)
+}
+
+func ebmlUint(v uint64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, v)
+	return b
+}
+
+func ebmlFloat(v float64) []byte {
+	return ebmlUint(math.Float64bits(v))
+}
+
+// buildMKV creates a minimal Matroska file with a 3840x2160 HEVC track at
+// 23.976 fps, a cluster, and a trailing Tags element holding a timecode
+func buildMKV(recorded time.Time, unknownSegmentSize bool) []byte {
+	header := ebml(idEBML, ebml(0x4282, []byte("matroska")))
+
+	info := ebml(idInfo,
+		ebml(idTimestampScale, ebmlUint(1000000)),
+		ebml(idDuration, ebmlFloat(90500)),
+		ebml(idDateUTC, ebmlUint(uint64(recorded.Sub(matroskaEpoch)))),
+	)
+	tracks := ebml(idTracks,
+		ebml(idTrackEntry,
+			ebml(idTrackType, []byte{2}),
+			ebml(idCodecID, []byte("A_AAC")),
+		),
+		ebml(idTrackEntry,
+			ebml(idTrackType, []byte{1}),
+			ebml(idCodecID, []byte("V_MPEGH/ISO/HEVC")),
+			ebml(idDefaultDuration, ebmlUint(41708333)),
+			ebml(idVideo,
+				ebml(idPixelWidth, ebmlUint(3840)),
+				ebml(idPixelHeight, ebmlUint(2160)),
+			),
+		),
+	)
+	cluster := ebml(idCluster, make([]byte, 64))
+	tags := ebml(idTags,
+		ebml(idTag,
+			ebml(idSimpleTag,
+				ebml(idTagName, []byte("TIMECODE")),
+				ebml(idTagString, []byte("01:02:03:04")),
+			),
+		),
+	)
+
+	body := bytes.Join([][]byte{info, tracks, cluster, tags}, nil)
+	segment := ebml(idSegment, body)
+	if unknownSegmentSize {
+		// Replace the size with the 1-byte unknown-size marker
+		segment = bytes.Join([][]byte{{0x18, 0x53, 0x80, 0x67}, {0xFF}, body}, nil)
+	}
+
+	return append(header, segment...)
+}
+
+func TestReadMatroska(t *testing.T) {
+	recorded := time.Date(2026, 1, 12, 14, 3, 22, 0, time.UTC)
+
+	for _, unknown := range []bool{false, true} {
+		md, err := ReadMatroska(bytes.NewReader(buildMKV(recorded, unknown)))
+		if err != nil {
+			t.Fatalf("read failed (unknown size %v): %v", unknown, err)
+		}
+
+		if !md.RecordedTime.Equal(recorded) {
+			t.Errorf("expected recorded time %v, got %v", recorded, md.RecordedTime)
+		}
+		if md.Duration != 90500*time.Millisecond {
+			t.Errorf("expected duration 90.5s, got %v", md.Duration)
+		}
+		if md.Width != 3840 || md.Height != 2160 {
+			t.Errorf("expected 3840x2160, got %dx%d", md.Width, md.Height)
+		}
+		if math.Abs(md.FrameRate-23.976) > 0.001 {
+			t.Errorf("expected 23.976 fps, got %v", md.FrameRate)
+		}
+		if md.Codec != "H.265" {
+			t.Errorf("expected codec H.265, got %s", md.Codec)
+		}
+		if md.Timecode != "01:02:03:04" {
+			t.Errorf("expected timecode 01:02:03:04, got %s", md.Timecode)
+		}
+	}
+}
+
+func TestReadMatroska_NotMatroska(t *testing.T) {
+	_, err := ReadMatroska(bytes.NewReader([]byte{0x00, 0x00, 0x00, 0x18, 'f', 't', 'y', 'p'}))
+	if err != ErrUnsupported {
+		t.Errorf("expected ErrUnsupported, got %v", err)
+	}
+}
+
+func TestReadVint(t *testing.T) {
+	tests := []struct {
+		data       []byte
+		keepMarker bool
+		value      uint64
+		length     int
+	}{
+		{[]byte{0x81}, false, 1, 1},
+		{[]byte{0x40, 0x02}, false, 2, 2},
+		{[]byte{0x1A, 0x45, 0xDF, 0xA3}, true, idEBML, 4},
+		{[]byte{0xFF}, false, unknownSize, 1},
+		{[]byte{0x01, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF}, false, unknownSize, 8},
+	}
+
+	for _, tt := range tests {
+		value, length, ok := readVint(tt.data, tt.keepMarker)
+		if !ok || value != tt.value || length != tt.length {
+			t.Errorf("readVint(%X) = %d, %d, %v; want %d, %d", tt.data, value, length, ok, tt.value, tt.length)
+		}
+	}
+}
@@ -0,0 +1,204 @@
+// metadata/metadata.go
+
+// Package metadata reads recording information embedded in video containers.
+// It understands ISO base media files (MP4/MOV) and Matroska (MKV/WebM)
+// without any external tools, so clips can be ordered by when they were
+// actually shot rather than by filesystem timestamps.
+package metadata
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ErrUnsupported is returned when a file is not a container this package can parse
+var ErrUnsupported = errors.New("unsupported container format")
+
+// Metadata contains the recording information found in a container.
+// Zero values mean the container did not provide the field.
+type Metadata struct {
+	RecordedTime time.Time     `json:"recorded_time,omitempty"`
+	Duration     time.Duration `json:"duration,omitempty"`
+	Width        int           `json:"width,omitempty"`
+	Height       int           `json:"height,omitempty"`
+	FrameRate    float64       `json:"frame_rate,omitempty"`
+	Codec        string        `json:"codec,omitempty"`
+	Timecode     string        `json:"timecode,omitempty"`
+	Camera       string        `json:"camera,omitempty"` // Camera model, e.g. "iPhone 15 Pro"
+}
+
+// Read opens a file and parses its container metadata based on the extension
+func Read(path string) (*Metadata, error) {
+	ext := strings.ToLower(filepath.Ext(path))
+	if !Supported(ext) {
+		return nil, ErrUnsupported
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open file: %w", err)
+	}
+	defer f.Close()
+
+	switch ext {
+	case ".mkv", ".webm":
+		return ReadMatroska(f)
+	default:
+		return ReadISOBMFF(f)
+	}
+}
+
+// Supported reports whether files with the given extension can be parsed
+func Supported(ext string) bool {
+	switch strings.ToLower(ext) {
+	case ".mp4", ".mov", ".m4v", ".mkv", ".webm":
+		return true
+	default:
+		return false
+	}
+}
+
+// Resolution returns the frame size as "WIDTHxHEIGHT", or "" if unknown
+func (m *Metadata) Resolution() string {
+	if m.Width == 0 || m.Height == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%dx%d", m.Width, m.Height)
+}
+
+// Summary returns a one-line description of the metadata for display
+func (m *Metadata) Summary() string {
+	var parts []string
+	if m.Duration > 0 {
+		parts = append(parts, formatDuration(m.Duration))
+	}
+	if res := m.Resolution(); res != "" {
+		parts = append(parts, res)
+	}
+	if m.FrameRate > 0 {
+		parts = append(parts, fmt.Sprintf("%s fps", formatFrameRate(m.FrameRate)))
+	}
+	if m.Codec != "" {
+		parts = append(parts, m.Codec)
+	}
+	if m.Timecode != "" {
+		parts = append(parts, "TC "+m.Timecode)
+	}
+	return strings.Join(parts, " · ")
+}
+
+// formatDuration formats a duration as M:SS or H:MM:SS
+func formatDuration(d time.Duration) string {
+	total := int(d.Round(time.Second) / time.Second)
+	h, m, s := total/3600, (total/60)%60, total%60
+	if h > 0 {
+		return fmt.Sprintf("%d:%02d:%02d", h, m, s)
+	}
+	return fmt.Sprintf("%d:%02d", m, s)
+}
+
+// formatFrameRate trims trailing zeros so 25 shows as "25" and 23.976 as "23.976"
+func formatFrameRate(rate float64) string {
+	s := fmt.Sprintf("%.3f", rate)
+	s = strings.TrimRight(s, "0")
+	return strings.TrimSuffix(s, ".")
+}
+
+// codecNames maps container codec identifiers to friendly names
+var codecNames = map[string]string{
+	"avc1":             "H.264",
+	"avc3":             "H.264",
+	"hvc1":             "H.265",
+	"hev1":             "H.265",
+	"av01":             "AV1",
+	"vp08":             "VP8",
+	"vp09":             "VP9",
+	"mp4v":             "MPEG-4",
+	"apco":             "ProRes 422 Proxy",
+	"apcs":             "ProRes 422 LT",
+	"apcn":             "ProRes 422",
+	"apch":             "ProRes 422 HQ",
+	"ap4h":             "ProRes 4444",
+	"ap4x":             "ProRes 4444 XQ",
+	"V_MPEG4/ISO/AVC":  "H.264",
+	"V_MPEGH/ISO/HEVC": "H.265",
+	"V_AV1":            "AV1",
+	"V_VP8":            "VP8",
+	"V_VP9":            "VP9",
+	"V_PRORES":         "ProRes",
+}
+
+// codecName returns the friendly name for a codec identifier, or the identifier itself
+func codecName(id string) string {
+	if name, ok := codecNames[id]; ok {
+		return name
+	}
+	return strings.TrimSpace(id)
+}
+
+// recordedTimeLayouts are the date formats cameras write into text metadata
+var recordedTimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05-0700",
+	"2006-01-02T15:04:05Z0700",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05-07:00",
+	"2006-01-02 15:04:05",
+	"2006:01:02 15:04:05",
+	"2006-01-02",
+}
+
+// parseRecordedTime parses a date string from container metadata
+func parseRecordedTime(value string) (time.Time, bool) {
+	value = strings.TrimSpace(strings.TrimRight(value, "\x00"))
+	for _, layout := range recordedTimeLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// formatTimecode converts a frame count into HH:MM:SS:FF. Drop-frame
+// timecode (29.97/59.94) uses ';' before the frame field and skips the
+// frame numbers that drop-frame counting leaves out.
+func formatTimecode(frame uint64, fps int, dropFrame bool) string {
+	if fps <= 0 {
+		return ""
+	}
+
+	sep := ":"
+	if dropFrame && fps%30 == 0 {
+		sep = ";"
+		dropped := uint64(2 * fps / 30)
+		framesPer10Min := uint64(fps*600) - dropped*9
+		framesPerMin := uint64(fps*60) - dropped
+		tens := frame / framesPer10Min
+		rem := frame % framesPer10Min
+		frame += dropped * 9 * tens
+		if rem > dropped {
+			frame += dropped * ((rem - dropped) / framesPerMin)
+		}
+	}
+
+	f := uint64(fps)
+	ff := frame % f
+	ss := (frame / f) % 60
+	mm := (frame / (f * 60)) % 60
+	hh := (frame / (f * 3600)) % 24
+	return fmt.Sprintf("%02d:%02d:%02d%s%02d", hh, mm, ss, sep, ff)
+}
+
+// readAt reads exactly len(buf) bytes at offset
+func readAt(r io.ReadSeeker, offset int64, buf []byte) error {
+	if _, err := r.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+	_, err := io.ReadFull(r, buf)
+	return err
+}
@@ -0,0 +1,70 @@
+// metadata/metadata_test.go
+package metadata
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFormatTimecode(t *testing.T) {
+	tests := []struct {
+		frame     uint64
+		fps       int
+		dropFrame bool
+		expected  string
+	}{
+		{0, 25, false, "00:00:00:00"},
+		{25*3600 + 24, 25, false, "01:00:00:24"},
+		{1800, 30, true, "00:01:00;02"},
+		{17982, 30, true, "00:10:00;00"},
+		{0, 0, false, ""},
+	}
+
+	for _, tt := range tests {
+		result := formatTimecode(tt.frame, tt.fps, tt.dropFrame)
+		if result != tt.expected {
+			t.Errorf("formatTimecode(%d, %d, %v) = %s, want %s", tt.frame, tt.fps, tt.dropFrame, result, tt.expected)
+		}
+	}
+}
+
+func TestParseRecordedTime(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected time.Time
+		ok       bool
+	}{
+		{"2026-01-12T14:03:22Z", time.Date(2026, 1, 12, 14, 3, 22, 0, time.UTC), true},
+		{"2026-01-12T15:03:22+0100", time.Date(2026, 1, 12, 14, 3, 22, 0, time.UTC), true},
+		{"2026-01-12 14:03:22", time.Date(2026, 1, 12, 14, 3, 22, 0, time.UTC), true},
+		{"2026:01:12 14:03:22\x00", time.Date(2026, 1, 12, 14, 3, 22, 0, time.UTC), true},
+		{"yesterday", time.Time{}, false},
+	}
+
+	for _, tt := range tests {
+		result, ok := parseRecordedTime(tt.value)
+		if ok != tt.ok || !result.Equal(tt.expected) {
+			t.Errorf("parseRecordedTime(%q) = %v, %v; want %v, %v", tt.value, result, ok, tt.expected, tt.ok)
+		}
+	}
+}
+
+func TestMetadata_Summary(t *testing.T) {
+	md := &Metadata{
+		Duration:  83 * time.Second,
+		Width:     1920,
+		Height:    1080,
+		FrameRate: 23.976,
+		Codec:     "H.264",
+		Timecode:  "01:00:00:00",
+	}
+
+	expected := "1:23 · 1920x1080 · 23.976 fps · H.264 · TC 01:00:00:00"
+	if result := md.Summary(); result != expected {
+		t.Errorf("expected %q, got %q", expected, result)
+	}
+
+	if result := (&Metadata{FrameRate: 25}).Summary(); result != "25 fps" {
+		t.Errorf("expected '25 fps', got %q", result)
+	}
+}
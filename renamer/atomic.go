@@ -0,0 +1,53 @@
+// renamer/atomic.go
+package renamer
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// syncFile flushes a file to disk; replaced in tests to simulate a failed write
+var syncFile = (*os.File).Sync
+
+// writeFileAtomic replaces path with data via a synced temporary file in
+// the same directory, so a crash or full disk leaves either the old file
+// or the new one, never a truncated mix. state keeps its own copy, so
+// writing files adds no dependency between the packages.
+func writeFileAtomic(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := syncFile(tmp); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Chmod(tmpPath, 0644); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	// Persist the rename itself; not every platform can sync a directory
+	if d, err := os.Open(dir); err == nil {
+		_ = d.Sync()
+		d.Close()
+	}
+	return nil
+}
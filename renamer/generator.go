@@ -2,9 +2,9 @@
 package renamer
 
 import (
-	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 )
 
 // Rename represents a file rename operation
@@ -12,21 +12,29 @@ type Rename struct {
 	OriginalPath string
 	TargetPath   string
 	ChangeType   string // "new", "updated", "moved", or ""
+	Reject       bool   // A rejected clip leaving the session, for the rejects folder or the trash
 }
 
+// Layout controls where renamed files are placed relative to the session root
+type Layout string
+
+const (
+	LayoutFlatten  Layout = "flatten"  // Every renamed file goes into the session root
+	LayoutPreserve Layout = "preserve" // Renamed files stay in their original subfolder
+)
+
+// defaultTemplate is the parsed DefaultTemplate
+var defaultTemplate = MustParseTemplate(DefaultTemplate)
+
 // GenerateFilename creates a filename in format [XX_YY] name.ext
 func GenerateFilename(groupOrder, takeNumber int, groupName, extension string) string {
-	groupNum := formatNumber(groupOrder)
-	takeNum := formatNumber(takeNumber)
-	return fmt.Sprintf("[%s_%s] %s%s", groupNum, takeNum, groupName, extension)
+	fields := Fields{GroupOrder: groupOrder, Take: takeNumber, GroupName: groupName}
+	return defaultTemplate.Filename(fields, extension)
 }
 
 // formatNumber formats a number with leading zero (01, 02, ..., 10, 11, ...)
 func formatNumber(n int) string {
-	if n < 10 {
-		return fmt.Sprintf("0%d", n)
-	}
-	return fmt.Sprintf("%d", n)
+	return padNumber(n, defaultNumberWidth)
 }
 
 // GenerateTargetPath generates the full target path for a file
@@ -36,14 +44,61 @@ func GenerateTargetPath(directory, originalPath string, groupOrder, takeNumber i
 	return filepath.Join(directory, newName)
 }
 
-// DetectConflicts checks if any target paths already exist
+// GenerateTargetPathInLayout generates the full target path for a file whose
+// path relative to the session root is relativeFile. With LayoutPreserve the
+// file keeps its subfolder; otherwise it is placed in the session root.
+func GenerateTargetPathInLayout(directory, relativeFile string, layout Layout, groupOrder, takeNumber int, groupName string) string {
+	fields := Fields{GroupOrder: groupOrder, Take: takeNumber, GroupName: groupName}
+	return GenerateTargetPathFromTemplate(directory, relativeFile, layout, defaultTemplate, fields)
+}
+
+// GenerateTargetPathFromTemplate is GenerateTargetPathInLayout with the new
+// name rendered from a template
+func GenerateTargetPathFromTemplate(directory, relativeFile string, layout Layout, tmpl *Template, fields Fields) string {
+	ext := filepath.Ext(relativeFile)
+	newName := tmpl.Filename(fields, ext)
+	if layout == LayoutPreserve {
+		return filepath.Join(directory, filepath.Dir(filepath.FromSlash(relativeFile)), newName)
+	}
+	return filepath.Join(directory, newName)
+}
+
+// RelativePath returns path relative to root using forward slashes,
+// falling back to the base name when path is not below root
+func RelativePath(root, path string) string {
+	if !isBelow(root, path) {
+		return filepath.Base(path)
+	}
+	rel, _ := filepath.Rel(root, path)
+	return filepath.ToSlash(rel)
+}
+
+// isBelow reports whether path is inside root
+func isBelow(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// DetectConflicts checks if any target paths already exist. A target that is
+// itself being renamed away in the same batch is not a conflict, since the
+// rename engine orders the moves so it is vacated first.
 func DetectConflicts(renames []Rename) []Rename {
+	sources := make(map[string]bool)
+	for _, r := range renames {
+		if r.OriginalPath != r.TargetPath {
+			sources[r.OriginalPath] = true
+		}
+	}
+
 	var conflicts []Rename
 	for _, r := range renames {
 		// Skip if target is same as source (no actual rename)
 		if r.OriginalPath == r.TargetPath {
 			continue
 		}
+		if sources[r.TargetPath] {
+			continue
+		}
 
 		if _, err := os.Stat(r.TargetPath); err == nil {
 			conflicts = append(conflicts, r)
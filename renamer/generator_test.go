@@ -110,3 +110,67 @@ func TestDetectConflicts(t *testing.T) {
 		t.Errorf("wrong conflict path: %s", conflicts[0].TargetPath)
 	}
 }
+
+func TestDetectConflicts_IgnoresTargetsVacatedByBatch(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	// Reclassifying swaps two already-renamed files
+	first := filepath.Join(tmpDir, "[01_01] intro.mp4")
+	second := filepath.Join(tmpDir, "[01_02] intro.mp4")
+	for _, p := range []string{first, second} {
+		if err := os.WriteFile(p, []byte("test"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	renames := []Rename{
+		{OriginalPath: first, TargetPath: second},
+		{OriginalPath: second, TargetPath: first},
+	}
+
+	if conflicts := DetectConflicts(renames); len(conflicts) != 0 {
+		t.Errorf("expected no conflicts, got %d", len(conflicts))
+	}
+}
+
+func TestGenerateTargetPathInLayout(t *testing.T) {
+	tests := []struct {
+		relativeFile string
+		layout       Layout
+		expected     string
+	}{
+		{"clip.mp4", LayoutFlatten, "/card/[01_02] intro.mp4"},
+		{"clip.mp4", LayoutPreserve, "/card/[01_02] intro.mp4"},
+		{"DCIM/100CANON/MVI_0001.MOV", LayoutFlatten, "/card/[01_02] intro.MOV"},
+		{"DCIM/100CANON/MVI_0001.MOV", LayoutPreserve, "/card/DCIM/100CANON/[01_02] intro.MOV"},
+		{"DCIM/100CANON/MVI_0001.MOV", "", "/card/[01_02] intro.MOV"},
+	}
+
+	for _, tt := range tests {
+		result := GenerateTargetPathInLayout("/card", tt.relativeFile, tt.layout, 1, 2, "intro")
+		expected := filepath.FromSlash(tt.expected)
+		if result != expected {
+			t.Errorf("GenerateTargetPathInLayout(%s, %s) = %s, want %s",
+				tt.relativeFile, tt.layout, result, expected)
+		}
+	}
+}
+
+func TestRelativePath(t *testing.T) {
+	tests := []struct {
+		root     string
+		path     string
+		expected string
+	}{
+		{"/card", "/card/clip.mp4", "clip.mp4"},
+		{"/card", "/card/DCIM/100CANON/clip.mp4", "DCIM/100CANON/clip.mp4"},
+		{"/card", "/elsewhere/clip.mp4", "clip.mp4"},
+	}
+
+	for _, tt := range tests {
+		result := RelativePath(filepath.FromSlash(tt.root), filepath.FromSlash(tt.path))
+		if result != tt.expected {
+			t.Errorf("RelativePath(%s, %s) = %s, want %s", tt.root, tt.path, result, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,262 @@
+// renamer/history.go
+package renamer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// HistoryFileName is the permanent record of executed batches, kept next to
+// the state file so original camera names can always be recovered
+const HistoryFileName = ".clip-tagger-history.json"
+
+// Batch modes
+const (
+	BatchModeRename = "rename"
+	BatchModeCopy   = "copy"
+)
+
+// HistoryEntry records one file in a batch. Original is relative to the
+// session root; New is relative to the session root for renames and to the
+// batch output directory for copies. A rejected clip moved to the trash has
+// an absolute New.
+type HistoryEntry struct {
+	Original string `json:"original"`
+	New      string `json:"new"`
+	Size     int64  `json:"size"`
+	Rejected bool   `json:"rejected,omitempty"`
+}
+
+// Batch is a single executed rename or copy operation
+type Batch struct {
+	ID       int            `json:"id"`
+	Time     time.Time      `json:"time"`
+	Mode     string         `json:"mode"`
+	Output   string         `json:"output,omitempty"` // Copy output directory, relative to the session root
+	Entries  []HistoryEntry `json:"entries"`
+	UndoneAt *time.Time     `json:"undone_at,omitempty"`
+}
+
+// History is the list of batches executed in a session, oldest first
+type History struct {
+	Batches []Batch `json:"batches"`
+}
+
+// LoadHistory reads the history file, returning an empty history if it doesn't exist
+func LoadHistory(path string) (*History, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &History{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read history: %w", err)
+	}
+
+	var h History
+	if err := json.Unmarshal(data, &h); err != nil {
+		return nil, fmt.Errorf("unmarshal history: %w", err)
+	}
+	return &h, nil
+}
+
+// Save writes the history file atomically; every recorded batch depends on it
+func (h *History) Save(path string) error {
+	data, err := json.MarshalIndent(h, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal history: %w", err)
+	}
+
+	if err := writeFileAtomic(path, data); err != nil {
+		return fmt.Errorf("write history: %w", err)
+	}
+	return nil
+}
+
+// Record appends a batch for renames that have just been executed under root.
+// For copies, outputDir is where the files were written.
+func (h *History) Record(mode, root, outputDir string, renames []Rename) (*Batch, error) {
+	batch := Batch{
+		ID:   h.nextID(),
+		Time: time.Now(),
+		Mode: mode,
+	}
+	if mode == BatchModeCopy {
+		batch.Output = RelativePath(root, outputDir)
+	}
+
+	for _, r := range renames {
+		if mode == BatchModeRename && r.OriginalPath == r.TargetPath {
+			continue
+		}
+
+		entry := HistoryEntry{
+			Original: RelativePath(root, r.OriginalPath),
+			New:      RelativePath(root, r.TargetPath),
+			Rejected: r.Reject,
+		}
+		if mode == BatchModeRename && !isBelow(root, r.TargetPath) {
+			entry.New = r.TargetPath
+		}
+
+		info, err := os.Stat(batch.newPath(root, entry))
+		if err != nil {
+			return nil, fmt.Errorf("stat %s: %w", entry.New, err)
+		}
+		entry.Size = info.Size()
+
+		batch.Entries = append(batch.Entries, entry)
+	}
+
+	h.Batches = append(h.Batches, batch)
+	return &h.Batches[len(h.Batches)-1], nil
+}
+
+// Latest returns the most recent batch that hasn't been undone
+func (h *History) Latest() *Batch {
+	for i := len(h.Batches) - 1; i >= 0; i-- {
+		if h.Batches[i].UndoneAt == nil {
+			return &h.Batches[i]
+		}
+	}
+	return nil
+}
+
+// Find returns the batch with the given ID
+func (h *History) Find(id int) *Batch {
+	for i := range h.Batches {
+		if h.Batches[i].ID == id {
+			return &h.Batches[i]
+		}
+	}
+	return nil
+}
+
+// nextID returns the ID for a new batch
+func (h *History) nextID() int {
+	id := 1
+	for _, b := range h.Batches {
+		if b.ID >= id {
+			id = b.ID + 1
+		}
+	}
+	return id
+}
+
+// RestoredNames maps each file's new name back to its original name, both
+// relative to the session root, for bringing state back in line after undo
+func (b *Batch) RestoredNames() map[string]string {
+	names := make(map[string]string)
+	for _, e := range b.Entries {
+		if !e.Rejected {
+			names[e.New] = e.Original
+		}
+	}
+	return names
+}
+
+// Rejected lists the original names of the rejected clips the batch moved
+// out of the session
+func (b *Batch) Rejected() []string {
+	var files []string
+	for _, e := range b.Entries {
+		if e.Rejected {
+			files = append(files, e.Original)
+		}
+	}
+	return files
+}
+
+// newPath resolves where an entry's file was written
+func (b *Batch) newPath(root string, e HistoryEntry) string {
+	if filepath.IsAbs(e.New) {
+		return e.New
+	}
+	if b.Mode == BatchModeCopy {
+		return filepath.Join(root, filepath.FromSlash(b.Output), filepath.FromSlash(e.New))
+	}
+	return filepath.Join(root, filepath.FromSlash(e.New))
+}
+
+// UndoBatch reverses a batch under root. Renames are moved back to their
+// original names as one transaction; copies are deleted, leaving the
+// originals untouched. Every file is checked against its recorded size
+// first, so a batch whose files were renamed again by a later batch (or
+// replaced) is refused rather than half undone.
+func UndoBatch(root string, b *Batch, journalPath string) error {
+	if b.UndoneAt != nil {
+		return fmt.Errorf("batch %d was already undone", b.ID)
+	}
+
+	for _, e := range b.Entries {
+		info, err := os.Stat(b.newPath(root, e))
+		if err != nil {
+			return fmt.Errorf("%s is missing; undo later batches first or restore it manually", e.New)
+		}
+		if info.Size() != e.Size {
+			return fmt.Errorf("%s has changed size since batch %d", e.New, b.ID)
+		}
+	}
+
+	switch b.Mode {
+	case BatchModeRename:
+		var renames []Rename
+		for _, e := range b.Entries {
+			renames = append(renames, Rename{
+				OriginalPath: b.newPath(root, e),
+				TargetPath:   filepath.Join(root, filepath.FromSlash(e.Original)),
+			})
+		}
+		if err := ExecuteRenames(renames, journalPath); err != nil {
+			return err
+		}
+		// Rejected clips leave an empty rejects folder behind
+		for _, e := range b.Entries {
+			if e.Rejected && !filepath.IsAbs(e.New) {
+				removeEmptyParents(root, b.newPath(root, e))
+			}
+		}
+
+	case BatchModeCopy:
+		for _, e := range b.Entries {
+			if err := os.Remove(b.newPath(root, e)); err != nil {
+				return fmt.Errorf("remove copy %s: %w", e.New, err)
+			}
+		}
+		removeEmptyDirs(filepath.Join(root, filepath.FromSlash(b.Output)))
+
+	default:
+		return fmt.Errorf("unknown batch mode: %s", b.Mode)
+	}
+
+	now := time.Now()
+	b.UndoneAt = &now
+	return nil
+}
+
+// removeEmptyDirs removes dir and any directories below it that are empty,
+// leaving anything that still holds files
+func removeEmptyDirs(dir string) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			removeEmptyDirs(filepath.Join(dir, entry.Name()))
+		}
+	}
+	_ = os.Remove(dir) // Fails harmlessly if not empty
+}
+
+// removeEmptyParents removes the empty folders above path, up to root
+func removeEmptyParents(root, path string) {
+	for dir := filepath.Dir(path); dir != root && isBelow(root, dir); dir = filepath.Dir(dir) {
+		if os.Remove(dir) != nil {
+			return // Not empty
+		}
+	}
+}
@@ -0,0 +1,210 @@
+// renamer/history_test.go
+package renamer
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestHistory_RecordAndUndoRename(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeFiles(t, tmpDir, "C0001.MP4", "C0002.MP4")
+
+	renames := []Rename{
+		{OriginalPath: filepath.Join(tmpDir, "C0001.MP4"), TargetPath: filepath.Join(tmpDir, "[01_01] intro.MP4")},
+		{OriginalPath: filepath.Join(tmpDir, "C0002.MP4"), TargetPath: filepath.Join(tmpDir, "[01_02] intro.MP4")},
+	}
+	if err := RenameInPlace(renames); err != nil {
+		t.Fatal(err)
+	}
+
+	historyPath := filepath.Join(tmpDir, HistoryFileName)
+	history, err := LoadHistory(historyPath)
+	if err != nil {
+		t.Fatalf("load empty history: %v", err)
+	}
+	batch, err := history.Record(BatchModeRename, tmpDir, "", renames)
+	if err != nil {
+		t.Fatalf("record failed: %v", err)
+	}
+	if err := history.Save(historyPath); err != nil {
+		t.Fatal(err)
+	}
+
+	if batch.ID != 1 || len(batch.Entries) != 2 {
+		t.Fatalf("unexpected batch: %+v", batch)
+	}
+	entry := batch.Entries[0]
+	if entry.Original != "C0001.MP4" || entry.New != "[01_01] intro.MP4" || entry.Size != int64(len("C0001.MP4")) {
+		t.Errorf("unexpected entry: %+v", entry)
+	}
+
+	// Reload to make sure undo works from what's on disk
+	history, err = LoadHistory(historyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	latest := history.Latest()
+	if latest == nil || latest.ID != 1 {
+		t.Fatalf("expected latest batch 1, got %+v", latest)
+	}
+
+	if err := UndoBatch(tmpDir, latest, ""); err != nil {
+		t.Fatalf("undo failed: %v", err)
+	}
+
+	assertContent(t, filepath.Join(tmpDir, "C0001.MP4"), "C0001.MP4")
+	assertContent(t, filepath.Join(tmpDir, "C0002.MP4"), "C0002.MP4")
+	if latest.UndoneAt == nil {
+		t.Error("expected batch to be marked undone")
+	}
+	if history.Latest() != nil {
+		t.Error("expected no batch left to undo")
+	}
+
+	names := latest.RestoredNames()
+	if names["[01_02] intro.MP4"] != "C0002.MP4" {
+		t.Errorf("unexpected restored names: %v", names)
+	}
+}
+
+func TestUndoBatch_Copy(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(tmpDir, "CLIP"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeFiles(t, tmpDir, "CLIP/C0001.MP4")
+
+	outputDir := filepath.Join(tmpDir, "renamed_2026-01-12_10-00-00")
+	renames := []Rename{
+		{OriginalPath: filepath.Join(tmpDir, "CLIP", "C0001.MP4"), TargetPath: filepath.Join(tmpDir, "CLIP", "[01_01] intro.MP4")},
+	}
+	if err := CopyTreeToDirectory(renames, tmpDir, outputDir); err != nil {
+		t.Fatal(err)
+	}
+
+	history := &History{}
+	batch, err := history.Record(BatchModeCopy, tmpDir, outputDir, renames)
+	if err != nil {
+		t.Fatalf("record failed: %v", err)
+	}
+	if batch.Output != "renamed_2026-01-12_10-00-00" || batch.Entries[0].New != "CLIP/[01_01] intro.MP4" {
+		t.Errorf("unexpected batch: %+v", batch)
+	}
+
+	if err := UndoBatch(tmpDir, batch, ""); err != nil {
+		t.Fatalf("undo failed: %v", err)
+	}
+
+	if _, err := os.Stat(outputDir); !os.IsNotExist(err) {
+		t.Error("expected empty output directory to be removed")
+	}
+	assertContent(t, filepath.Join(tmpDir, "CLIP", "C0001.MP4"), "CLIP/C0001.MP4")
+}
+
+func TestUndoBatch_RefusesChangedFiles(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeFiles(t, tmpDir, "renamed.mp4")
+
+	batch := &Batch{
+		ID:      1,
+		Mode:    BatchModeRename,
+		Entries: []HistoryEntry{{Original: "C0001.MP4", New: "renamed.mp4", Size: 999}},
+	}
+	if err := UndoBatch(tmpDir, batch, ""); err == nil {
+		t.Error("expected error when size doesn't match")
+	}
+
+	batch.Entries[0] = HistoryEntry{Original: "C0001.MP4", New: "gone.mp4", Size: 1}
+	if err := UndoBatch(tmpDir, batch, ""); err == nil {
+		t.Error("expected error when file is missing")
+	}
+
+	if batch.UndoneAt != nil {
+		t.Error("refused undo must not mark the batch undone")
+	}
+}
+
+func TestUndoBatch_Rejects(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeFiles(t, tmpDir, "C0001.MP4", "C0002.MP4")
+
+	renames := []Rename{
+		{OriginalPath: filepath.Join(tmpDir, "C0001.MP4"), TargetPath: filepath.Join(tmpDir, "[01_01] intro.MP4")},
+		{OriginalPath: filepath.Join(tmpDir, "C0002.MP4"), TargetPath: filepath.Join(tmpDir, "_rejected", "C0002.MP4"), Reject: true},
+	}
+	if err := RenameInPlace(renames); err != nil {
+		t.Fatal(err)
+	}
+	history := &History{}
+	batch, err := history.Record(BatchModeRename, tmpDir, "", renames)
+	if err != nil {
+		t.Fatalf("record failed: %v", err)
+	}
+
+	if rejected := batch.Rejected(); len(rejected) != 1 || rejected[0] != "C0002.MP4" {
+		t.Errorf("expected C0002.MP4 to be recorded as rejected, got %v", rejected)
+	}
+	if names := batch.RestoredNames(); len(names) != 1 || names["[01_01] intro.MP4"] != "C0001.MP4" {
+		t.Errorf("expected only the renamed clip in restored names, got %v", names)
+	}
+
+	if err := UndoBatch(tmpDir, batch, ""); err != nil {
+		t.Fatalf("undo failed: %v", err)
+	}
+	assertContent(t, filepath.Join(tmpDir, "C0002.MP4"), "C0002.MP4")
+	if _, err := os.Stat(filepath.Join(tmpDir, "_rejected")); !os.IsNotExist(err) {
+		t.Error("expected the empty rejects folder to be removed")
+	}
+}
+
+func TestHistory_Find(t *testing.T) {
+	history := &History{Batches: []Batch{{ID: 1}, {ID: 2}}}
+
+	if b := history.Find(2); b == nil || b.ID != 2 {
+		t.Errorf("expected batch 2, got %+v", b)
+	}
+	if b := history.Find(3); b != nil {
+		t.Errorf("expected nil for unknown batch, got %+v", b)
+	}
+	if id := history.nextID(); id != 3 {
+		t.Errorf("expected next ID 3, got %d", id)
+	}
+}
+
+func TestHistory_SaveFailureKeepsPreviousHistory(t *testing.T) {
+	tmpDir := t.TempDir()
+	historyPath := filepath.Join(tmpDir, HistoryFileName)
+
+	history := &History{Batches: []Batch{{ID: 1, Mode: BatchModeRename, Entries: []HistoryEntry{{Original: "C0001.MP4", New: "[01_01] intro.MP4"}}}}}
+	if err := history.Save(historyPath); err != nil {
+		t.Fatal(err)
+	}
+
+	// The disk fills before the new history is on disk
+	syncFile = func(*os.File) error { return errors.New("no space left on device") }
+	t.Cleanup(func() { syncFile = (*os.File).Sync })
+
+	history.Batches = append(history.Batches, Batch{ID: 2, Mode: BatchModeCopy})
+	if err := history.Save(historyPath); err == nil {
+		t.Fatal("expected the failed write to return an error")
+	}
+
+	loaded, err := LoadHistory(historyPath)
+	if err != nil {
+		t.Fatalf("expected the previous history to stay readable, got %v", err)
+	}
+	if len(loaded.Batches) != 1 || loaded.Batches[0].Entries[0].Original != "C0001.MP4" {
+		t.Errorf("expected the previous history, got %+v", loaded.Batches)
+	}
+
+	entries, err := os.ReadDir(tmpDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("expected the temporary file to be removed, got %v", entries)
+	}
+}
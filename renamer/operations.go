@@ -8,40 +8,71 @@ import (
 	"path/filepath"
 )
 
-// RenameInPlace renames files in their current directory
+// RenameInPlace renames files in their current directory as a single
+// transaction: either every file is renamed or none are
 func RenameInPlace(renames []Rename) error {
+	return ExecuteRenames(renames, "")
+}
+
+// RenameInPlaceWithJournal is RenameInPlace with the plan journaled to
+// journalPath so an interrupted batch can be rolled back by RecoverJournal
+func RenameInPlaceWithJournal(renames []Rename, journalPath string) error {
+	return ExecuteRenames(renames, journalPath)
+}
+
+// CopyToDirectory copies files to a new directory
+func CopyToDirectory(renames []Rename, outputDir string) error {
+	// Create output directory if needed
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return fmt.Errorf("create output directory: %w", err)
+	}
+
 	for _, r := range renames {
-		// Skip if no actual change
-		if r.OriginalPath == r.TargetPath {
-			continue
-		}
+		targetPath := filepath.Join(outputDir, filepath.Base(r.TargetPath))
 
-		if err := os.Rename(r.OriginalPath, r.TargetPath); err != nil {
-			return fmt.Errorf("rename %s -> %s: %w",
+		if err := copyFile(r.OriginalPath, targetPath); err != nil {
+			return fmt.Errorf("copy %s -> %s: %w",
 				filepath.Base(r.OriginalPath),
-				filepath.Base(r.TargetPath),
+				filepath.Base(targetPath),
 				err)
 		}
 	}
 	return nil
 }
 
-// CopyToDirectory copies files to a new directory
-func CopyToDirectory(renames []Rename, outputDir string) error {
+// CopyTreeToDirectory copies files to a new directory, keeping each target's
+// path relative to root so that subfolders are recreated under outputDir
+func CopyTreeToDirectory(renames []Rename, root, outputDir string) error {
 	// Create output directory if needed
 	if err := os.MkdirAll(outputDir, 0755); err != nil {
 		return fmt.Errorf("create output directory: %w", err)
 	}
 
+	// Files copied so far, removed again if a later copy fails
+	var copied []string
+	cleanup := func() {
+		for _, p := range copied {
+			_ = os.Remove(p)
+		}
+	}
+
 	for _, r := range renames {
-		targetPath := filepath.Join(outputDir, filepath.Base(r.TargetPath))
+		targetPath := filepath.Join(outputDir, filepath.FromSlash(RelativePath(root, r.TargetPath)))
+
+		if err := os.MkdirAll(filepath.Dir(targetPath), 0755); err != nil {
+			cleanup()
+			return fmt.Errorf("create directory for %s: %w", filepath.Base(targetPath), err)
+		}
 
 		if err := copyFile(r.OriginalPath, targetPath); err != nil {
+			_ = os.Remove(targetPath)
+			cleanup()
 			return fmt.Errorf("copy %s -> %s: %w",
 				filepath.Base(r.OriginalPath),
 				filepath.Base(targetPath),
 				err)
 		}
+		copied = append(copied, targetPath)
 	}
 	return nil
 }
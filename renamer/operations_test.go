@@ -74,3 +74,35 @@ func TestCopyToDirectory(t *testing.T) {
 		t.Error("content mismatch")
 	}
 }
+
+func TestCopyTreeToDirectory(t *testing.T) {
+	tmpDir := t.TempDir()
+	outputDir := filepath.Join(tmpDir, "output")
+
+	// Create source file in a subfolder
+	srcDir := filepath.Join(tmpDir, "DCIM", "100CANON")
+	if err := os.MkdirAll(srcDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	src := filepath.Join(srcDir, "MVI_0001.MOV")
+	if err := os.WriteFile(src, []byte("content"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	rename := Rename{OriginalPath: src, TargetPath: filepath.Join(srcDir, "[01_01] intro.MOV")}
+
+	err := CopyTreeToDirectory([]Rename{rename}, tmpDir, outputDir)
+	if err != nil {
+		t.Fatalf("copy failed: %v", err)
+	}
+
+	// Subfolder should be recreated under the output directory
+	dst := filepath.Join(outputDir, "DCIM", "100CANON", "[01_01] intro.MOV")
+	content, err := os.ReadFile(dst)
+	if err != nil {
+		t.Fatalf("target doesn't exist: %v", err)
+	}
+	if string(content) != "content" {
+		t.Error("content mismatch")
+	}
+}
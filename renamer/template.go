@@ -0,0 +1,650 @@
+// renamer/template.go
+package renamer
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// DefaultTemplate produces the classic "[01_02] group name" filenames
+const DefaultTemplate = "[{seq}_{take}] {group}"
+
+// defaultNumberWidth is the padding used when a number field has no width
+const defaultNumberWidth = 2
+
+// defaultDateLayout is the layout used when {date} has no format
+const defaultDateLayout = "2006-01-02"
+
+// defaultMarker is the text {circled} and {stars} use when they have no format
+const defaultMarker = "★"
+
+// defaultTagSeparator joins tags when {tags} has no format
+const defaultTagSeparator = "+"
+
+// Fields holds the values a template can reference for one file
+type Fields struct {
+	GroupOrder int       // {seq} (alias {order}) of a top-level group
+	GroupPath  []int     // {seq} of a nested group: orders from the top level down
+	Take       int       // {take}
+	GroupName  string    // {group}
+	Stem       string    // {stem}: original filename without extension
+	Recorded   time.Time // {date}: recording date
+	Camera     string    // {camera}: camera model
+	Counter    int       // {counter}: position in the whole batch, starting at 1
+	Rating     int       // {rating} and {stars}: 0 (unrated) to 5
+	Circled    bool      // {circled}: marker for circled takes
+	Notes      string    // {notes}: free-text notes
+	Tags       []string  // {tags}: labels, joined by the field's separator
+
+	// Padding widths for the project, normally the digit count of the largest
+	// value so every name sorts correctly. They only ever widen a number; a
+	// wider width in the template itself wins.
+	OrderWidth   int
+	TakeWidth    int
+	CounterWidth int
+}
+
+// Sequence returns the group orders {seq} writes: GroupPath, or just
+// GroupOrder for a top-level group
+func (f Fields) Sequence() []int {
+	if len(f.GroupPath) > 0 {
+		return f.GroupPath
+	}
+	return []int{f.GroupOrder}
+}
+
+// DigitWidth returns the number of decimal digits in n
+func DigitWidth(n int) int {
+	return len(strconv.Itoa(n))
+}
+
+// fieldKind determines how a field is formatted and parsed back
+type fieldKind int
+
+const (
+	kindNumber fieldKind = iota
+	kindText
+	kindDate
+	kindMarker   // Text repeated a number of times, e.g. once if circled
+	kindSequence // Group orders from the top level down, e.g. 03_02
+	kindList     // Text values joined by a separator, e.g. b-roll+drone
+)
+
+// templateFields lists the fields a template may reference
+var templateFields = map[string]fieldKind{
+	"seq":     kindSequence,
+	"order":   kindSequence,
+	"take":    kindNumber,
+	"counter": kindNumber,
+	"group":   kindText,
+	"stem":    kindText,
+	"camera":  kindText,
+	"date":    kindDate,
+	"rating":  kindNumber,
+	"notes":   kindText,
+	"circled": kindMarker,
+	"stars":   kindMarker,
+	"tags":    kindList,
+}
+
+// templateModifiers lists the modifiers that can follow a field with "|"
+var templateModifiers = map[string]func(string) string{
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	"title": titleCase,
+	"slug":  slugify,
+}
+
+// Template is a parsed filename template such as
+// "{date:2006-01-02}_{seq:03}-{take:02}_{group|slug}". Fields are written as
+// {name}, {name:format} or {name|modifier}; numbers take a minimum
+// zero-padding width as their format and {date} takes a Go time layout.
+// {seq} writes one number per level of nesting, joined with "_" (03_02),
+// or with an "A" format the second level as letters (03A, {seq:03A}).
+// {circled} and {stars} take the text to write, e.g. "{circled:_OK}"; they
+// write it once for a circled take and once per rating star respectively.
+// {tags} takes the separator to join tags with, e.g. "{tags:, }" (default
+// "+").
+// "{{" and "}}" produce literal braces. The template describes the name
+// without its extension, which is always kept from the original file.
+type Template struct {
+	source  string
+	parts   []templatePart
+	pattern *regexp.Regexp
+	fields  []int // Indexes into parts of the pattern's capture groups
+}
+
+// templatePart is either a literal run of text or a field reference
+type templatePart struct {
+	literal   string
+	field     string
+	kind      fieldKind
+	width     int
+	layout    string
+	marker    string // Text of a marker field, or the separator of a list
+	letters   bool   // {seq:A}: nested levels as letters, e.g. 03A
+	modifiers []string
+}
+
+// ParseTemplate parses and validates a filename template
+func ParseTemplate(source string) (*Template, error) {
+	if strings.ContainsAny(source, `/\`) {
+		return nil, fmt.Errorf("template must not contain path separators")
+	}
+
+	t := &Template{source: source}
+	var literal strings.Builder
+	flushLiteral := func() {
+		if literal.Len() > 0 {
+			t.parts = append(t.parts, templatePart{literal: literal.String()})
+			literal.Reset()
+		}
+	}
+
+	for i := 0; i < len(source); i++ {
+		switch c := source[i]; {
+		case c == '{' && strings.HasPrefix(source[i:], "{{"):
+			literal.WriteByte('{')
+			i++
+		case c == '}' && strings.HasPrefix(source[i:], "}}"):
+			literal.WriteByte('}')
+			i++
+		case c == '}':
+			return nil, fmt.Errorf("unexpected '}' at position %d", i+1)
+		case c == '{':
+			end := strings.IndexByte(source[i:], '}')
+			if end < 0 {
+				return nil, fmt.Errorf("unclosed '{' at position %d", i+1)
+			}
+			part, err := parseTemplateField(source[i+1 : i+end])
+			if err != nil {
+				return nil, err
+			}
+			flushLiteral()
+			t.parts = append(t.parts, part)
+			i += end
+		default:
+			literal.WriteByte(c)
+		}
+	}
+	flushLiteral()
+
+	if len(t.fieldParts()) == 0 {
+		return nil, fmt.Errorf("template must reference at least one field")
+	}
+
+	t.compilePattern()
+	return t, nil
+}
+
+// MustParseTemplate is ParseTemplate for templates known to be valid
+func MustParseTemplate(source string) *Template {
+	t, err := ParseTemplate(source)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// parseTemplateField parses the inside of a {...} field reference
+func parseTemplateField(spec string) (templatePart, error) {
+	segments := strings.Split(spec, "|")
+	name, format, hasFormat := strings.Cut(segments[0], ":")
+	name = strings.TrimSpace(name)
+
+	kind, ok := templateFields[name]
+	if !ok {
+		return templatePart{}, fmt.Errorf("unknown field {%s}", name)
+	}
+	part := templatePart{field: name, kind: kind}
+
+	switch kind {
+	case kindNumber:
+		part.width = defaultNumberWidth
+		if name == "rating" {
+			part.width = 1
+		}
+		if hasFormat {
+			width, err := strconv.Atoi(format)
+			if err != nil || width < 1 {
+				return templatePart{}, fmt.Errorf("invalid width %q for {%s}", format, name)
+			}
+			part.width = width
+		}
+	case kindDate:
+		part.layout = defaultDateLayout
+		if hasFormat && format != "" {
+			part.layout = format
+		}
+	case kindSequence:
+		part.width = defaultNumberWidth
+		if hasFormat {
+			format, part.letters = strings.CutSuffix(format, "A")
+		}
+		if format != "" {
+			width, err := strconv.Atoi(format)
+			if err != nil || width < 1 {
+				return templatePart{}, fmt.Errorf("invalid format for {%s}: use a width and/or A, e.g. {%s:03A}", name, name)
+			}
+			part.width = width
+		}
+	case kindMarker:
+		part.marker = defaultMarker
+		if hasFormat && format != "" {
+			part.marker = format
+		}
+	case kindList:
+		part.marker = defaultTagSeparator
+		if hasFormat && format != "" {
+			part.marker = format
+		}
+	default:
+		if hasFormat {
+			return templatePart{}, fmt.Errorf("{%s} does not take a format", name)
+		}
+	}
+
+	for _, modifier := range segments[1:] {
+		modifier = strings.TrimSpace(modifier)
+		if _, ok := templateModifiers[modifier]; !ok {
+			return templatePart{}, fmt.Errorf("unknown modifier %q for {%s}", modifier, name)
+		}
+		part.modifiers = append(part.modifiers, modifier)
+	}
+
+	return part, nil
+}
+
+// compilePattern builds the regular expression used to parse names back
+func (t *Template) compilePattern() {
+	var b strings.Builder
+	b.WriteString("^")
+	for i, p := range t.parts {
+		if p.field == "" {
+			b.WriteString(regexp.QuoteMeta(p.literal))
+			continue
+		}
+		switch {
+		case p.kind == kindNumber:
+			b.WriteString(`(\d+)`)
+		case p.kind == kindSequence && p.letters:
+			b.WriteString(`(\d+(?:[A-Z]+(?:_\d+)*)?)`)
+		case p.kind == kindSequence:
+			b.WriteString(`(\d+(?:_\d+)*)`)
+		case p.kind == kindMarker:
+			b.WriteString(`((?:` + regexp.QuoteMeta(p.marker) + `)*)`)
+		case p.field == "camera", p.field == "notes", p.kind == kindList:
+			b.WriteString(`(.*?)`)
+		default:
+			b.WriteString(`(.+?)`)
+		}
+		t.fields = append(t.fields, i)
+	}
+	b.WriteString("$")
+	t.pattern = regexp.MustCompile(b.String())
+}
+
+// fieldParts returns the parts that reference fields
+func (t *Template) fieldParts() []templatePart {
+	var fields []templatePart
+	for _, p := range t.parts {
+		if p.field != "" {
+			fields = append(fields, p)
+		}
+	}
+	return fields
+}
+
+// String returns the template source
+func (t *Template) String() string {
+	return t.source
+}
+
+// Uses reports whether the template references a field
+func (t *Template) Uses(field string) bool {
+	for _, p := range t.parts {
+		if p.field == field {
+			return true
+		}
+	}
+	return false
+}
+
+// Execute renders the name (without extension) for the given fields
+func (t *Template) Execute(f Fields) string {
+	var b strings.Builder
+	for _, p := range t.parts {
+		if p.field == "" {
+			b.WriteString(p.literal)
+			continue
+		}
+		b.WriteString(p.render(f))
+	}
+	return b.String()
+}
+
+// Filename renders the name and appends the extension
+func (t *Template) Filename(f Fields, extension string) string {
+	return t.Execute(f) + extension
+}
+
+// Parse splits a filename produced by this template back into its fields.
+// Numbers are accepted at any padding width. Text fields come back as they
+// appear in the name, i.e. with modifiers already applied. Returns false if
+// the name doesn't follow the template.
+func (t *Template) Parse(filename string) (Fields, bool) {
+	matches := t.match(filename)
+	if matches == nil {
+		return Fields{}, false
+	}
+
+	var f Fields
+	for i, index := range t.fields {
+		p := t.parts[index]
+		value := matches[i+1]
+		switch p.kind {
+		case kindNumber:
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return Fields{}, false
+			}
+			p.setNumber(&f, n)
+		case kindDate:
+			recorded, err := time.Parse(p.layout, value)
+			if err != nil {
+				return Fields{}, false
+			}
+			f.Recorded = recorded
+		case kindSequence:
+			path, ok := p.parseSequence(value)
+			if !ok {
+				return Fields{}, false
+			}
+			f.GroupPath = path
+			f.GroupOrder = path[len(path)-1]
+		case kindMarker:
+			p.setMarker(&f, strings.Count(value, p.marker))
+		case kindList:
+			f.Tags = nil
+			for _, tag := range strings.Split(value, p.marker) {
+				if tag != "" {
+					f.Tags = append(f.Tags, tag)
+				}
+			}
+		default:
+			p.setText(&f, value)
+		}
+	}
+	return f, true
+}
+
+// Matches reports whether filename is the name this template produces for
+// the given fields, accepting numbers at any padding width
+func (t *Template) Matches(filename string, f Fields) bool {
+	matches := t.match(filename)
+	if matches == nil {
+		return false
+	}
+
+	for i, index := range t.fields {
+		p := t.parts[index]
+		value := matches[i+1]
+		if p.kind == kindNumber {
+			n, err := strconv.Atoi(value)
+			if err != nil || n != p.number(f) {
+				return false
+			}
+			continue
+		}
+		if p.kind == kindSequence {
+			path, ok := p.parseSequence(value)
+			if !ok || !slices.Equal(path, f.Sequence()) {
+				return false
+			}
+			continue
+		}
+		if value != p.render(f) {
+			return false
+		}
+	}
+	return true
+}
+
+// match runs the pattern against a filename without its extension
+func (t *Template) match(filename string) []string {
+	name := filepath.Base(filename)
+	name = strings.TrimSuffix(name, filepath.Ext(name))
+	return t.pattern.FindStringSubmatch(name)
+}
+
+// render formats a field part for the given fields
+func (p templatePart) render(f Fields) string {
+	var value string
+	switch p.kind {
+	case kindNumber:
+		value = padNumber(p.number(f), max(p.width, p.projectWidth(f)))
+	case kindSequence:
+		value = p.sequence(f.Sequence(), max(p.width, f.OrderWidth))
+	case kindDate:
+		if !f.Recorded.IsZero() {
+			value = f.Recorded.Format(p.layout)
+		}
+	case kindMarker:
+		value = strings.Repeat(p.marker, p.markerCount(f))
+	case kindList:
+		value = strings.Join(f.Tags, p.marker)
+	default:
+		value = p.text(f)
+	}
+
+	for _, modifier := range p.modifiers {
+		value = templateModifiers[modifier](value)
+	}
+	return value
+}
+
+// sequence formats group orders from the top level down
+func (p templatePart) sequence(path []int, width int) string {
+	var b strings.Builder
+	for level, order := range path {
+		switch {
+		case level == 0:
+			b.WriteString(padNumber(order, width))
+		case level == 1 && p.letters:
+			b.WriteString(letters(order))
+		default:
+			b.WriteString("_" + padNumber(order, width))
+		}
+	}
+	return b.String()
+}
+
+// parseSequence reads group orders back from a {seq} value
+func (p templatePart) parseSequence(value string) ([]int, bool) {
+	var path []int
+	head, rest, _ := strings.Cut(value, "_")
+	if p.letters {
+		// The first level ends where the letters start
+		split := strings.IndexFunc(head, func(r rune) bool { return r < '0' || r > '9' })
+		if split > 0 {
+			order, err := strconv.Atoi(head[:split])
+			if err != nil {
+				return nil, false
+			}
+			path = append(path, order, fromLetters(head[split:]))
+			head = ""
+		}
+	}
+	levels := strings.Split(rest, "_")
+	if rest == "" {
+		levels = nil
+	}
+	if head != "" {
+		levels = append([]string{head}, levels...)
+	}
+	for _, level := range levels {
+		order, err := strconv.Atoi(level)
+		if err != nil {
+			return nil, false
+		}
+		path = append(path, order)
+	}
+	return path, len(path) > 0
+}
+
+// number returns the value of a number field
+func (p templatePart) number(f Fields) int {
+	switch p.field {
+	case "take":
+		return f.Take
+	case "counter":
+		return f.Counter
+	case "rating":
+		return f.Rating
+	default:
+		return f.GroupOrder
+	}
+}
+
+// projectWidth returns the project-wide padding width for a number field
+func (p templatePart) projectWidth(f Fields) int {
+	switch p.field {
+	case "take":
+		return f.TakeWidth
+	case "counter":
+		return f.CounterWidth
+	case "rating":
+		return 0
+	default:
+		return f.OrderWidth
+	}
+}
+
+// setNumber stores a parsed number field
+func (p templatePart) setNumber(f *Fields, n int) {
+	switch p.field {
+	case "take":
+		f.Take = n
+	case "counter":
+		f.Counter = n
+	case "rating":
+		f.Rating = n
+	default:
+		f.GroupOrder = n
+	}
+}
+
+// markerCount returns how many times a marker field writes its text
+func (p templatePart) markerCount(f Fields) int {
+	if p.field == "stars" {
+		return max(f.Rating, 0)
+	}
+	if f.Circled {
+		return 1
+	}
+	return 0
+}
+
+// setMarker stores a parsed marker field
+func (p templatePart) setMarker(f *Fields, count int) {
+	if p.field == "stars" {
+		f.Rating = count
+	} else {
+		f.Circled = count > 0
+	}
+}
+
+// text returns the value of a text field
+func (p templatePart) text(f Fields) string {
+	switch p.field {
+	case "stem":
+		return f.Stem
+	case "camera":
+		return f.Camera
+	case "notes":
+		return f.Notes
+	default:
+		return f.GroupName
+	}
+}
+
+// setText stores a parsed text field
+func (p templatePart) setText(f *Fields, value string) {
+	switch p.field {
+	case "stem":
+		f.Stem = value
+	case "camera":
+		f.Camera = value
+	case "notes":
+		f.Notes = value
+	default:
+		f.GroupName = value
+	}
+}
+
+// letters writes 1, 2, ..., 26, 27 as A, B, ..., Z, AA
+func letters(n int) string {
+	var s string
+	for n > 0 {
+		n--
+		s = string(rune('A'+n%26)) + s
+		n /= 26
+	}
+	return s
+}
+
+// fromLetters reverses letters
+func fromLetters(s string) int {
+	n := 0
+	for _, r := range s {
+		n = n*26 + int(r-'A') + 1
+	}
+	return n
+}
+
+// padNumber formats a number with leading zeros up to width digits
+func padNumber(n, width int) string {
+	return fmt.Sprintf("%0*d", width, n)
+}
+
+// titleCase capitalises the first letter of every word
+func titleCase(s string) string {
+	runes := []rune(s)
+	start := true
+	for i, r := range runes {
+		if unicode.IsSpace(r) {
+			start = true
+			continue
+		}
+		if start {
+			runes[i] = unicode.ToUpper(r)
+			start = false
+		}
+	}
+	return string(runes)
+}
+
+// slugify lowercases a string and joins its words with hyphens, dropping
+// anything that isn't a letter or digit
+func slugify(s string) string {
+	var b strings.Builder
+	pendingHyphen := false
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if pendingHyphen && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			pendingHyphen = false
+			b.WriteRune(r)
+			continue
+		}
+		pendingHyphen = true
+	}
+	return b.String()
+}
@@ -0,0 +1,290 @@
+// renamer/template_test.go
+package renamer
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestTemplate_Execute(t *testing.T) {
+	fields := Fields{
+		GroupOrder: 3,
+		Take:       2,
+		GroupName:  "Magic Trick!",
+		Stem:       "C0001",
+		Recorded:   time.Date(2026, 1, 12, 14, 3, 22, 0, time.UTC),
+		Camera:     "ILCE-7SM3",
+		Counter:    17,
+	}
+
+	tests := []struct {
+		template string
+		expected string
+	}{
+		{DefaultTemplate, "[03_02] Magic Trick!"},
+		{"{date:2006-01-02}_{seq:03}-{take:02}_{group|slug}", "2026-01-12_003-02_magic-trick"},
+		{"{order}_{stem}", "03_C0001"},
+		{"{counter:4} {camera|lower}", "0017 ilce-7sm3"},
+		{"{group|upper}", "MAGIC TRICK!"},
+		{"{group|lower|title}", "Magic Trick!"},
+		{"{date}", "2026-01-12"},
+		{"{{{take}}}", "{02}"},
+	}
+
+	for _, tt := range tests {
+		tmpl, err := ParseTemplate(tt.template)
+		if err != nil {
+			t.Errorf("ParseTemplate(%q) failed: %v", tt.template, err)
+			continue
+		}
+		if result := tmpl.Execute(fields); result != tt.expected {
+			t.Errorf("Execute(%q) = %q, want %q", tt.template, result, tt.expected)
+		}
+	}
+}
+
+func TestParseTemplate_Errors(t *testing.T) {
+	tests := []string{
+		"",
+		"no fields",
+		"{scene}",
+		"{take:abc}",
+		"{take:0}",
+		"{group:03}",
+		"{group|reverse}",
+		"{group",
+		"group}",
+		"{date}/{group}",
+	}
+
+	for _, source := range tests {
+		if _, err := ParseTemplate(source); err == nil {
+			t.Errorf("ParseTemplate(%q) should fail", source)
+		}
+	}
+}
+
+func TestTemplate_Parse(t *testing.T) {
+	tmpl := MustParseTemplate("{date:20060102}_{seq:03}-{take:02}_{group|slug}")
+
+	fields, ok := tmpl.Parse("20260112_004-12_magic-trick.MP4")
+	if !ok {
+		t.Fatal("expected name to parse")
+	}
+	if fields.GroupOrder != 4 || fields.Take != 12 || fields.GroupName != "magic-trick" {
+		t.Errorf("unexpected fields: %+v", fields)
+	}
+	if !fields.Recorded.Equal(time.Date(2026, 1, 12, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected date: %v", fields.Recorded)
+	}
+
+	// Other padding widths are accepted
+	if fields, ok := tmpl.Parse("20260112_4-1_intro.MP4"); !ok || fields.GroupOrder != 4 || fields.Take != 1 {
+		t.Errorf("expected unpadded name to parse, got %+v, %v", fields, ok)
+	}
+
+	for _, name := range []string{"C0001.MP4", "2026_004-12_intro.MP4", "20260112_004-12_.MP4"} {
+		if _, ok := tmpl.Parse(name); ok {
+			t.Errorf("expected %s not to parse", name)
+		}
+	}
+}
+
+func TestTemplate_ParseDefault(t *testing.T) {
+	fields, ok := defaultTemplate.Parse("[01_02] magic trick.mov")
+	if !ok {
+		t.Fatal("expected default name to parse")
+	}
+	if fields.GroupOrder != 1 || fields.Take != 2 || fields.GroupName != "magic trick" {
+		t.Errorf("unexpected fields: %+v", fields)
+	}
+}
+
+func TestTemplate_Matches(t *testing.T) {
+	tmpl := MustParseTemplate("{seq:03}-{take}_{group|slug}")
+	fields := Fields{GroupOrder: 7, Take: 3, GroupName: "Wide Shot"}
+
+	tests := []struct {
+		name     string
+		expected bool
+	}{
+		{"007-03_wide-shot.mov", true},
+		{"07-03_wide-shot.mov", true}, // Older padding width
+		{"007-04_wide-shot.mov", false},
+		{"007-03_close-up.mov", false},
+		{"Wide Shot.mov", false},
+	}
+
+	for _, tt := range tests {
+		if result := tmpl.Matches(tt.name, fields); result != tt.expected {
+			t.Errorf("Matches(%s) = %v, want %v", tt.name, result, tt.expected)
+		}
+	}
+}
+
+func TestTemplate_Annotation(t *testing.T) {
+	circled := Fields{GroupOrder: 1, Take: 2, GroupName: "intro", Rating: 4, Circled: true, Notes: "focus buzz"}
+	plain := Fields{GroupOrder: 1, Take: 3, GroupName: "intro"}
+
+	tests := []struct {
+		template string
+		fields   Fields
+		expected string
+	}{
+		{"[{seq}_{take}] {group}{circled:_OK}", circled, "[01_02] intro_OK"},
+		{"[{seq}_{take}] {group}{circled:_OK}", plain, "[01_03] intro"},
+		{"{circled}{group}", circled, "★intro"},
+		{"{group} {stars}", circled, "intro ★★★★"},
+		{"{group} {stars:*}", plain, "intro "},
+		{"{group}_r{rating}", circled, "intro_r4"},
+		{"{group}_{notes|slug}", circled, "intro_focus-buzz"},
+	}
+
+	for _, tt := range tests {
+		tmpl, err := ParseTemplate(tt.template)
+		if err != nil {
+			t.Errorf("ParseTemplate(%q) failed: %v", tt.template, err)
+			continue
+		}
+		result := tmpl.Execute(tt.fields)
+		if result != tt.expected {
+			t.Errorf("Execute(%q) = %q, want %q", tt.template, result, tt.expected)
+		}
+		if !tmpl.Matches(result+".mov", tt.fields) {
+			t.Errorf("expected %q to match its own fields", result)
+		}
+	}
+
+	// Markers are read back
+	tmpl := MustParseTemplate("[{seq}_{take}] {group}{circled:_OK} {stars}")
+	fields, ok := tmpl.Parse("[01_02] intro_OK ★★★.mov")
+	if !ok || !fields.Circled || fields.Rating != 3 || fields.GroupName != "intro" {
+		t.Errorf("unexpected fields: %+v, %v", fields, ok)
+	}
+	fields, ok = tmpl.Parse("[01_02] intro .mov")
+	if !ok || fields.Circled || fields.Rating != 0 {
+		t.Errorf("unexpected fields: %+v, %v", fields, ok)
+	}
+}
+
+func TestTemplate_Tags(t *testing.T) {
+	tagged := Fields{GroupOrder: 1, Take: 2, GroupName: "intro", Tags: []string{"b-roll", "drone"}}
+	plain := Fields{GroupOrder: 1, Take: 3, GroupName: "intro"}
+
+	tests := []struct {
+		template string
+		fields   Fields
+		expected string
+	}{
+		{"[{seq}_{take}] {group} {tags}", tagged, "[01_02] intro b-roll+drone"},
+		{"[{seq}_{take}] {group} {tags}", plain, "[01_03] intro "},
+		{"{group} ({tags:, })", tagged, "intro (b-roll, drone)"},
+		{"{group}_{tags:_|upper}", tagged, "intro_B-ROLL_DRONE"},
+	}
+
+	for _, tt := range tests {
+		tmpl, err := ParseTemplate(tt.template)
+		if err != nil {
+			t.Errorf("ParseTemplate(%q) failed: %v", tt.template, err)
+			continue
+		}
+		result := tmpl.Execute(tt.fields)
+		if result != tt.expected {
+			t.Errorf("Execute(%q) = %q, want %q", tt.template, result, tt.expected)
+		}
+		if !tmpl.Matches(result+".mov", tt.fields) {
+			t.Errorf("expected %q to match its own fields", result)
+		}
+	}
+
+	// Tags are read back
+	tmpl := MustParseTemplate("[{seq}_{take}] {group} ({tags:, })")
+	fields, ok := tmpl.Parse("[01_02] intro (b-roll, drone).mov")
+	if !ok || !slices.Equal(fields.Tags, []string{"b-roll", "drone"}) {
+		t.Errorf("unexpected fields: %+v, %v", fields, ok)
+	}
+	fields, ok = tmpl.Parse("[01_02] intro ().mov")
+	if !ok || fields.Tags != nil {
+		t.Errorf("unexpected fields: %+v, %v", fields, ok)
+	}
+}
+
+func TestSlugify(t *testing.T) {
+	tests := map[string]string{
+		"Magic Trick":      "magic-trick",
+		"  B-roll (wide) ": "b-roll-wide",
+		"Café / Über":      "café-über",
+		"!!!":              "",
+	}
+
+	for input, expected := range tests {
+		if result := slugify(input); result != expected {
+			t.Errorf("slugify(%q) = %q, want %q", input, result, expected)
+		}
+	}
+}
+
+func TestTemplate_ProjectWidths(t *testing.T) {
+	fields := Fields{GroupOrder: 7, Take: 3, Counter: 12, OrderWidth: 3, TakeWidth: 1, CounterWidth: 4}
+
+	tests := []struct {
+		template string
+		expected string
+	}{
+		// Project widths widen the default two digits
+		{DefaultTemplate + " {counter}", "[007_03]  0012"},
+		// A wider template width wins
+		{"{seq:04}-{take:03}", "0007-003"},
+	}
+
+	for _, tt := range tests {
+		if result := MustParseTemplate(tt.template).Execute(fields); result != tt.expected {
+			t.Errorf("Execute(%q) = %q, want %q", tt.template, result, tt.expected)
+		}
+	}
+}
+
+func TestTemplate_Sequence(t *testing.T) {
+	shot := Fields{GroupOrder: 2, GroupPath: []int{3, 2}, Take: 1, GroupName: "wide"}
+
+	tests := []struct {
+		template string
+		fields   Fields
+		expected string
+	}{
+		{DefaultTemplate, shot, "[03_02_01] wide"},
+		{"[{seq:A}_{take}] {group}", shot, "[03B_01] wide"},
+		{"[{seq:03A}_{take}] {group}", Fields{GroupPath: []int{3, 28, 4}, Take: 1, GroupName: "wide"}, "[003AB_004_01] wide"},
+		{"[{seq:A}_{take}] {group}", Fields{GroupOrder: 3, Take: 1, GroupName: "scene"}, "[03_01] scene"},
+	}
+
+	for _, tt := range tests {
+		tmpl := MustParseTemplate(tt.template)
+		result := tmpl.Execute(tt.fields)
+		if result != tt.expected {
+			t.Errorf("Execute(%q) = %q, want %q", tt.template, result, tt.expected)
+			continue
+		}
+		if !tmpl.Matches(result+".mov", tt.fields) {
+			t.Errorf("expected %q to match its own fields", result)
+		}
+		parsed, ok := tmpl.Parse(result + ".mov")
+		if !ok || !reflect.DeepEqual(parsed.Sequence(), tt.fields.Sequence()) || parsed.Take != tt.fields.Take {
+			t.Errorf("Parse(%q) = %+v, %v", result, parsed, ok)
+		}
+	}
+
+	// A flat name doesn't match a nested group, or the other way round
+	if defaultTemplate.Matches("[03_02] wide.mov", shot) {
+		t.Error("expected a two-level name not to match a shot")
+	}
+	if !defaultTemplate.Matches("[03_02] scene.mov", Fields{GroupOrder: 3, Take: 2, GroupName: "scene"}) {
+		t.Error("expected two-level names to keep matching top-level groups")
+	}
+
+	if _, err := ParseTemplate("{seq:B}"); err == nil {
+		t.Error("expected error for an invalid sequence format")
+	}
+}
@@ -0,0 +1,227 @@
+// renamer/transaction.go
+package renamer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// JournalFileName is the transaction journal written next to the state file
+// while a rename batch is in progress
+const JournalFileName = ".clip-tagger-journal.json"
+
+// tempPrefix marks files parked under a temporary name to break rename cycles
+const tempPrefix = ".clip-tagger-tmp-"
+
+// Step is a single os.Rename performed by a transaction
+type Step struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// journal is the on-disk record of a rename transaction
+type journal struct {
+	Started time.Time `json:"started"`
+	Steps   []Step    `json:"steps"`
+}
+
+// PlanRenames orders a batch of renames into steps that never overwrite a
+// file still waiting to be moved. Chains (A -> B while B -> C) are ordered so
+// B moves first; cycles (A -> B, B -> A) are broken by parking one file under
+// a temporary name. No-op renames are dropped.
+func PlanRenames(renames []Rename) ([]Step, error) {
+	var pending []Step
+	targets := make(map[string]string)
+	for _, r := range renames {
+		if r.OriginalPath == r.TargetPath {
+			continue
+		}
+		if other, exists := targets[r.TargetPath]; exists {
+			return nil, fmt.Errorf("%s and %s would both be renamed to %s",
+				filepath.Base(other), filepath.Base(r.OriginalPath), filepath.Base(r.TargetPath))
+		}
+		targets[r.TargetPath] = r.OriginalPath
+		pending = append(pending, Step{From: r.OriginalPath, To: r.TargetPath})
+	}
+
+	// Sources that haven't been moved yet; their paths are still occupied
+	occupied := make(map[string]bool)
+	for _, s := range pending {
+		occupied[s.From] = true
+	}
+
+	var steps []Step
+	tempCount := 0
+	for len(pending) > 0 {
+		progressed := false
+		remaining := pending[:0]
+		for _, s := range pending {
+			if occupied[s.To] {
+				remaining = append(remaining, s)
+				continue
+			}
+			steps = append(steps, s)
+			delete(occupied, s.From)
+			progressed = true
+		}
+		pending = remaining
+
+		if !progressed && len(pending) > 0 {
+			// Every remaining target is occupied by another pending source,
+			// so they form cycles; park one file to free its path
+			s := &pending[0]
+			tempCount++
+			temp := filepath.Join(filepath.Dir(s.From),
+				fmt.Sprintf("%s%d-%s", tempPrefix, tempCount, filepath.Base(s.From)))
+			steps = append(steps, Step{From: s.From, To: temp})
+			delete(occupied, s.From)
+			occupied[temp] = true
+			s.From = temp
+		}
+	}
+
+	return steps, nil
+}
+
+// ExecuteRenames performs a batch of renames as a single transaction. The plan
+// is checked against the filesystem first, so files outside the batch are
+// never overwritten. Missing target folders are created. Files moved into a
+// freedesktop.org trash get the info file that lets the trash restore them.
+// If journalPath is non-empty the plan is written there before anything is
+// touched and removed once the batch has succeeded or been rolled back. If
+// any step fails, every completed step is undone in reverse.
+func ExecuteRenames(renames []Rename, journalPath string) error {
+	steps, err := PlanRenames(renames)
+	if err != nil {
+		return err
+	}
+	if len(steps) == 0 {
+		return nil
+	}
+
+	if err := checkTargets(steps); err != nil {
+		return err
+	}
+
+	if journalPath != "" {
+		if err := writeJournal(journalPath, steps); err != nil {
+			return fmt.Errorf("write journal: %w", err)
+		}
+	}
+
+	for i, s := range steps {
+		if err := move(s.From, s.To); err != nil {
+			stepErr := fmt.Errorf("rename %s -> %s: %w",
+				filepath.Base(s.From), filepath.Base(s.To), err)
+
+			if rollbackErr := rollback(steps[:i]); rollbackErr != nil {
+				return fmt.Errorf("%w; rollback failed, journal kept at %s: %v",
+					stepErr, journalPath, rollbackErr)
+			}
+			removeJournal(journalPath)
+			return fmt.Errorf("%w (all changes rolled back)", stepErr)
+		}
+	}
+
+	removeJournal(journalPath)
+	return nil
+}
+
+// RecoverJournal rolls back a transaction that was interrupted (for example
+// by a crash or power loss) and left its journal behind. Steps are undone in
+// reverse wherever the destination exists and the source is free, so it is
+// safe to run no matter how far the transaction got. Returns false if there
+// was no journal.
+func RecoverJournal(journalPath string) (bool, error) {
+	data, err := os.ReadFile(journalPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("read journal: %w", err)
+	}
+
+	var j journal
+	if err := json.Unmarshal(data, &j); err != nil {
+		return true, fmt.Errorf("unmarshal journal: %w", err)
+	}
+
+	for i := len(j.Steps) - 1; i >= 0; i-- {
+		s := j.Steps[i]
+		if !exists(s.To) || exists(s.From) {
+			continue // Step never ran
+		}
+		if err := move(s.To, s.From); err != nil {
+			return true, fmt.Errorf("restore %s: %w", filepath.Base(s.From), err)
+		}
+	}
+
+	if err := os.Remove(journalPath); err != nil {
+		return true, fmt.Errorf("remove journal: %w", err)
+	}
+	return true, nil
+}
+
+// checkTargets refuses a plan that would overwrite a file outside the batch
+func checkTargets(steps []Step) error {
+	sources := make(map[string]bool)
+	for _, s := range steps {
+		sources[s.From] = true
+	}
+	for _, s := range steps {
+		if !sources[s.To] && exists(s.To) {
+			return fmt.Errorf("%s already exists and is not part of this batch", filepath.Base(s.To))
+		}
+	}
+	return nil
+}
+
+// rollback undoes completed steps in reverse order
+func rollback(completed []Step) error {
+	for i := len(completed) - 1; i >= 0; i-- {
+		s := completed[i]
+		if err := move(s.To, s.From); err != nil {
+			return fmt.Errorf("restore %s: %w", filepath.Base(s.From), err)
+		}
+	}
+	return nil
+}
+
+// writeJournal durably records the plan before any file is moved
+func writeJournal(path string, steps []Step) error {
+	data, err := json.MarshalIndent(journal{Started: time.Now(), Steps: steps}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// removeJournal deletes the journal once the directory is consistent again
+func removeJournal(path string) {
+	if path != "" {
+		_ = os.Remove(path)
+	}
+}
+
+// exists reports whether a path exists
+func exists(path string) bool {
+	_, err := os.Lstat(path)
+	return err == nil
+}
@@ -0,0 +1,215 @@
+// renamer/transaction_test.go
+package renamer
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeFiles creates files whose content is their own base name
+func writeFiles(t *testing.T, dir string, names ...string) {
+	t.Helper()
+	for _, name := range names {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// assertContent checks that a file holds the given content
+func assertContent(t *testing.T, path, expected string) {
+	t.Helper()
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Errorf("read %s: %v", filepath.Base(path), err)
+		return
+	}
+	if string(content) != expected {
+		t.Errorf("expected %s to contain %q, got %q", filepath.Base(path), expected, string(content))
+	}
+}
+
+func TestPlanRenames_Chain(t *testing.T) {
+	renames := []Rename{
+		{OriginalPath: "/d/a", TargetPath: "/d/b"},
+		{OriginalPath: "/d/b", TargetPath: "/d/c"},
+	}
+
+	steps, err := PlanRenames(renames)
+	if err != nil {
+		t.Fatalf("plan failed: %v", err)
+	}
+
+	expected := []Step{{From: "/d/b", To: "/d/c"}, {From: "/d/a", To: "/d/b"}}
+	if len(steps) != len(expected) {
+		t.Fatalf("expected %d steps, got %v", len(expected), steps)
+	}
+	for i := range expected {
+		if steps[i] != expected[i] {
+			t.Errorf("step %d: expected %v, got %v", i, expected[i], steps[i])
+		}
+	}
+}
+
+func TestPlanRenames_DuplicateTarget(t *testing.T) {
+	renames := []Rename{
+		{OriginalPath: "/d/a", TargetPath: "/d/x"},
+		{OriginalPath: "/d/b", TargetPath: "/d/x"},
+	}
+
+	if _, err := PlanRenames(renames); err == nil {
+		t.Error("expected error for duplicate targets")
+	}
+}
+
+func TestExecuteRenames_Swap(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeFiles(t, tmpDir, "a.mp4", "b.mp4")
+
+	a := filepath.Join(tmpDir, "a.mp4")
+	b := filepath.Join(tmpDir, "b.mp4")
+	renames := []Rename{
+		{OriginalPath: a, TargetPath: b},
+		{OriginalPath: b, TargetPath: a},
+	}
+
+	if err := ExecuteRenames(renames, ""); err != nil {
+		t.Fatalf("swap failed: %v", err)
+	}
+
+	assertContent(t, a, "b.mp4")
+	assertContent(t, b, "a.mp4")
+
+	entries, _ := os.ReadDir(tmpDir)
+	if len(entries) != 2 {
+		t.Errorf("expected no temporary files left, got %d entries", len(entries))
+	}
+}
+
+func TestExecuteRenames_RefusesToOverwrite(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeFiles(t, tmpDir, "a.mp4", "b.mp4", "existing.mp4")
+
+	renames := []Rename{
+		{OriginalPath: filepath.Join(tmpDir, "a.mp4"), TargetPath: filepath.Join(tmpDir, "renamed_a.mp4")},
+		{OriginalPath: filepath.Join(tmpDir, "b.mp4"), TargetPath: filepath.Join(tmpDir, "existing.mp4")},
+	}
+
+	if err := ExecuteRenames(renames, ""); err == nil {
+		t.Fatal("expected error when target exists outside the batch")
+	}
+
+	// Nothing should have been touched
+	assertContent(t, filepath.Join(tmpDir, "a.mp4"), "a.mp4")
+	assertContent(t, filepath.Join(tmpDir, "existing.mp4"), "existing.mp4")
+}
+
+func TestExecuteRenames_RollsBackOnFailure(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeFiles(t, tmpDir, "a.mp4", "b.mp4", "blocker")
+	journalPath := filepath.Join(tmpDir, JournalFileName)
+
+	renames := []Rename{
+		{OriginalPath: filepath.Join(tmpDir, "a.mp4"), TargetPath: filepath.Join(tmpDir, "renamed_a.mp4")},
+		// A file stands where the target directory would go, so this step fails
+		{OriginalPath: filepath.Join(tmpDir, "b.mp4"), TargetPath: filepath.Join(tmpDir, "blocker", "b.mp4")},
+	}
+
+	if err := ExecuteRenames(renames, journalPath); err == nil {
+		t.Fatal("expected error")
+	}
+
+	assertContent(t, filepath.Join(tmpDir, "a.mp4"), "a.mp4")
+	assertContent(t, filepath.Join(tmpDir, "b.mp4"), "b.mp4")
+	if _, err := os.Stat(filepath.Join(tmpDir, "renamed_a.mp4")); !os.IsNotExist(err) {
+		t.Error("completed step was not rolled back")
+	}
+	if _, err := os.Stat(journalPath); !os.IsNotExist(err) {
+		t.Error("journal should be removed after a successful rollback")
+	}
+}
+
+func TestExecuteRenames_RemovesJournalOnSuccess(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeFiles(t, tmpDir, "a.mp4")
+	journalPath := filepath.Join(tmpDir, JournalFileName)
+
+	renames := []Rename{
+		{OriginalPath: filepath.Join(tmpDir, "a.mp4"), TargetPath: filepath.Join(tmpDir, "renamed_a.mp4")},
+	}
+
+	if err := ExecuteRenames(renames, journalPath); err != nil {
+		t.Fatalf("rename failed: %v", err)
+	}
+	if _, err := os.Stat(journalPath); !os.IsNotExist(err) {
+		t.Error("journal should be removed after success")
+	}
+}
+
+func TestRecoverJournal(t *testing.T) {
+	tmpDir := t.TempDir()
+	journalPath := filepath.Join(tmpDir, JournalFileName)
+
+	a := filepath.Join(tmpDir, "a.mp4")
+	b := filepath.Join(tmpDir, "b.mp4")
+	temp := filepath.Join(tmpDir, tempPrefix+"1-a.mp4")
+
+	// Simulate a swap interrupted after its first two steps: a was parked
+	// and b moved into a's place, but the parked file never reached b
+	steps := []Step{
+		{From: a, To: temp},
+		{From: b, To: a},
+		{From: temp, To: b},
+	}
+	data, _ := json.Marshal(journal{Steps: steps})
+	if err := os.WriteFile(journalPath, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(temp, []byte("a.mp4"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(a, []byte("b.mp4"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	recovered, err := RecoverJournal(journalPath)
+	if err != nil {
+		t.Fatalf("recover failed: %v", err)
+	}
+	if !recovered {
+		t.Fatal("expected journal to be recovered")
+	}
+
+	assertContent(t, a, "a.mp4")
+	assertContent(t, b, "b.mp4")
+	if _, err := os.Stat(temp); !os.IsNotExist(err) {
+		t.Error("temporary file should be gone")
+	}
+	if _, err := os.Stat(journalPath); !os.IsNotExist(err) {
+		t.Error("journal should be removed after recovery")
+	}
+}
+
+func TestRecoverJournal_NoJournal(t *testing.T) {
+	recovered, err := RecoverJournal(filepath.Join(t.TempDir(), JournalFileName))
+	if err != nil || recovered {
+		t.Errorf("expected nothing to recover, got %v, %v", recovered, err)
+	}
+}
+
+func TestExecuteRenames_CreatesTargetFolders(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeFiles(t, tmpDir, "a.mp4")
+
+	target := filepath.Join(tmpDir, "_rejected", "day1", "a.mp4")
+	renames := []Rename{
+		{OriginalPath: filepath.Join(tmpDir, "a.mp4"), TargetPath: target},
+	}
+
+	if err := ExecuteRenames(renames, ""); err != nil {
+		t.Fatalf("rename failed: %v", err)
+	}
+	assertContent(t, target, "a.mp4")
+}
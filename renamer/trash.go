@@ -0,0 +1,130 @@
+// renamer/trash.go
+package renamer
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// trashInfoExt is the extension of the freedesktop.org trash info file that
+// records where a trashed file came from
+const trashInfoExt = ".trashinfo"
+
+// TrashTargets picks where each file goes in the freedesktop.org trash: a
+// free name in the files folder of the trash on the file's filesystem. Names
+// already taken in the trash or earlier in paths get a number, e.g.
+// "C0001.2.MP4". Nothing is created on disk; see CreateTrash. Only
+// supported on Linux.
+func TrashTargets(paths []string) ([]string, error) {
+	targets := make([]string, len(paths))
+	taken := make(map[string]bool)
+	for i, p := range paths {
+		dir, err := trashDir(p)
+		if err != nil {
+			return nil, err
+		}
+
+		ext := filepath.Ext(p)
+		stem := strings.TrimSuffix(filepath.Base(p), ext)
+		name := stem + ext
+		for n := 2; taken[filepath.Join(dir, "files", name)] || trashNameUsed(dir, name); n++ {
+			name = fmt.Sprintf("%s.%d%s", stem, n, ext)
+		}
+		targets[i] = filepath.Join(dir, "files", name)
+		taken[targets[i]] = true
+	}
+	return targets, nil
+}
+
+// CreateTrash creates the trashes the renames move files into, with the
+// private permissions the trash specification requires. Call it right
+// before the renames run.
+func CreateTrash(renames []Rename) error {
+	for _, r := range renames {
+		if _, ok := trashInfoPath(r.TargetPath); !ok {
+			continue
+		}
+		dir := filepath.Dir(filepath.Dir(r.TargetPath))
+		for _, sub := range []string{"files", "info"} {
+			if err := os.MkdirAll(filepath.Join(dir, sub), 0700); err != nil {
+				return fmt.Errorf("create trash: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// trashNameUsed reports whether a trash already holds a file or info file
+// with the name
+func trashNameUsed(dir, name string) bool {
+	return exists(filepath.Join(dir, "files", name)) || exists(filepath.Join(dir, "info", name+trashInfoExt))
+}
+
+// trashInfoPath returns the info file for a path inside a trash's files
+// folder, or false if the path isn't in a trash
+func trashInfoPath(path string) (string, bool) {
+	files := filepath.Dir(path)
+	if filepath.Base(files) != "files" {
+		return "", false
+	}
+	dir := filepath.Dir(files)
+	if name := filepath.Base(dir); name != "Trash" && !strings.HasPrefix(name, ".Trash") {
+		return "", false
+	}
+	return filepath.Join(dir, "info", filepath.Base(path)+trashInfoExt), true
+}
+
+// writeTrashInfo records where a trashed file came from. The info file is
+// created exclusively, as the trash specification requires, so a name
+// claimed by another program in the meantime fails the move.
+func writeTrashInfo(infoPath, original string) error {
+	original, err := filepath.Abs(original)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(infoPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return fmt.Errorf("write trash info: %w", err)
+	}
+	escaped := (&url.URL{Path: filepath.ToSlash(original)}).EscapedPath()
+	_, err = fmt.Fprintf(f, "[Trash Info]\nPath=%s\nDeletionDate=%s\n",
+		escaped, time.Now().Format("2006-01-02T15:04:05"))
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(infoPath)
+		return fmt.Errorf("write trash info: %w", err)
+	}
+	return nil
+}
+
+// move renames one file, creating the target's folder first. Moves into a
+// trash write the file's info file and moves out of one remove it, so
+// rollbacks and undo keep the trash consistent.
+func move(from, to string) error {
+	if err := os.MkdirAll(filepath.Dir(to), 0755); err != nil {
+		return err
+	}
+
+	infoPath, toTrash := trashInfoPath(to)
+	if toTrash {
+		if err := writeTrashInfo(infoPath, from); err != nil {
+			return err
+		}
+	}
+	if err := os.Rename(from, to); err != nil {
+		if toTrash {
+			_ = os.Remove(infoPath)
+		}
+		return err
+	}
+	if infoPath, fromTrash := trashInfoPath(from); fromTrash {
+		_ = os.Remove(infoPath)
+	}
+	return nil
+}
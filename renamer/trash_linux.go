@@ -0,0 +1,64 @@
+// renamer/trash_linux.go
+//go:build linux
+
+package renamer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/sys/unix"
+)
+
+// trashDir returns the trash for a file: the home trash when the file is on
+// the same filesystem as the home directory, otherwise $topdir/.Trash-$uid
+// at the top of the file's own filesystem, so files are never copied
+func trashDir(path string) (string, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	dev, err := device(path)
+	if err != nil {
+		return "", fmt.Errorf("find trash for %s: %w", filepath.Base(path), err)
+	}
+
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("find trash: %w", err)
+		}
+		dataHome = filepath.Join(home, ".local", "share")
+	}
+	if homeDev, err := device(dataHome); err == nil && homeDev == dev {
+		return filepath.Join(dataHome, "Trash"), nil
+	}
+
+	// Climb to the mount point of the file's filesystem
+	top := filepath.Dir(path)
+	for top != filepath.Dir(top) {
+		if parentDev, err := device(filepath.Dir(top)); err != nil || parentDev != dev {
+			break
+		}
+		top = filepath.Dir(top)
+	}
+	return filepath.Join(top, fmt.Sprintf(".Trash-%d", os.Getuid())), nil
+}
+
+// device returns the filesystem of a path, or of its nearest existing
+// parent when it doesn't exist yet
+func device(path string) (uint64, error) {
+	for {
+		var st unix.Stat_t
+		err := unix.Stat(path, &st)
+		if err == nil {
+			return st.Dev, nil
+		}
+		if err != unix.ENOENT || path == filepath.Dir(path) {
+			return 0, err
+		}
+		path = filepath.Dir(path)
+	}
+}
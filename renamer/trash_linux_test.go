@@ -0,0 +1,80 @@
+// renamer/trash_linux_test.go
+//go:build linux
+
+package renamer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTrash_RejectAndUndo(t *testing.T) {
+	tmpDir := t.TempDir()
+	dataHome := filepath.Join(tmpDir, "data")
+	t.Setenv("XDG_DATA_HOME", dataHome)
+	root := filepath.Join(tmpDir, "card")
+	if err := os.MkdirAll(root, 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeFiles(t, root, "C0001.MP4", "C 0002.MP4")
+
+	// A file of the same name is already in the trash
+	trash := filepath.Join(dataHome, "Trash")
+	if err := os.MkdirAll(filepath.Join(trash, "files"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	writeFiles(t, filepath.Join(trash, "files"), "C0001.MP4")
+
+	originals := []string{filepath.Join(root, "C0001.MP4"), filepath.Join(root, "C 0002.MP4")}
+	targets, err := TrashTargets(originals)
+	if err != nil {
+		t.Fatalf("trash targets: %v", err)
+	}
+	if targets[0] != filepath.Join(trash, "files", "C0001.2.MP4") || targets[1] != filepath.Join(trash, "files", "C 0002.MP4") {
+		t.Fatalf("unexpected targets %v", targets)
+	}
+	if _, err := os.Stat(filepath.Join(trash, "info")); !os.IsNotExist(err) {
+		t.Error("expected picking targets to leave the trash alone")
+	}
+
+	var renames []Rename
+	for i := range originals {
+		renames = append(renames, Rename{OriginalPath: originals[i], TargetPath: targets[i], Reject: true})
+	}
+	if err := CreateTrash(renames); err != nil {
+		t.Fatalf("create trash: %v", err)
+	}
+	if info, err := os.Stat(filepath.Join(trash, "info")); err != nil || info.Mode().Perm() != 0700 {
+		t.Errorf("expected a private info folder, got %v", err)
+	}
+	if err := RenameInPlace(renames); err != nil {
+		t.Fatalf("move to trash failed: %v", err)
+	}
+	assertContent(t, targets[0], "C0001.MP4")
+	info, err := os.ReadFile(filepath.Join(trash, "info", "C 0002.MP4.trashinfo"))
+	if err != nil {
+		t.Fatalf("expected a trash info file: %v", err)
+	}
+	if !strings.HasPrefix(string(info), "[Trash Info]\n") || !strings.Contains(string(info), "Path="+filepath.ToSlash(root)+"/C%200002.MP4\n") {
+		t.Errorf("unexpected trash info:\n%s", info)
+	}
+
+	history := &History{}
+	batch, err := history.Record(BatchModeRename, root, "", renames)
+	if err != nil {
+		t.Fatalf("record failed: %v", err)
+	}
+	if batch.Entries[0].New != targets[0] {
+		t.Errorf("expected the absolute trash path in history, got %s", batch.Entries[0].New)
+	}
+
+	if err := UndoBatch(root, batch, ""); err != nil {
+		t.Fatalf("undo failed: %v", err)
+	}
+	assertContent(t, originals[1], "C 0002.MP4")
+	if _, err := os.Stat(filepath.Join(trash, "info", "C 0002.MP4.trashinfo")); !os.IsNotExist(err) {
+		t.Error("expected undo to remove the trash info file")
+	}
+}
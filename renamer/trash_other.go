@@ -0,0 +1,11 @@
+// renamer/trash_other.go
+//go:build !linux
+
+package renamer
+
+import "errors"
+
+// trashDir is unavailable on this platform
+func trashDir(_ string) (string, error) {
+	return "", errors.New("the trash is only supported on Linux")
+}
@@ -0,0 +1,19 @@
+// scanner/birthtime_darwin.go
+//go:build darwin
+
+package scanner
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+// birthTime reads the file creation time from the stat birthtime field
+func birthTime(_ string, info os.FileInfo) (time.Time, bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return time.Time{}, false
+	}
+	return time.Unix(stat.Birthtimespec.Sec, stat.Birthtimespec.Nsec), true
+}
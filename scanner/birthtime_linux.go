@@ -0,0 +1,24 @@
+// scanner/birthtime_linux.go
+//go:build linux
+
+package scanner
+
+import (
+	"os"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// birthTime reads the file creation time through statx(STATX_BTIME).
+// Returns false when the kernel or filesystem doesn't record birth times.
+func birthTime(path string, _ os.FileInfo) (time.Time, bool) {
+	var stx unix.Statx_t
+	if err := unix.Statx(unix.AT_FDCWD, path, 0, unix.STATX_BTIME, &stx); err != nil {
+		return time.Time{}, false
+	}
+	if stx.Mask&unix.STATX_BTIME == 0 {
+		return time.Time{}, false
+	}
+	return time.Unix(stx.Btime.Sec, int64(stx.Btime.Nsec)), true
+}
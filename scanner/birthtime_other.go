@@ -0,0 +1,14 @@
+// scanner/birthtime_other.go
+//go:build !linux && !darwin && !windows
+
+package scanner
+
+import (
+	"os"
+	"time"
+)
+
+// birthTime is unavailable on this platform
+func birthTime(_ string, _ os.FileInfo) (time.Time, bool) {
+	return time.Time{}, false
+}
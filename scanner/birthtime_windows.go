@@ -0,0 +1,19 @@
+// scanner/birthtime_windows.go
+//go:build windows
+
+package scanner
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+// birthTime reads the file creation time from the Win32 file attributes
+func birthTime(_ string, info os.FileInfo) (time.Time, bool) {
+	attrs, ok := info.Sys().(*syscall.Win32FileAttributeData)
+	if !ok {
+		return time.Time{}, false
+	}
+	return time.Unix(0, attrs.CreationTime.Nanoseconds()), true
+}
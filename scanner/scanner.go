@@ -2,10 +2,13 @@
 package scanner
 
 import (
+	"clip-tagger/metadata"
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -17,6 +20,8 @@ const (
 	SortByModifiedTime SortBy = "modified_time"
 	SortByCreatedTime  SortBy = "created_time"
 	SortByName         SortBy = "name"
+	SortByRecorded     SortBy = "recorded"
+	SortByTimecode     SortBy = "timecode"
 )
 
 var videoExtensions = map[string]bool{
@@ -27,12 +32,29 @@ var videoExtensions = map[string]bool{
 	".webm": true,
 }
 
+// DefaultExcludes lists directory patterns that are never descended into
+// during a recursive scan: hidden folders and copy-mode output directories.
+// The rejects folder is configured per session, so callers pass it in
+// Options.Exclude.
+var DefaultExcludes = []string{".*", "renamed_*"}
+
 // FileInfo contains metadata about a scanned file
 type FileInfo struct {
 	Path         string
-	Name         string
+	Name         string // Path relative to the scan root, using forward slashes
+	Size         int64
 	ModifiedTime time.Time
 	CreatedTime  time.Time
+	// CreatedTimeIsFallback is true when the filesystem has no birth time
+	// and CreatedTime is a copy of ModifiedTime
+	CreatedTimeIsFallback bool
+	Metadata              *metadata.Metadata // Container metadata, nil if not read or unavailable
+}
+
+// MetadataCache supplies previously parsed container metadata so unchanged
+// files don't have to be re-read on every scan
+type MetadataCache interface {
+	CachedMetadata(name string, size int64, modTime time.Time) (*metadata.Metadata, bool)
 }
 
 // ScanResult contains the results of a directory scan
@@ -41,9 +63,20 @@ type ScanResult struct {
 	Total int
 }
 
+// Options controls how a directory is scanned
+type Options struct {
+	Recursive bool     // Descend into subdirectories
+	Include   []string // Glob patterns a file must match (empty matches everything)
+	Exclude   []string // Glob patterns for files and directories to ignore
+
+	ReadMetadata bool          // Parse container metadata for each file
+	Cache        MetadataCache // Optional cache consulted before parsing
+}
+
 // Scanner scans directories for video files
 type Scanner struct {
 	directory string
+	options   Options
 }
 
 // NewScanner creates a new scanner for a directory
@@ -51,44 +84,177 @@ func NewScanner(directory string) *Scanner {
 	return &Scanner{directory: directory}
 }
 
+// NewScannerWithOptions creates a new scanner for a directory with scan options
+func NewScannerWithOptions(directory string, options Options) *Scanner {
+	return &Scanner{directory: directory, options: options}
+}
+
 // Scan scans the directory for video files and sorts them
 func (s *Scanner) Scan(sortBy SortBy) (*ScanResult, error) {
 	var files []FileInfo
 
-	entries, err := os.ReadDir(s.directory)
-	if err != nil {
-		return nil, fmt.Errorf("read directory: %w", err)
+	if s.options.Recursive {
+		walked, err := s.walk()
+		if err != nil {
+			return nil, err
+		}
+		files = walked
+	} else {
+		entries, err := os.ReadDir(s.directory)
+		if err != nil {
+			return nil, fmt.Errorf("read directory: %w", err)
+		}
+
+		for _, entry := range entries {
+			if entry.IsDir() {
+				continue
+			}
+			if !s.acceptFile(entry.Name()) {
+				continue
+			}
+
+			info, err := entry.Info()
+			if err != nil {
+				continue // Skip files we can't stat
+			}
+
+			files = append(files, newFileInfo(filepath.Join(s.directory, entry.Name()), entry.Name(), info))
+		}
 	}
 
-	for _, entry := range entries {
+	if s.options.ReadMetadata {
+		s.readMetadata(files)
+	}
+
+	sortFiles(files, sortBy)
+
+	return &ScanResult{
+		Files: files,
+		Total: len(files),
+	}, nil
+}
+
+// walk recursively collects video files below the scan root
+func (s *Scanner) walk() ([]FileInfo, error) {
+	var files []FileInfo
+
+	err := filepath.WalkDir(s.directory, func(fullPath string, entry os.DirEntry, err error) error {
+		if err != nil {
+			if fullPath == s.directory {
+				return err
+			}
+			return nil // Skip entries we can't read
+		}
+
+		rel, err := filepath.Rel(s.directory, fullPath)
+		if err != nil {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+
 		if entry.IsDir() {
-			continue
+			if fullPath != s.directory && s.excludeDir(rel) {
+				return filepath.SkipDir
+			}
+			return nil
 		}
 
-		path := filepath.Join(s.directory, entry.Name())
-		if !isVideoFile(path) {
-			continue
+		if !s.acceptFile(rel) {
+			return nil
 		}
 
 		info, err := entry.Info()
 		if err != nil {
-			continue // Skip files we can't stat
+			return nil // Skip files we can't stat
 		}
 
-		files = append(files, FileInfo{
-			Path:         path,
-			Name:         entry.Name(),
-			ModifiedTime: info.ModTime(),
-			CreatedTime:  info.ModTime(), // Use ModTime as fallback
-		})
+		files = append(files, newFileInfo(fullPath, rel, info))
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("walk directory: %w", err)
 	}
 
-	sortFiles(files, sortBy)
+	return files, nil
+}
 
-	return &ScanResult{
-		Files: files,
-		Total: len(files),
-	}, nil
+// readMetadata fills in container metadata, preferring cached values for
+// files whose size and modification time haven't changed
+func (s *Scanner) readMetadata(files []FileInfo) {
+	for i := range files {
+		f := &files[i]
+		if s.options.Cache != nil {
+			if md, ok := s.options.Cache.CachedMetadata(f.Name, f.Size, f.ModifiedTime); ok {
+				f.Metadata = md
+				continue
+			}
+		}
+
+		md, err := metadata.Read(f.Path)
+		if err != nil {
+			continue // Unsupported or unreadable containers just have no metadata
+		}
+		f.Metadata = md
+	}
+}
+
+// newFileInfo builds a FileInfo from a stat result
+func newFileInfo(fullPath, name string, info os.FileInfo) FileInfo {
+	f := FileInfo{
+		Path:         fullPath,
+		Name:         name,
+		Size:         info.Size(),
+		ModifiedTime: info.ModTime(),
+	}
+
+	if created, ok := birthTime(fullPath, info); ok {
+		f.CreatedTime = created
+	} else {
+		f.CreatedTime = info.ModTime() // Use ModTime as fallback
+		f.CreatedTimeIsFallback = true
+	}
+
+	return f
+}
+
+// excludeDir reports whether a directory (relative to the root) should be pruned
+func (s *Scanner) excludeDir(rel string) bool {
+	if matchesAny(DefaultExcludes, rel) {
+		return true
+	}
+	return matchesAny(s.options.Exclude, rel)
+}
+
+// acceptFile reports whether a file (relative to the root) passes the
+// extension check and the include/exclude rules
+func (s *Scanner) acceptFile(rel string) bool {
+	if !isVideoFile(rel) {
+		return false
+	}
+	if matchesAny(s.options.Exclude, rel) {
+		return false
+	}
+	if len(s.options.Include) > 0 && !matchesAny(s.options.Include, rel) {
+		return false
+	}
+	return true
+}
+
+// matchesAny reports whether a slash-separated relative path matches any pattern.
+// Patterns without a slash are matched against the final path element only,
+// so "THMBNL" excludes that folder at any depth.
+func matchesAny(patterns []string, rel string) bool {
+	base := path.Base(rel)
+	for _, pattern := range patterns {
+		target := base
+		if strings.Contains(pattern, "/") {
+			target = rel
+		}
+		if ok, _ := path.Match(strings.ToLower(pattern), strings.ToLower(target)); ok {
+			return true
+		}
+	}
+	return false
 }
 
 // isVideoFile checks if a file has a video extension
@@ -112,5 +278,86 @@ func sortFiles(files []FileInfo, sortBy SortBy) {
 		sort.Slice(files, func(i, j int) bool {
 			return files[i].Name < files[j].Name
 		})
+	case SortByRecorded:
+		sort.SliceStable(files, func(i, j int) bool {
+			return recordedBefore(files[i], files[j])
+		})
+	case SortByTimecode:
+		sort.SliceStable(files, func(i, j int) bool {
+			ti, iok := timecodeKey(files[i])
+			tj, jok := timecodeKey(files[j])
+			switch {
+			case iok && jok && ti != tj:
+				return ti < tj
+			case iok != jok:
+				// Files with a timecode come before files without one
+				return iok
+			default:
+				return recordedBefore(files[i], files[j])
+			}
+		})
+	}
+}
+
+// recordedTime returns the embedded recording time of a file, falling back
+// to its modified time when the container doesn't provide one
+func recordedTime(f FileInfo) time.Time {
+	if f.Metadata != nil && !f.Metadata.RecordedTime.IsZero() {
+		return f.Metadata.RecordedTime
+	}
+	return f.ModifiedTime
+}
+
+// recordedBefore orders files by recording time, breaking ties by name so
+// clips from different cameras with identical timestamps keep a stable order
+func recordedBefore(a, b FileInfo) bool {
+	ta, tb := recordedTime(a), recordedTime(b)
+	if !ta.Equal(tb) {
+		return ta.Before(tb)
+	}
+	return a.Name < b.Name
+}
+
+// timecodeKey converts a file's start timecode (HH:MM:SS:FF or HH:MM:SS;FF)
+// into a sortable number. Frames only order clips within the same second,
+// so the frame rate isn't needed.
+func timecodeKey(f FileInfo) (int64, bool) {
+	if f.Metadata == nil || f.Metadata.Timecode == "" {
+		return 0, false
+	}
+
+	fields := strings.FieldsFunc(f.Metadata.Timecode, func(r rune) bool {
+		return r == ':' || r == ';' || r == '.'
+	})
+	if len(fields) != 4 {
+		return 0, false
+	}
+
+	var values [4]int64
+	for i, field := range fields {
+		v, err := strconv.ParseInt(field, 10, 64)
+		if err != nil || v < 0 {
+			return 0, false
+		}
+		values[i] = v
+	}
+
+	seconds := values[0]*3600 + values[1]*60 + values[2]
+	return seconds*1000 + values[3], true
+}
+
+// HasSortKey reports whether a file has the metadata the sort order relies on.
+// Files without it are placed using the documented fallback order.
+func HasSortKey(f FileInfo, sortBy SortBy) bool {
+	switch sortBy {
+	case SortByCreatedTime:
+		return !f.CreatedTimeIsFallback
+	case SortByRecorded:
+		return f.Metadata != nil && !f.Metadata.RecordedTime.IsZero()
+	case SortByTimecode:
+		_, ok := timecodeKey(f)
+		return ok
+	default:
+		return true
 	}
 }
@@ -2,9 +2,11 @@
 package scanner
 
 import (
+	"clip-tagger/metadata"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 )
 
 func TestScanner_ScanDirectory(t *testing.T) {
@@ -62,3 +64,277 @@ func TestIsVideoFile(t *testing.T) {
 		}
 	}
 }
+
+func TestScanner_ScanRecursive(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	files := []string{
+		"root.mp4",
+		"DCIM/100CANON/MVI_0001.MOV",
+		"PRIVATE/M4ROOT/CLIP/C0001.MP4",
+		"PRIVATE/M4ROOT/THMBNL/C0001.mp4",
+		".hidden/secret.mp4",
+		"renamed_2026-01-01_10-00-00/[01_01] intro.mp4",
+		"_rejected/C0002.MP4",
+		"DCIM/100CANON/notes.txt",
+	}
+	for _, f := range files {
+		path := filepath.Join(tmpDir, filepath.FromSlash(f))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("test"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	scanner := NewScannerWithOptions(tmpDir, Options{
+		Recursive: true,
+		Exclude:   []string{"THMBNL", "_rejected"},
+	})
+	result, err := scanner.Scan(SortByName)
+	if err != nil {
+		t.Fatalf("scan failed: %v", err)
+	}
+
+	expected := []string{
+		"DCIM/100CANON/MVI_0001.MOV",
+		"PRIVATE/M4ROOT/CLIP/C0001.MP4",
+		"root.mp4",
+	}
+	if len(result.Files) != len(expected) {
+		t.Fatalf("expected %d files, got %d: %v", len(expected), len(result.Files), result.Files)
+	}
+	for i, f := range result.Files {
+		if f.Name != expected[i] {
+			t.Errorf("index %d: expected %s, got %s", i, expected[i], f.Name)
+		}
+		if f.Path != filepath.Join(tmpDir, filepath.FromSlash(expected[i])) {
+			t.Errorf("index %d: unexpected path %s", i, f.Path)
+		}
+	}
+}
+
+func TestScanner_NonRecursiveIgnoresSubdirectories(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	if err := os.MkdirAll(filepath.Join(tmpDir, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range []string{"top.mp4", "sub/nested.mp4"} {
+		if err := os.WriteFile(filepath.Join(tmpDir, f), []byte("test"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	result, err := NewScanner(tmpDir).Scan(SortByName)
+	if err != nil {
+		t.Fatalf("scan failed: %v", err)
+	}
+
+	if len(result.Files) != 1 || result.Files[0].Name != "top.mp4" {
+		t.Errorf("expected only top.mp4, got %v", result.Files)
+	}
+}
+
+func TestMatchesAny(t *testing.T) {
+	tests := []struct {
+		patterns []string
+		rel      string
+		expected bool
+	}{
+		{[]string{"THMBNL"}, "PRIVATE/M4ROOT/THMBNL", true},
+		{[]string{"thmbnl"}, "PRIVATE/M4ROOT/THMBNL", true},
+		{[]string{"*.mp4"}, "DCIM/clip.MP4", true},
+		{[]string{"PRIVATE/*/CLIP/*"}, "PRIVATE/M4ROOT/CLIP/C0001.MP4", true},
+		{[]string{"CLIP/*"}, "PRIVATE/M4ROOT/CLIP/C0001.MP4", false},
+		{[]string{"PROXY"}, "DCIM/100CANON", false},
+		{nil, "anything.mp4", false},
+	}
+
+	for _, tt := range tests {
+		result := matchesAny(tt.patterns, tt.rel)
+		if result != tt.expected {
+			t.Errorf("matchesAny(%v, %s) = %v, want %v", tt.patterns, tt.rel, result, tt.expected)
+		}
+	}
+}
+
+func TestScanner_IncludePatterns(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	for _, f := range []string{"A001.mp4", "B001.mp4", "A002.mov"} {
+		if err := os.WriteFile(filepath.Join(tmpDir, f), []byte("test"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	result, err := NewScannerWithOptions(tmpDir, Options{Include: []string{"A*"}}).Scan(SortByName)
+	if err != nil {
+		t.Fatalf("scan failed: %v", err)
+	}
+
+	if len(result.Files) != 2 {
+		t.Fatalf("expected 2 files, got %d", len(result.Files))
+	}
+	if result.Files[0].Name != "A001.mp4" || result.Files[1].Name != "A002.mov" {
+		t.Errorf("unexpected files: %v", result.Files)
+	}
+}
+
+// fakeCache is a MetadataCache that serves a fixed entry and records lookups
+type fakeCache struct {
+	entries map[string]*metadata.Metadata
+	lookups []string
+}
+
+func (c *fakeCache) CachedMetadata(name string, size int64, modTime time.Time) (*metadata.Metadata, bool) {
+	c.lookups = append(c.lookups, name)
+	md, ok := c.entries[name]
+	return md, ok
+}
+
+func TestScanner_ReadMetadata(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	for _, f := range []string{"cached.mp4", "garbage.mov", "old.avi"} {
+		if err := os.WriteFile(filepath.Join(tmpDir, f), []byte("not a real video"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	recorded := time.Date(2026, 1, 12, 9, 0, 0, 0, time.UTC)
+	cache := &fakeCache{entries: map[string]*metadata.Metadata{
+		"cached.mp4": {RecordedTime: recorded},
+	}}
+
+	result, err := NewScannerWithOptions(tmpDir, Options{ReadMetadata: true, Cache: cache}).Scan(SortByName)
+	if err != nil {
+		t.Fatalf("scan failed: %v", err)
+	}
+
+	if len(cache.lookups) != 3 {
+		t.Errorf("expected 3 cache lookups, got %d", len(cache.lookups))
+	}
+
+	for _, f := range result.Files {
+		switch f.Name {
+		case "cached.mp4":
+			if f.Metadata == nil || !f.Metadata.RecordedTime.Equal(recorded) {
+				t.Errorf("expected cached metadata for %s, got %v", f.Name, f.Metadata)
+			}
+		default:
+			if f.Metadata != nil {
+				t.Errorf("expected no metadata for %s, got %v", f.Name, f.Metadata)
+			}
+		}
+		if f.Size != int64(len("not a real video")) {
+			t.Errorf("expected size to be recorded for %s, got %d", f.Name, f.Size)
+		}
+	}
+}
+
+func TestSortFiles_Recorded(t *testing.T) {
+	base := time.Date(2026, 1, 12, 10, 0, 0, 0, time.UTC)
+	copied := time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC) // mtime reset by copying
+
+	files := []FileInfo{
+		{Name: "A/C0002.MP4", ModifiedTime: copied, Metadata: &metadata.Metadata{RecordedTime: base.Add(3 * time.Minute)}},
+		{Name: "B/MVI_0001.MOV", ModifiedTime: copied, Metadata: &metadata.Metadata{RecordedTime: base.Add(2 * time.Minute)}},
+		{Name: "A/C0001.MP4", ModifiedTime: copied, Metadata: &metadata.Metadata{RecordedTime: base}},
+		{Name: "screen.mkv", ModifiedTime: base.Add(time.Minute)},                                        // no metadata
+		{Name: "B/MVI_0000.MOV", ModifiedTime: copied, Metadata: &metadata.Metadata{RecordedTime: base}}, // tie with A/C0001
+	}
+
+	sortFiles(files, SortByRecorded)
+
+	expected := []string{"A/C0001.MP4", "B/MVI_0000.MOV", "screen.mkv", "B/MVI_0001.MOV", "A/C0002.MP4"}
+	for i, f := range files {
+		if f.Name != expected[i] {
+			t.Errorf("index %d: expected %s, got %s", i, expected[i], f.Name)
+		}
+	}
+}
+
+func TestSortFiles_Timecode(t *testing.T) {
+	base := time.Date(2026, 1, 12, 10, 0, 0, 0, time.UTC)
+
+	files := []FileInfo{
+		{Name: "no-tc-late.mp4", ModifiedTime: base.Add(time.Hour)},
+		{Name: "camB.mov", Metadata: &metadata.Metadata{Timecode: "10:15:00;02"}},
+		{Name: "no-tc-early.mp4", ModifiedTime: base},
+		{Name: "camA.mov", Metadata: &metadata.Metadata{Timecode: "10:15:00:01"}},
+		{Name: "camA2.mov", Metadata: &metadata.Metadata{Timecode: "09:59:59:24"}},
+	}
+
+	sortFiles(files, SortByTimecode)
+
+	expected := []string{"camA2.mov", "camA.mov", "camB.mov", "no-tc-early.mp4", "no-tc-late.mp4"}
+	for i, f := range files {
+		if f.Name != expected[i] {
+			t.Errorf("index %d: expected %s, got %s", i, expected[i], f.Name)
+		}
+	}
+}
+
+func TestHasSortKey(t *testing.T) {
+	withTime := FileInfo{Metadata: &metadata.Metadata{RecordedTime: time.Now()}}
+	withTimecode := FileInfo{Metadata: &metadata.Metadata{Timecode: "01:00:00:00"}}
+	bare := FileInfo{}
+
+	tests := []struct {
+		name     string
+		file     FileInfo
+		sortBy   SortBy
+		expected bool
+	}{
+		{"recorded with time", withTime, SortByRecorded, true},
+		{"recorded without time", bare, SortByRecorded, false},
+		{"timecode with timecode", withTimecode, SortByTimecode, true},
+		{"timecode without timecode", withTime, SortByTimecode, false},
+		{"name never falls back", bare, SortByName, true},
+	}
+
+	for _, tt := range tests {
+		if result := HasSortKey(tt.file, tt.sortBy); result != tt.expected {
+			t.Errorf("%s: HasSortKey = %v, want %v", tt.name, result, tt.expected)
+		}
+	}
+}
+
+func TestScanner_CreatedTime(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "clip.mp4")
+	if err := os.WriteFile(path, []byte("test"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Push the modified time into the past so a real birth time differs from it
+	past := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	if err := os.Chtimes(path, past, past); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := NewScanner(tmpDir).Scan(SortByCreatedTime)
+	if err != nil {
+		t.Fatalf("scan failed: %v", err)
+	}
+
+	f := result.Files[0]
+	if f.CreatedTimeIsFallback {
+		// Filesystem without birth times: value must be the modified time
+		if !f.CreatedTime.Equal(f.ModifiedTime) {
+			t.Errorf("expected fallback created time to equal modified time, got %v", f.CreatedTime)
+		}
+		if HasSortKey(f, SortByCreatedTime) {
+			t.Error("expected fallback file to have no created sort key")
+		}
+	} else {
+		if f.CreatedTime.Equal(past) {
+			t.Error("expected birth time to be independent of modified time")
+		}
+		if !HasSortKey(f, SortByCreatedTime) {
+			t.Error("expected file with birth time to have a created sort key")
+		}
+	}
+}
@@ -0,0 +1,39 @@
+// state/annotation.go
+package state
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MaxRating is the highest rating a take can be given
+const MaxRating = 5
+
+// Annotation is what was noted about a take on set
+type Annotation struct {
+	Rating  int    `json:"rating,omitempty"`  // 0 (unrated) to MaxRating
+	Circled bool   `json:"circled,omitempty"` // A circled take, i.e. one to use
+	Notes   string `json:"notes,omitempty"`   // Free text, e.g. "focus buzz at end"
+}
+
+// IsZero reports whether nothing has been noted
+func (a Annotation) IsZero() bool {
+	return a == Annotation{}
+}
+
+// Annotate replaces the rating, circle and notes of a classified file.
+// Notes are trimmed; a rating outside 0 to MaxRating is an error.
+func (s *State) Annotate(filename string, annotation Annotation) error {
+	if annotation.Rating < 0 || annotation.Rating > MaxRating {
+		return fmt.Errorf("rating must be between 0 and %d, got %d", MaxRating, annotation.Rating)
+	}
+	annotation.Notes = strings.TrimSpace(annotation.Notes)
+
+	for i := range s.Classifications {
+		if s.Classifications[i].File == filename {
+			s.Classifications[i].Annotation = annotation
+			return nil
+		}
+	}
+	return fmt.Errorf("file is not classified: %s", filename)
+}
@@ -0,0 +1,80 @@
+// state/annotation_test.go
+package state
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestState_Annotate(t *testing.T) {
+	st, intro, middle, _ := groupFixture()
+
+	if err := st.Annotate("b.mp4", Annotation{Rating: 4, Circled: true, Notes: "  focus buzz at end "}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	c, _ := st.GetClassification("b.mp4")
+	if c.Rating != 4 || !c.Circled || c.Notes != "focus buzz at end" {
+		t.Errorf("unexpected annotation: %+v", c.Annotation)
+	}
+
+	if err := st.Annotate("b.mp4", Annotation{Rating: MaxRating + 1}); err == nil {
+		t.Error("expected error for a rating above the maximum")
+	}
+	if err := st.Annotate("b.mp4", Annotation{Rating: -1}); err == nil {
+		t.Error("expected error for a negative rating")
+	}
+	if err := st.Annotate("missing.mp4", Annotation{Rating: 1}); err == nil {
+		t.Error("expected error for an unclassified file")
+	}
+
+	// The annotation belongs to the clip, so it follows it to another group
+	st.AssignClassification("b.mp4", middle.ID, []string{"a.mp4", "b.mp4", "c.mp4", "d.mp4"})
+	st.AddOrUpdateClassification("b.mp4", intro.ID)
+	if c, _ := st.GetClassification("b.mp4"); c.Rating != 4 || !c.Circled {
+		t.Errorf("expected annotation to survive reclassifying, got %+v", c.Annotation)
+	}
+}
+
+func TestAnnotation_JSON(t *testing.T) {
+	c := Classification{File: "a.mp4", GroupID: "g", TakeNumber: 1}
+	data, _ := json.Marshal(c)
+	if strings.Contains(string(data), "rating") || strings.Contains(string(data), "notes") {
+		t.Errorf("expected an empty annotation to be omitted, got %s", data)
+	}
+
+	c.Annotation = Annotation{Rating: 3, Circled: true, Notes: "good"}
+	data, _ = json.Marshal(c)
+	var decoded Classification
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(decoded, c) {
+		t.Errorf("expected %+v, got %+v", c, decoded)
+	}
+	if !strings.Contains(string(data), `"circled":true`) {
+		t.Errorf("expected annotation fields next to the classification, got %s", data)
+	}
+}
+
+func TestState_TargetPath_Annotation(t *testing.T) {
+	st := NewState("/card", SortByName)
+	st.NameTemplate = "[{seq}_{take}] {group}{circled:_OK} {notes}"
+	group := NewGroup("intro", 1)
+	st.Groups = []Group{group}
+	st.Classifications = []Classification{
+		{File: "C0001.MP4", GroupID: group.ID, TakeNumber: 1,
+			Annotation: Annotation{Circled: true, Notes: "wide/tight"}},
+	}
+
+	target, ok := st.TargetPath(st.Classifications[0], st.NameNumbers())
+	if !ok {
+		t.Fatal("expected target path")
+	}
+	expected := filepath.Join("/card", "[01_01] intro_OK wide-tight.MP4")
+	if target != expected {
+		t.Errorf("expected %s, got %s", expected, target)
+	}
+}
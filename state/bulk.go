@@ -0,0 +1,54 @@
+// state/bulk.go
+package state
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// AssignClassifications classifies several files into one group at once.
+// Takes are numbered in the session's file order (order), whatever order
+// the files are given in; each file takes its place among the group's
+// existing takes as with AssignClassification.
+func (s *State) AssignClassifications(files []string, groupID string, order []string) error {
+	if s.FindGroupByID(groupID) == nil {
+		return fmt.Errorf("group not found: %s", groupID)
+	}
+
+	position := make(map[string]int, len(order))
+	for i, f := range order {
+		position[f] = i
+	}
+	sorted := slices.Clone(files)
+	slices.SortStableFunc(sorted, func(a, b string) int {
+		pa, okA := position[a]
+		pb, okB := position[b]
+		switch {
+		case okA && okB:
+			return pa - pb
+		case okA:
+			return -1
+		case okB:
+			return 1
+		}
+		return 0
+	})
+
+	for _, f := range slices.Compact(sorted) {
+		s.AssignClassification(f, groupID, order)
+	}
+	return nil
+}
+
+// RecordingGap returns the time between the end of one clip and the start
+// of the next, using the clip's duration when it is known and its start
+// time otherwise. Clips without a recording time fall back to their
+// modified time.
+func (s *State) RecordingGap(before, after string) time.Duration {
+	end := s.recordedTime(before)
+	if md, ok := s.GetMetadata(before); ok && !md.RecordedTime.IsZero() {
+		end = end.Add(md.Duration)
+	}
+	return s.recordedTime(after).Sub(end)
+}
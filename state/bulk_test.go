@@ -0,0 +1,57 @@
+// state/bulk_test.go
+package state
+
+import (
+	"clip-tagger/metadata"
+	"testing"
+	"time"
+)
+
+func TestState_AssignClassifications(t *testing.T) {
+	st, intro, middle, _ := groupFixture()
+	order := []string{"a.mp4", "b.mp4", "c.mp4", "d.mp4", "e.mp4", "f.mp4", "x.mp4", "y.mp4", "z.mp4"}
+
+	// Given out of order, new files still get takes in file order, and d.mp4
+	// lands among them
+	if err := st.AssignClassifications([]string{"z.mp4", "x.mp4", "y.mp4"}, middle.ID, order); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := st.AssignClassifications([]string{"b.mp4"}, middle.ID, order); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := map[string]int{"b.mp4": 1, "d.mp4": 2, "x.mp4": 3, "y.mp4": 4, "z.mp4": 5}
+	for file, take := range expected {
+		c, ok := st.GetClassification(file)
+		if !ok || c.GroupID != middle.ID || c.TakeNumber != take {
+			t.Errorf("expected %s as take %d of middle, got %+v", file, take, c)
+		}
+	}
+	if c, _ := st.GetClassification("c.mp4"); c.GroupID != intro.ID || c.TakeNumber != 2 {
+		t.Errorf("expected the gap in intro to close, got %+v", c)
+	}
+
+	if err := st.AssignClassifications([]string{"a.mp4"}, "missing", order); err == nil {
+		t.Error("expected error for a missing group")
+	}
+}
+
+func TestState_RecordingGap(t *testing.T) {
+	st := NewState(t.TempDir(), SortByRecorded)
+	start := time.Date(2026, 1, 12, 10, 0, 0, 0, time.UTC)
+	st.CacheMetadata("a.mp4", 1, start, metadata.Metadata{RecordedTime: start, Duration: 50 * time.Second})
+	st.CacheMetadata("b.mp4", 1, start, metadata.Metadata{RecordedTime: start.Add(time.Minute)})
+	st.CacheMetadata("c.mp4", 1, start.Add(10*time.Minute), metadata.Metadata{})
+
+	// The gap starts where a.mp4 ends
+	if gap := st.RecordingGap("a.mp4", "b.mp4"); gap != 10*time.Second {
+		t.Errorf("expected 10s, got %v", gap)
+	}
+	// Without a recording time the modified time is used, with no duration
+	if gap := st.RecordingGap("b.mp4", "c.mp4"); gap != 9*time.Minute {
+		t.Errorf("expected 9m, got %v", gap)
+	}
+	if gap := st.RecordingGap("c.mp4", "a.mp4"); gap >= 0 {
+		t.Errorf("expected a negative gap for clips out of order, got %v", gap)
+	}
+}
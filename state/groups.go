@@ -0,0 +1,382 @@
+// state/groups.go
+package state
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// RenameGroup changes a group's name. Classifications refer to groups by
+// ID, so nothing else needs updating.
+func (s *State) RenameGroup(id, name string) error {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return fmt.Errorf("group name cannot be empty")
+	}
+	group := s.FindGroupByID(id)
+	if group == nil {
+		return fmt.Errorf("group not found: %s", id)
+	}
+	group.Name = name
+	return nil
+}
+
+// MergeGroups moves every clip of the source group into the target group
+// and removes the source. The source's takes follow the target's, in their
+// existing order, and its sub-groups follow the target's sub-groups.
+func (s *State) MergeGroups(sourceID, targetID string) error {
+	if sourceID == targetID {
+		return fmt.Errorf("cannot merge a group into itself")
+	}
+	if s.FindGroupByID(sourceID) == nil {
+		return fmt.Errorf("group not found: %s", sourceID)
+	}
+	if s.FindGroupByID(targetID) == nil {
+		return fmt.Errorf("group not found: %s", targetID)
+	}
+	if s.isDescendant(targetID, sourceID) {
+		return fmt.Errorf("cannot merge a group into one of its sub-groups")
+	}
+
+	offset := len(s.Children(targetID))
+	for i := range s.Groups {
+		if s.Groups[i].ParentID == sourceID {
+			s.Groups[i].ParentID = targetID
+			s.Groups[i].Order += offset
+		}
+	}
+
+	takeOffset := s.NextTakeNumber(targetID) - 1
+	for i := range s.Classifications {
+		c := &s.Classifications[i]
+		if c.GroupID == sourceID {
+			c.GroupID = targetID
+			c.TakeNumber += takeOffset
+		}
+	}
+	s.renumberTakes(targetID)
+
+	s.removeGroup(sourceID)
+	return nil
+}
+
+// SplitGroup moves a group's takes from atTake onwards into a new group
+// placed right after it with the same parent, numbered from take 1. Its
+// sub-groups stay where they are. Returns the new group.
+func (s *State) SplitGroup(id string, atTake int, name string) (Group, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return Group{}, fmt.Errorf("group name cannot be empty")
+	}
+	group := s.FindGroupByID(id)
+	if group == nil {
+		return Group{}, fmt.Errorf("group not found: %s", id)
+	}
+	lastTake := s.NextTakeNumber(id) - 1
+	if atTake < 2 || atTake > lastTake {
+		return Group{}, fmt.Errorf("take %d is not inside the group (takes 2-%d can start a new group)", atTake, lastTake)
+	}
+
+	split := NewSubGroup(name, group.ParentID, group.Order+1)
+	for i := range s.Groups {
+		if s.Groups[i].ParentID == split.ParentID && s.Groups[i].Order > group.Order {
+			s.Groups[i].Order++
+		}
+	}
+	s.Groups = append(s.Groups, split)
+	s.renumberGroups()
+
+	for i := range s.Classifications {
+		c := &s.Classifications[i]
+		if c.GroupID == id && c.TakeNumber >= atTake {
+			c.GroupID = split.ID
+			c.TakeNumber -= atTake - 1
+		}
+	}
+	return split, nil
+}
+
+// DeleteGroup removes a group with its sub-groups and unclassifies their
+// clips. Returns the files that are now unclassified.
+func (s *State) DeleteGroup(id string) ([]string, error) {
+	if s.FindGroupByID(id) == nil {
+		return nil, fmt.Errorf("group not found: %s", id)
+	}
+
+	removed := map[string]bool{id: true}
+	for _, g := range s.Groups {
+		if s.isDescendant(g.ID, id) {
+			removed[g.ID] = true
+		}
+	}
+
+	var unclassified []string
+	s.Classifications = slices.DeleteFunc(s.Classifications, func(c Classification) bool {
+		if removed[c.GroupID] {
+			unclassified = append(unclassified, c.File)
+			return true
+		}
+		return false
+	})
+
+	s.Groups = slices.DeleteFunc(s.Groups, func(g Group) bool { return removed[g.ID] })
+	s.renumberGroups()
+	return unclassified, nil
+}
+
+// MoveGroup moves a group up (negative delta) or down the order among the
+// groups with the same parent. Returns false if the group is already at
+// that end.
+func (s *State) MoveGroup(id string, delta int) bool {
+	s.renumberGroups()
+	group := s.FindGroupByID(id)
+	if group == nil || delta == 0 {
+		return false
+	}
+	siblings := s.Children(group.ParentID)
+	index := slices.IndexFunc(siblings, func(g Group) bool { return g.ID == id })
+	target := index + delta
+	if target < 0 || target >= len(siblings) {
+		return false
+	}
+
+	moved := siblings[index]
+	siblings = slices.Delete(siblings, index, index+1)
+	siblings = slices.Insert(siblings, target, moved)
+	for i, sibling := range siblings {
+		s.FindGroupByID(sibling.ID).Order = i + 1
+	}
+	s.renumberGroups()
+	return true
+}
+
+// InsertGroup adds a group at its order among the groups with the same
+// parent, moving later ones down
+func (s *State) InsertGroup(group Group) error {
+	if group.ParentID != "" && s.FindGroupByID(group.ParentID) == nil {
+		return fmt.Errorf("parent group not found: %s", group.ParentID)
+	}
+	for i := range s.Groups {
+		if s.Groups[i].ParentID == group.ParentID && s.Groups[i].Order >= group.Order {
+			s.Groups[i].Order++
+		}
+	}
+	s.Groups = append(s.Groups, group)
+	s.renumberGroups()
+	return nil
+}
+
+// Children returns the groups directly inside a parent, or the top-level
+// groups for "", in order
+func (s *State) Children(parentID string) []Group {
+	var children []Group
+	for _, g := range s.Groups {
+		if g.ParentID == parentID {
+			children = append(children, g)
+		}
+	}
+	sort.SliceStable(children, func(i, j int) bool { return children[i].Order < children[j].Order })
+	return children
+}
+
+// OrderedGroups returns every group in naming order: each group is followed
+// by its sub-groups
+func (s *State) OrderedGroups() []Group {
+	ordered := make([]Group, 0, len(s.Groups))
+	var walk func(parentID string)
+	walk = func(parentID string) {
+		for _, g := range s.Children(parentID) {
+			ordered = append(ordered, g)
+			walk(g.ID)
+		}
+	}
+	walk("")
+
+	// Keep groups the walk can't reach, e.g. from a hand-edited file
+	if len(ordered) < len(s.Groups) {
+		for _, g := range s.Groups {
+			if !slices.ContainsFunc(ordered, func(o Group) bool { return o.ID == g.ID }) {
+				ordered = append(ordered, g)
+			}
+		}
+	}
+	return ordered
+}
+
+// GroupPath returns the orders from a group's top-level ancestor down to
+// the group itself, e.g. [3 2] for the second shot of the third scene
+func (s *State) GroupPath(id string) []int {
+	var path []int
+	for group := s.FindGroupByID(id); group != nil && len(path) <= len(s.Groups); group = s.FindGroupByID(group.ParentID) {
+		path = append([]int{group.Order}, path...)
+		if group.ParentID == "" {
+			break
+		}
+	}
+	return path
+}
+
+// GroupNumber formats a group's path for display, e.g. "3" or "3.2"
+func (s *State) GroupNumber(id string) string {
+	parts := make([]string, 0, 2)
+	for _, order := range s.GroupPath(id) {
+		parts = append(parts, strconv.Itoa(order))
+	}
+	return strings.Join(parts, ".")
+}
+
+// GroupFullName joins a group's name with its parents', e.g.
+// "scene 3 / wide"
+func (s *State) GroupFullName(id string) string {
+	var names []string
+	for group := s.FindGroupByID(id); group != nil && len(names) <= len(s.Groups); group = s.FindGroupByID(group.ParentID) {
+		names = append([]string{group.Name}, names...)
+		if group.ParentID == "" {
+			break
+		}
+	}
+	return strings.Join(names, " / ")
+}
+
+// MoveTake swaps a clip's take number with the take before (negative delta)
+// or after it in the same group. Returns false if there is no such take.
+func (s *State) MoveTake(filename string, delta int) bool {
+	c, ok := s.GetClassification(filename)
+	if !ok || delta == 0 {
+		return false
+	}
+	target := c.TakeNumber + delta
+	if target < 1 || target >= s.NextTakeNumber(c.GroupID) {
+		return false
+	}
+
+	for i := range s.Classifications {
+		other := &s.Classifications[i]
+		if other.GroupID != c.GroupID {
+			continue
+		}
+		switch {
+		case other.File == filename:
+			other.TakeNumber = target
+		case delta < 0 && other.TakeNumber >= target && other.TakeNumber < c.TakeNumber:
+			other.TakeNumber++
+		case delta > 0 && other.TakeNumber > c.TakeNumber && other.TakeNumber <= target:
+			other.TakeNumber--
+		}
+	}
+	return true
+}
+
+// RenumberTakes numbers a group's takes from 1 by recording time or by
+// camera filename. Recording time falls back to the modified time; ties
+// go by filename.
+func (s *State) RenumberTakes(groupID string, by SortBy) error {
+	if s.FindGroupByID(groupID) == nil {
+		return fmt.Errorf("group not found: %s", groupID)
+	}
+	if by != SortByRecorded && by != SortByName {
+		return fmt.Errorf("takes can be renumbered by %s or %s, not %s", SortByRecorded, SortByName, by)
+	}
+
+	var indexes []int
+	for i, c := range s.Classifications {
+		if c.GroupID == groupID {
+			indexes = append(indexes, i)
+		}
+	}
+
+	name := func(i int) string {
+		c := s.Classifications[i]
+		if c.Original != "" {
+			return path.Base(c.Original)
+		}
+		return path.Base(c.File)
+	}
+	sort.SliceStable(indexes, func(a, b int) bool {
+		if by == SortByRecorded {
+			ta := s.recordedTime(s.Classifications[indexes[a]].File)
+			tb := s.recordedTime(s.Classifications[indexes[b]].File)
+			if !ta.Equal(tb) {
+				return ta.Before(tb)
+			}
+		}
+		return name(indexes[a]) < name(indexes[b])
+	})
+
+	for take, i := range indexes {
+		s.Classifications[i].TakeNumber = take + 1
+	}
+	return nil
+}
+
+// GroupTakeCount returns how many clips are classified in a group
+func (s *State) GroupTakeCount(id string) int {
+	count := 0
+	for _, c := range s.Classifications {
+		if c.GroupID == id {
+			count++
+		}
+	}
+	return count
+}
+
+// removeGroup drops a group and closes the gap in the order
+func (s *State) removeGroup(id string) {
+	s.Groups = slices.DeleteFunc(s.Groups, func(g Group) bool { return g.ID == id })
+	s.renumberGroups()
+}
+
+// renumberGroups numbers the groups inside each parent from 1 by order and
+// keeps the list in naming order. Groups whose parent is gone, or that are
+// their own ancestor, move to the top level.
+func (s *State) renumberGroups() {
+	for i := range s.Groups {
+		g := &s.Groups[i]
+		if g.ParentID != "" && (s.FindGroupByID(g.ParentID) == nil || s.isDescendant(g.ParentID, g.ID)) {
+			g.ParentID = ""
+		}
+	}
+
+	sort.SliceStable(s.Groups, func(i, j int) bool {
+		return s.Groups[i].Order < s.Groups[j].Order
+	})
+	next := make(map[string]int)
+	for i := range s.Groups {
+		parent := s.Groups[i].ParentID
+		next[parent]++
+		s.Groups[i].Order = next[parent]
+	}
+	s.Groups = s.OrderedGroups()
+}
+
+// isDescendant reports whether a group is inside another, at any depth
+func (s *State) isDescendant(id, ancestorID string) bool {
+	group := s.FindGroupByID(id)
+	for steps := 0; group != nil && group.ParentID != "" && steps <= len(s.Groups); steps++ {
+		if group.ParentID == ancestorID {
+			return true
+		}
+		group = s.FindGroupByID(group.ParentID)
+	}
+	return false
+}
+
+// renumberTakes numbers a group's takes from 1 in their current order
+func (s *State) renumberTakes(groupID string) {
+	var indexes []int
+	for i, c := range s.Classifications {
+		if c.GroupID == groupID {
+			indexes = append(indexes, i)
+		}
+	}
+	sort.SliceStable(indexes, func(a, b int) bool {
+		return s.Classifications[indexes[a]].TakeNumber < s.Classifications[indexes[b]].TakeNumber
+	})
+	for take, i := range indexes {
+		s.Classifications[i].TakeNumber = take + 1
+	}
+}
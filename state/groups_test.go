@@ -0,0 +1,369 @@
+// state/groups_test.go
+package state
+
+import (
+	"clip-tagger/metadata"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// groupFixture returns a state with intro (a, b, c), middle (d) and outro (e, f)
+func groupFixture() (*State, Group, Group, Group) {
+	st := NewState("/tmp/test", SortByName)
+	intro := NewGroup("intro", 1)
+	middle := NewGroup("middle", 2)
+	outro := NewGroup("outro", 3)
+	st.Groups = []Group{intro, middle, outro}
+	for _, f := range []string{"a.mp4", "b.mp4", "c.mp4"} {
+		st.AddOrUpdateClassification(f, intro.ID)
+	}
+	st.AddOrUpdateClassification("d.mp4", middle.ID)
+	st.AddOrUpdateClassification("e.mp4", outro.ID)
+	st.AddOrUpdateClassification("f.mp4", outro.ID)
+	return st, intro, middle, outro
+}
+
+// groupNames lists group names in order
+func groupNames(st *State) []string {
+	var names []string
+	for i, g := range st.Groups {
+		if g.Order != i+1 {
+			return []string{"order out of sequence"}
+		}
+		names = append(names, g.Name)
+	}
+	return names
+}
+
+func TestState_RenameGroup(t *testing.T) {
+	st, intro, _, _ := groupFixture()
+
+	if err := st.RenameGroup(intro.ID, "  opening  "); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if st.FindGroupByID(intro.ID).Name != "opening" {
+		t.Errorf("expected trimmed name, got %q", st.FindGroupByID(intro.ID).Name)
+	}
+	if takes(st)["a.mp4"] != "opening/1" {
+		t.Errorf("expected clips to follow the rename, got %v", takes(st))
+	}
+
+	if err := st.RenameGroup(intro.ID, " "); err == nil {
+		t.Error("expected error for empty name")
+	}
+	if err := st.RenameGroup("missing", "x"); err == nil {
+		t.Error("expected error for unknown group")
+	}
+}
+
+func TestState_MergeGroups(t *testing.T) {
+	st, intro, _, outro := groupFixture()
+
+	if err := st.MergeGroups(intro.ID, outro.ID); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := map[string]string{
+		"d.mp4": "middle/1",
+		"e.mp4": "outro/1", "f.mp4": "outro/2",
+		"a.mp4": "outro/3", "b.mp4": "outro/4", "c.mp4": "outro/5",
+	}
+	if got := takes(st); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+	if got := groupNames(st); !reflect.DeepEqual(got, []string{"middle", "outro"}) {
+		t.Errorf("unexpected groups: %v", got)
+	}
+
+	if err := st.MergeGroups(outro.ID, outro.ID); err == nil {
+		t.Error("expected error merging a group into itself")
+	}
+	if err := st.MergeGroups(intro.ID, outro.ID); err == nil {
+		t.Error("expected error for a removed group")
+	}
+}
+
+func TestState_SplitGroup(t *testing.T) {
+	st, intro, _, _ := groupFixture()
+
+	split, err := st.SplitGroup(intro.ID, 2, "intro b")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := map[string]string{
+		"a.mp4": "intro/1", "b.mp4": "intro b/1", "c.mp4": "intro b/2",
+		"d.mp4": "middle/1", "e.mp4": "outro/1", "f.mp4": "outro/2",
+	}
+	if got := takes(st); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+	if got := groupNames(st); !reflect.DeepEqual(got, []string{"intro", "intro b", "middle", "outro"}) {
+		t.Errorf("unexpected groups: %v", got)
+	}
+	if split.Order != 2 {
+		t.Errorf("expected new group at order 2, got %d", split.Order)
+	}
+
+	for _, take := range []int{1, 4} {
+		if _, err := st.SplitGroup(intro.ID, take, "x"); err == nil {
+			t.Errorf("expected error splitting at take %d", take)
+		}
+	}
+	if _, err := st.SplitGroup(intro.ID, 2, ""); err == nil {
+		t.Error("expected error for empty name")
+	}
+}
+
+func TestState_DeleteGroup(t *testing.T) {
+	st, intro, _, _ := groupFixture()
+
+	files, err := st.DeleteGroup(intro.ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(files, []string{"a.mp4", "b.mp4", "c.mp4"}) {
+		t.Errorf("unexpected unclassified files: %v", files)
+	}
+	if _, ok := st.GetClassification("a.mp4"); ok {
+		t.Error("expected a.mp4 to be unclassified")
+	}
+	if got := groupNames(st); !reflect.DeepEqual(got, []string{"middle", "outro"}) {
+		t.Errorf("unexpected groups: %v", got)
+	}
+
+	if _, err := st.DeleteGroup(intro.ID); err == nil {
+		t.Error("expected error for a removed group")
+	}
+}
+
+func TestState_MoveGroup(t *testing.T) {
+	st, intro, _, outro := groupFixture()
+
+	if !st.MoveGroup(intro.ID, 1) {
+		t.Fatal("expected intro to move down")
+	}
+	if got := groupNames(st); !reflect.DeepEqual(got, []string{"middle", "intro", "outro"}) {
+		t.Errorf("unexpected groups: %v", got)
+	}
+
+	if !st.MoveGroup(outro.ID, -2) {
+		t.Fatal("expected outro to move to the top")
+	}
+	if got := groupNames(st); !reflect.DeepEqual(got, []string{"outro", "middle", "intro"}) {
+		t.Errorf("unexpected groups: %v", got)
+	}
+
+	if st.MoveGroup(outro.ID, -1) {
+		t.Error("expected the first group not to move up")
+	}
+	if st.MoveGroup("missing", 1) {
+		t.Error("expected unknown group not to move")
+	}
+}
+
+func TestState_MoveTake(t *testing.T) {
+	st, _, _, _ := groupFixture()
+
+	if !st.MoveTake("c.mp4", -2) {
+		t.Fatal("expected c.mp4 to move to take 1")
+	}
+	for file, want := range map[string]string{"c.mp4": "intro/1", "a.mp4": "intro/2", "b.mp4": "intro/3"} {
+		if got := takes(st)[file]; got != want {
+			t.Errorf("%s: expected %s, got %s", file, want, got)
+		}
+	}
+
+	if !st.MoveTake("c.mp4", 1) {
+		t.Fatal("expected c.mp4 to move down")
+	}
+	if takes(st)["c.mp4"] != "intro/2" || takes(st)["a.mp4"] != "intro/1" {
+		t.Errorf("unexpected takes: %v", takes(st))
+	}
+
+	if st.MoveTake("d.mp4", 1) || st.MoveTake("a.mp4", -1) {
+		t.Error("expected moves past either end to be refused")
+	}
+	if st.MoveTake("missing.mp4", 1) {
+		t.Error("expected unclassified file not to move")
+	}
+}
+
+func TestState_RenumberTakes(t *testing.T) {
+	st, intro, _, _ := groupFixture()
+	base := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	st.Media = map[string]MediaInfo{
+		"a.mp4": {ModTime: base.Add(2 * time.Minute)},
+		"b.mp4": {Metadata: metadata.Metadata{RecordedTime: base}},
+		"c.mp4": {ModTime: base.Add(time.Minute)},
+	}
+
+	if err := st.RenumberTakes(intro.ID, SortByRecorded); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := takes(st); got["b.mp4"] != "intro/1" || got["c.mp4"] != "intro/2" || got["a.mp4"] != "intro/3" {
+		t.Errorf("expected takes by recording time, got %v", got)
+	}
+
+	// Renamed files sort by their camera filename
+	for i := range st.Classifications {
+		if st.Classifications[i].File == "a.mp4" {
+			st.Classifications[i].File = "[01_03] intro.mp4"
+			st.Classifications[i].Original = "a.mp4"
+		}
+	}
+	if err := st.RenumberTakes(intro.ID, SortByName); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := takes(st); got["[01_03] intro.mp4"] != "intro/1" || got["b.mp4"] != "intro/2" || got["c.mp4"] != "intro/3" {
+		t.Errorf("expected takes by camera filename, got %v", got)
+	}
+
+	if err := st.RenumberTakes(intro.ID, SortByCreatedTime); err == nil {
+		t.Error("expected error for an unsupported order")
+	}
+	if err := st.RenumberTakes("missing", SortByName); err == nil {
+		t.Error("expected error for unknown group")
+	}
+}
+
+func TestState_ReclassifyClosesTakeGap(t *testing.T) {
+	st, intro, middle, _ := groupFixture()
+
+	st.AddOrUpdateClassification("b.mp4", middle.ID)
+	if got := takes(st); got["a.mp4"] != "intro/1" || got["c.mp4"] != "intro/2" || got["b.mp4"] != "middle/2" {
+		t.Errorf("expected intro's takes to close up, got %v", got)
+	}
+	if st.NextTakeNumber(intro.ID) != 3 {
+		t.Errorf("expected next intro take 3, got %d", st.NextTakeNumber(intro.ID))
+	}
+}
+
+// nestedFixture returns a state with scene 1 (shots wide and close, with
+// clips a, b and c) and scene 2 (clip d)
+func nestedFixture() (*State, Group, Group, Group, Group) {
+	st := NewState("/tmp/test", SortByName)
+	scene1 := NewGroup("scene 1", 1)
+	scene2 := NewGroup("scene 2", 2)
+	wide := NewSubGroup("wide", scene1.ID, 1)
+	closeUp := NewSubGroup("close", scene1.ID, 2)
+	st.Groups = []Group{scene2, closeUp, scene1, wide}
+	st.AddOrUpdateClassification("a.mp4", wide.ID)
+	st.AddOrUpdateClassification("b.mp4", wide.ID)
+	st.AddOrUpdateClassification("c.mp4", closeUp.ID)
+	st.AddOrUpdateClassification("d.mp4", scene2.ID)
+	return st, scene1, scene2, wide, closeUp
+}
+
+// treeNames lists group numbers and names in naming order
+func treeNames(st *State) []string {
+	var names []string
+	for _, g := range st.OrderedGroups() {
+		names = append(names, st.GroupNumber(g.ID)+" "+g.Name)
+	}
+	return names
+}
+
+func TestState_GroupHierarchy(t *testing.T) {
+	st, scene1, _, wide, closeUp := nestedFixture()
+
+	expected := []string{"1 scene 1", "1.1 wide", "1.2 close", "2 scene 2"}
+	if got := treeNames(st); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+	if got := st.GroupPath(closeUp.ID); !reflect.DeepEqual(got, []int{1, 2}) {
+		t.Errorf("expected path [1 2], got %v", got)
+	}
+	if got := st.GroupFullName(wide.ID); got != "scene 1 / wide" {
+		t.Errorf("unexpected full name %q", got)
+	}
+	if got := st.Children(scene1.ID); len(got) != 2 || got[0].ID != wide.ID {
+		t.Errorf("unexpected children: %+v", got)
+	}
+	if st.GroupPath("missing") != nil {
+		t.Error("expected no path for an unknown group")
+	}
+}
+
+func TestState_InsertGroup(t *testing.T) {
+	st, scene1, _, _, _ := nestedFixture()
+
+	if err := st.InsertGroup(NewSubGroup("insert", scene1.ID, 2)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := st.InsertGroup(NewGroup("opening", 1)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []string{"1 opening", "2 scene 1", "2.1 wide", "2.2 insert", "2.3 close", "3 scene 2"}
+	if got := treeNames(st); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+
+	if err := st.InsertGroup(NewSubGroup("lost", "missing", 1)); err == nil {
+		t.Error("expected error for an unknown parent")
+	}
+}
+
+func TestState_NestedGroupOperations(t *testing.T) {
+	t.Run("move among siblings", func(t *testing.T) {
+		st, scene1, _, _, closeUp := nestedFixture()
+		if !st.MoveGroup(closeUp.ID, -1) {
+			t.Fatal("expected close to move up")
+		}
+		if st.MoveGroup(closeUp.ID, -1) {
+			t.Error("expected the first shot not to move out of its scene")
+		}
+		if !st.MoveGroup(scene1.ID, 1) {
+			t.Fatal("expected scene 1 to move down")
+		}
+		expected := []string{"1 scene 2", "2 scene 1", "2.1 close", "2.2 wide"}
+		if got := treeNames(st); !reflect.DeepEqual(got, expected) {
+			t.Errorf("expected %v, got %v", expected, got)
+		}
+	})
+
+	t.Run("delete removes sub-groups", func(t *testing.T) {
+		st, scene1, _, _, _ := nestedFixture()
+		files, err := st.DeleteGroup(scene1.ID)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(files) != 3 || len(st.Groups) != 1 || len(st.Classifications) != 1 {
+			t.Errorf("expected scene 1 and its shots to be gone, got %v %+v", files, st.Groups)
+		}
+	})
+
+	t.Run("merge moves sub-groups", func(t *testing.T) {
+		st, scene1, scene2, _, _ := nestedFixture()
+		if err := st.MergeGroups(scene1.ID, scene2.ID); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		expected := []string{"1 scene 2", "1.1 wide", "1.2 close"}
+		if got := treeNames(st); !reflect.DeepEqual(got, expected) {
+			t.Errorf("expected %v, got %v", expected, got)
+		}
+	})
+
+	t.Run("merge into a sub-group", func(t *testing.T) {
+		st, scene1, _, wide, _ := nestedFixture()
+		if err := st.MergeGroups(scene1.ID, wide.ID); err == nil {
+			t.Error("expected error merging a group into its own sub-group")
+		}
+	})
+
+	t.Run("split keeps the parent", func(t *testing.T) {
+		st, _, _, wide, _ := nestedFixture()
+		split, err := st.SplitGroup(wide.ID, 2, "wide 2")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if split.ParentID != wide.ParentID {
+			t.Errorf("expected split to stay in scene 1, got parent %q", split.ParentID)
+		}
+		expected := []string{"1 scene 1", "1.1 wide", "1.2 wide 2", "1.3 close", "2 scene 2"}
+		if got := treeNames(st); !reflect.DeepEqual(got, expected) {
+			t.Errorf("expected %v, got %v", expected, got)
+		}
+	})
+}
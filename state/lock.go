@@ -0,0 +1,193 @@
+// state/lock.go
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// errLockUnsupported is returned by tryLock where the filesystem has no
+// working advisory locks; the PID in the lock file is used instead
+var errLockUnsupported = errors.New("advisory locks not supported")
+
+// LockInfo identifies the process holding a session lock
+type LockInfo struct {
+	PID      int       `json:"pid"`
+	Hostname string    `json:"hostname"`
+	Started  time.Time `json:"started"`
+}
+
+// String describes the lock holder for messages
+func (i LockInfo) String() string {
+	return fmt.Sprintf("PID %d on %s, since %s", i.PID, i.Hostname, i.Started.Format("2006-01-02 15:04"))
+}
+
+// LockedError is returned when another session holds the lock
+type LockedError struct {
+	Holder LockInfo
+}
+
+func (e *LockedError) Error() string {
+	if e.Holder.PID == 0 {
+		return "directory is in use by another clip-tagger session"
+	}
+	return fmt.Sprintf("directory is in use by another clip-tagger session (%s)", e.Holder)
+}
+
+// Lock is an advisory single-instance lock on a session directory
+type Lock struct {
+	path string
+	file *os.File
+
+	// Stale is set when the lock file was left behind by a session that is
+	// no longer running and has been taken over
+	Stale *LockInfo
+}
+
+// LockFilePath returns the lock file path for a directory
+func LockFilePath(dir string) string {
+	return filepath.Join(dir, LockFileName)
+}
+
+// AcquireLock takes the session lock for a directory. The lock is an flock
+// on the lock file, which the OS drops if the process dies, so a file left
+// behind by a crash is detected as stale and taken over. Where advisory
+// locks don't work the PID and hostname in the file decide instead. Returns
+// a *LockedError if another live session holds the lock.
+func AcquireLock(dir string) (*Lock, error) {
+	path := LockFilePath(dir)
+
+	for {
+		f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+		if err != nil {
+			return nil, fmt.Errorf("open lock file: %w", err)
+		}
+
+		previous, hasPrevious := readLockInfo(f)
+
+		err = tryLock(f)
+		switch {
+		case err == nil:
+			// The holder may have released and removed the file between our
+			// open and lock; if so we locked an orphan and must retry
+			if !samePath(f, path) {
+				f.Close()
+				continue
+			}
+		case errors.Is(err, errLockUnsupported):
+			if hasPrevious && !previous.isStale() {
+				f.Close()
+				return nil, &LockedError{Holder: previous}
+			}
+		default:
+			f.Close()
+			if hasPrevious {
+				return nil, &LockedError{Holder: previous}
+			}
+			return nil, &LockedError{}
+		}
+
+		lock := &Lock{path: path, file: f}
+		if hasPrevious && previous.PID != os.Getpid() {
+			lock.Stale = &previous
+		}
+		if err := lock.writeInfo(); err != nil {
+			lock.Release()
+			return nil, err
+		}
+		return lock, nil
+	}
+}
+
+// Release removes the lock file and drops the lock
+func (l *Lock) Release() error {
+	if l == nil || l.file == nil {
+		return nil
+	}
+	// Remove while still locked so no other session can lock the old file
+	removeErr := os.Remove(l.path)
+	unlock(l.file)
+	closeErr := l.file.Close()
+	l.file = nil
+
+	if removeErr != nil && !os.IsNotExist(removeErr) {
+		// Some platforms can't delete a file that is still open
+		if err := os.Remove(l.path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("remove lock file: %w", err)
+		}
+	}
+	return closeErr
+}
+
+// ForceUnlock deletes a directory's lock file regardless of who holds it.
+// A session still running keeps its lock on the deleted file, but new
+// sessions no longer see it.
+func ForceUnlock(dir string) error {
+	if err := os.Remove(LockFilePath(dir)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("remove lock file: %w", err)
+	}
+	return nil
+}
+
+// writeInfo records this process in the lock file
+func (l *Lock) writeInfo() error {
+	hostname, _ := os.Hostname()
+	data, err := json.Marshal(LockInfo{
+		PID:      os.Getpid(),
+		Hostname: hostname,
+		Started:  time.Now(),
+	})
+	if err != nil {
+		return fmt.Errorf("marshal lock info: %w", err)
+	}
+
+	if err := l.file.Truncate(0); err != nil {
+		return fmt.Errorf("write lock file: %w", err)
+	}
+	if _, err := l.file.WriteAt(data, 0); err != nil {
+		return fmt.Errorf("write lock file: %w", err)
+	}
+	return l.file.Sync()
+}
+
+// readLockInfo reads the holder recorded in a lock file, if any
+func readLockInfo(f *os.File) (LockInfo, bool) {
+	data, err := io.ReadAll(io.NewSectionReader(f, 0, 1<<16))
+	if err != nil || len(data) == 0 {
+		return LockInfo{}, false
+	}
+
+	var info LockInfo
+	if err := json.Unmarshal(data, &info); err != nil || info.PID == 0 {
+		return LockInfo{}, false
+	}
+	return info, true
+}
+
+// isStale reports whether the recorded holder is known to be gone. A holder
+// on another host can't be checked, so it is assumed to be alive.
+func (i LockInfo) isStale() bool {
+	hostname, _ := os.Hostname()
+	if i.Hostname != hostname {
+		return false
+	}
+	return !processAlive(i.PID)
+}
+
+// samePath reports whether an open file is still the one at path
+func samePath(f *os.File, path string) bool {
+	openInfo, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	pathInfo, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+	return os.SameFile(openInfo, pathInfo)
+}
@@ -0,0 +1,105 @@
+// state/lock_test.go
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestAcquireLock_ReleaseRemovesFile(t *testing.T) {
+	dir := t.TempDir()
+
+	lock, err := AcquireLock(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if lock.Stale != nil {
+		t.Errorf("expected no stale lock, got %+v", lock.Stale)
+	}
+
+	f, err := os.Open(LockFilePath(dir))
+	if err != nil {
+		t.Fatalf("expected lock file: %v", err)
+	}
+	info, ok := readLockInfo(f)
+	f.Close()
+	if !ok || info.PID != os.Getpid() {
+		t.Errorf("expected lock file to record this process, got %+v", info)
+	}
+
+	if err := lock.Release(); err != nil {
+		t.Fatalf("release: %v", err)
+	}
+	if _, err := os.Stat(LockFilePath(dir)); !os.IsNotExist(err) {
+		t.Error("expected lock file to be removed")
+	}
+}
+
+func TestAcquireLock_Held(t *testing.T) {
+	dir := t.TempDir()
+
+	lock, err := AcquireLock(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer lock.Release()
+
+	_, err = AcquireLock(dir)
+	var locked *LockedError
+	if !errors.As(err, &locked) {
+		t.Fatalf("expected LockedError, got %v", err)
+	}
+	if locked.Holder.PID != os.Getpid() {
+		t.Errorf("expected holder PID %d, got %d", os.Getpid(), locked.Holder.PID)
+	}
+}
+
+func TestAcquireLock_Stale(t *testing.T) {
+	dir := t.TempDir()
+
+	// A crashed session leaves its file behind but no OS lock
+	hostname, _ := os.Hostname()
+	previous := LockInfo{PID: os.Getpid() + 100000, Hostname: hostname, Started: time.Now().Add(-time.Hour)}
+	data, _ := json.Marshal(previous)
+	if err := os.WriteFile(LockFilePath(dir), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	lock, err := AcquireLock(dir)
+	if err != nil {
+		t.Fatalf("expected stale lock to be taken over, got %v", err)
+	}
+	defer lock.Release()
+
+	if lock.Stale == nil || lock.Stale.PID != previous.PID {
+		t.Errorf("expected stale holder %d, got %+v", previous.PID, lock.Stale)
+	}
+}
+
+func TestForceUnlock(t *testing.T) {
+	dir := t.TempDir()
+
+	lock, err := AcquireLock(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer lock.Release()
+
+	if err := ForceUnlock(dir); err != nil {
+		t.Fatalf("force unlock: %v", err)
+	}
+
+	second, err := AcquireLock(dir)
+	if err != nil {
+		t.Fatalf("expected lock after force unlock, got %v", err)
+	}
+	second.Release()
+
+	// Nothing to remove is not an error
+	if err := ForceUnlock(dir); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,59 @@
+// state/media.go
+package state
+
+import (
+	"clip-tagger/metadata"
+	"time"
+)
+
+// MediaInfo is container metadata cached for a file. Size and ModTime
+// identify the version of the file the metadata was read from.
+type MediaInfo struct {
+	Size     int64             `json:"size"`
+	ModTime  time.Time         `json:"mod_time"`
+	Metadata metadata.Metadata `json:"metadata"`
+}
+
+// CachedMetadata returns cached metadata for a file if the file is unchanged
+func (s *State) CachedMetadata(file string, size int64, modTime time.Time) (*metadata.Metadata, bool) {
+	info, ok := s.Media[file]
+	if !ok || info.Size != size || !info.ModTime.Equal(modTime) {
+		return nil, false
+	}
+	md := info.Metadata
+	return &md, true
+}
+
+// CacheMetadata stores metadata for a file
+func (s *State) CacheMetadata(file string, size int64, modTime time.Time, md metadata.Metadata) {
+	if s.Media == nil {
+		s.Media = make(map[string]MediaInfo)
+	}
+	s.Media[file] = MediaInfo{Size: size, ModTime: modTime, Metadata: md}
+}
+
+// GetMetadata returns cached metadata for a file regardless of freshness
+func (s *State) GetMetadata(file string) (*metadata.Metadata, bool) {
+	info, ok := s.Media[file]
+	if !ok {
+		return nil, false
+	}
+	md := info.Metadata
+	return &md, true
+}
+
+// RenameMedia moves cache entries after files are renamed. The renames map
+// old names to new names and is applied as one step, so chains such as
+// A -> B, B -> C don't overwrite each other.
+func (s *State) RenameMedia(renames map[string]string) {
+	moved := make(map[string]MediaInfo)
+	for oldFile, newFile := range renames {
+		if info, ok := s.Media[oldFile]; ok {
+			moved[newFile] = info
+			delete(s.Media, oldFile)
+		}
+	}
+	for newFile, info := range moved {
+		s.Media[newFile] = info
+	}
+}
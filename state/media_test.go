@@ -0,0 +1,97 @@
+// state/media_test.go
+package state
+
+import (
+	"clip-tagger/metadata"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestState_CachedMetadata(t *testing.T) {
+	state := NewState("/test", SortByName)
+	modTime := time.Date(2026, 1, 12, 9, 0, 0, 0, time.UTC)
+	md := metadata.Metadata{Width: 1920, Height: 1080, Codec: "H.264"}
+
+	state.CacheMetadata("clip.mp4", 1024, modTime, md)
+
+	t.Run("hit when file unchanged", func(t *testing.T) {
+		cached, ok := state.CachedMetadata("clip.mp4", 1024, modTime)
+		if !ok {
+			t.Fatal("expected cache hit")
+		}
+		if cached.Codec != "H.264" {
+			t.Errorf("expected codec H.264, got %s", cached.Codec)
+		}
+	})
+
+	t.Run("miss when size changed", func(t *testing.T) {
+		if _, ok := state.CachedMetadata("clip.mp4", 2048, modTime); ok {
+			t.Error("expected cache miss for changed size")
+		}
+	})
+
+	t.Run("miss when modified time changed", func(t *testing.T) {
+		if _, ok := state.CachedMetadata("clip.mp4", 1024, modTime.Add(time.Second)); ok {
+			t.Error("expected cache miss for changed modified time")
+		}
+	})
+
+	t.Run("miss for unknown file", func(t *testing.T) {
+		if _, ok := state.CachedMetadata("other.mp4", 1024, modTime); ok {
+			t.Error("expected cache miss for unknown file")
+		}
+	})
+}
+
+func TestState_RenameMedia(t *testing.T) {
+	state := NewState("/test", SortByName)
+	modTime := time.Now()
+	state.CacheMetadata("[02_01] a.mp4", 1, modTime, metadata.Metadata{Codec: "A"})
+	state.CacheMetadata("[03_01] b.mp4", 2, modTime, metadata.Metadata{Codec: "B"})
+
+	// Chain: a moves into b's old name while b moves on
+	state.RenameMedia(map[string]string{
+		"[02_01] a.mp4": "[03_01] a.mp4",
+		"[03_01] b.mp4": "[04_01] b.mp4",
+	})
+
+	if md, ok := state.GetMetadata("[03_01] a.mp4"); !ok || md.Codec != "A" {
+		t.Errorf("expected a's metadata under new name, got %v", md)
+	}
+	if md, ok := state.GetMetadata("[04_01] b.mp4"); !ok || md.Codec != "B" {
+		t.Errorf("expected b's metadata under new name, got %v", md)
+	}
+	if _, ok := state.GetMetadata("[02_01] a.mp4"); ok {
+		t.Error("expected old name to be removed")
+	}
+}
+
+func TestState_MediaSaveAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), StateFileName)
+	modTime := time.Date(2026, 1, 12, 9, 0, 0, 0, time.UTC)
+	recorded := time.Date(2026, 1, 12, 8, 59, 0, 0, time.UTC)
+
+	state := NewState("/test", SortByName)
+	state.CacheMetadata("clip.mp4", 1024, modTime, metadata.Metadata{
+		RecordedTime: recorded,
+		Duration:     30 * time.Second,
+		Timecode:     "01:00:00:00",
+	})
+	if err := state.Save(path); err != nil {
+		t.Fatalf("save failed: %v", err)
+	}
+
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+
+	md, ok := loaded.CachedMetadata("clip.mp4", 1024, modTime)
+	if !ok {
+		t.Fatal("expected cached metadata after load")
+	}
+	if !md.RecordedTime.Equal(recorded) || md.Duration != 30*time.Second || md.Timecode != "01:00:00:00" {
+		t.Errorf("metadata did not round-trip: %+v", md)
+	}
+}
@@ -0,0 +1,146 @@
+// state/migrate.go
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// SchemaVersion is the state file format written by this build. Bump it
+// and append a migration whenever a change to State, Group or
+// Classification would be misread from an older file.
+const SchemaVersion = 3
+
+// migration upgrades a decoded state document from one schema version to
+// the next. Documents are generic JSON so a migration can reshape fields
+// the current structs no longer have.
+type migration struct {
+	from        int
+	description string
+	apply       func(doc map[string]any) error
+}
+
+// migrations is the ordered upgrade path; entry i upgrades version i to i+1
+var migrations = []migration{
+	{
+		from:        0,
+		description: "add schema_version to files written before versioning",
+		apply: func(doc map[string]any) error {
+			// Hand-edited files may have dropped or nulled the lists
+			for _, key := range []string{"groups", "classifications", "skipped"} {
+				if doc[key] == nil {
+					doc[key] = []any{}
+				}
+			}
+			return nil
+		},
+	},
+	{
+		from:        1,
+		description: "groups can have a parent group",
+		apply: func(doc map[string]any) error {
+			// Existing groups have no parent_id and stay at the top level;
+			// the bump keeps older builds from flattening nested groups
+			return nil
+		},
+	},
+	{
+		from:        2,
+		description: "skipped files are no longer classified",
+		apply: func(doc map[string]any) error {
+			// Older builds could classify a skipped file, or skip a file
+			// twice; classifying now takes a file out of skipped
+			classified := make(map[string]bool)
+			list, _ := doc["classifications"].([]any)
+			for _, item := range list {
+				if c, ok := item.(map[string]any); ok {
+					if file, ok := c["file"].(string); ok {
+						classified[file] = true
+					}
+				}
+			}
+
+			skipped, _ := doc["skipped"].([]any)
+			kept := []any{}
+			seen := make(map[string]bool)
+			for _, item := range skipped {
+				file, ok := item.(string)
+				if !ok {
+					return fmt.Errorf("invalid skipped file: %v", item)
+				}
+				if classified[file] || seen[file] {
+					continue
+				}
+				seen[file] = true
+				kept = append(kept, file)
+			}
+			doc["skipped"] = kept
+			return nil
+		},
+	},
+}
+
+// NewerSchemaError is returned when a state file was written by a newer
+// clip-tagger than this one
+type NewerSchemaError struct {
+	Version int
+}
+
+func (e *NewerSchemaError) Error() string {
+	return fmt.Sprintf("state file uses schema version %d, but this clip-tagger only reads up to version %d; upgrade clip-tagger to open this session",
+		e.Version, SchemaVersion)
+}
+
+// migrate upgrades a decoded state document to SchemaVersion in place and
+// returns the version it started at
+func migrate(doc map[string]any) (int, error) {
+	version := 0
+	if raw, ok := doc["schema_version"]; ok && raw != nil {
+		number, ok := raw.(float64)
+		if !ok || number < 0 || number != float64(int(number)) {
+			return 0, fmt.Errorf("invalid schema_version: %v", raw)
+		}
+		version = int(number)
+	}
+
+	if version > SchemaVersion {
+		return version, &NewerSchemaError{Version: version}
+	}
+
+	for v := version; v < SchemaVersion; v++ {
+		m := migrations[v]
+		if err := m.apply(doc); err != nil {
+			return version, fmt.Errorf("migrate schema %d to %d (%s): %w", m.from, m.from+1, m.description, err)
+		}
+		doc["schema_version"] = m.from + 1
+	}
+	return version, nil
+}
+
+// decodeState parses a state file of any supported schema version
+func decodeState(data []byte) (*State, error) {
+	var doc map[string]any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if doc == nil {
+		return nil, fmt.Errorf("state file is empty")
+	}
+
+	version, err := migrate(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	if version != SchemaVersion {
+		if data, err = json.Marshal(doc); err != nil {
+			return nil, err
+		}
+	}
+
+	var state State
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
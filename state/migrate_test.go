@@ -0,0 +1,217 @@
+// state/migrate_test.go
+package state
+
+import (
+	"clip-tagger/renamer"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// loadFixture copies a testdata state file into a temp dir and loads it,
+// so restores or saves never touch the fixture
+func loadFixture(t *testing.T, name string) (*State, string, error) {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := StateFilePath(t.TempDir())
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	st, err := Load(path)
+	return st, path, err
+}
+
+func TestMigrations_Ordered(t *testing.T) {
+	if len(migrations) != SchemaVersion {
+		t.Fatalf("expected %d migrations for schema %d, got %d", SchemaVersion, SchemaVersion, len(migrations))
+	}
+	for i, m := range migrations {
+		if m.from != i {
+			t.Errorf("migration %d upgrades from %d; registry must be ordered and contiguous", i, m.from)
+		}
+	}
+}
+
+func TestLoad_SchemaV0(t *testing.T) {
+	st, _, err := loadFixture(t, "v0-baseline.json")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if st.SchemaVersion != SchemaVersion {
+		t.Errorf("expected schema %d after migration, got %d", SchemaVersion, st.SchemaVersion)
+	}
+	if st.SortBy != SortByModifiedTime || st.CurrentIndex != 3 {
+		t.Errorf("unexpected session settings: %s, %d", st.SortBy, st.CurrentIndex)
+	}
+	if len(st.Groups) != 2 || st.Groups[1].Name != "card trick" {
+		t.Errorf("unexpected groups: %+v", st.Groups)
+	}
+	if len(st.Classifications) != 3 || st.Classifications[2].TakeNumber != 2 {
+		t.Errorf("unexpected classifications: %+v", st.Classifications)
+	}
+	if len(st.Skipped) != 1 || st.Skipped[0] != "bloopers.mp4" {
+		t.Errorf("unexpected skipped files: %v", st.Skipped)
+	}
+}
+
+func TestLoad_SchemaV0_NullLists(t *testing.T) {
+	st, _, err := loadFixture(t, "v0-null-lists.json")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if st.Groups == nil || st.Classifications == nil || st.Skipped == nil {
+		t.Errorf("expected empty lists, got %+v", st)
+	}
+}
+
+func TestLoad_SchemaV1(t *testing.T) {
+	st, _, err := loadFixture(t, "v1-current.json")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !st.Recursive || st.Layout != renamer.LayoutPreserve || st.NameTemplate == "" {
+		t.Errorf("unexpected session settings: %+v", st)
+	}
+	if st.Classifications[0].Original != "C0001.MP4" {
+		t.Errorf("expected original name, got %+v", st.Classifications[0])
+	}
+	media, ok := st.Media[st.Classifications[0].File]
+	if !ok || media.Size != 104857600 || media.Metadata.Camera != "FX3" {
+		t.Errorf("unexpected media cache: %+v", st.Media)
+	}
+}
+
+func TestLoad_NewerSchemaRefused(t *testing.T) {
+	_, path, err := loadFixture(t, "future.json")
+
+	var newer *NewerSchemaError
+	if !errors.As(err, &newer) {
+		t.Fatalf("expected NewerSchemaError, got %v", err)
+	}
+	if newer.Version != 99 {
+		t.Errorf("expected version 99, got %d", newer.Version)
+	}
+
+	// The file is left alone rather than treated as corrupt
+	if _, err := os.Stat(path + ".corrupt"); !os.IsNotExist(err) {
+		t.Error("expected newer file not to be moved aside")
+	}
+}
+
+func TestSave_WritesCurrentSchema(t *testing.T) {
+	st, path, err := loadFixture(t, "v0-baseline.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := st.Save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	doc, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reloaded, err := decodeState(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reloaded.SchemaVersion != SchemaVersion || len(reloaded.Classifications) != 3 {
+		t.Errorf("unexpected state after save: %+v", reloaded)
+	}
+}
+
+func TestMigrate_InvalidVersion(t *testing.T) {
+	if _, err := migrate(map[string]any{"schema_version": "one"}); err == nil {
+		t.Error("expected error for non-numeric schema_version")
+	}
+	if _, err := migrate(map[string]any{"schema_version": -1.0}); err == nil {
+		t.Error("expected error for negative schema_version")
+	}
+}
+
+func TestLoad_SchemaV1NamesUnchanged(t *testing.T) {
+	st, _, err := loadFixture(t, "v1-current.json")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Groups from before nesting stay top level and keep their names
+	if st.Groups[0].ParentID != "" {
+		t.Errorf("expected a top-level group, got parent %q", st.Groups[0].ParentID)
+	}
+	c := st.Classifications[0]
+	target, _ := st.TargetPath(c, st.NameNumbers())
+	if filepath.Base(target) != filepath.Base(c.File) {
+		t.Errorf("expected %s to keep its name, got %s", c.File, filepath.Base(target))
+	}
+}
+
+func TestLoad_SchemaV2Nested(t *testing.T) {
+	st, _, err := loadFixture(t, "v2-nested.json")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	c, _ := st.GetClassification("C0002.MP4")
+	if !c.Circled || c.Rating != 4 {
+		t.Errorf("unexpected annotation: %+v", c.Annotation)
+	}
+	if target, _ := st.TargetPath(c, st.NameNumbers()); filepath.Base(target) != "[01_02_01] close.MP4" {
+		t.Errorf("unexpected target %s", filepath.Base(target))
+	}
+}
+
+func TestLoad_SchemaV2SkippedAndClassified(t *testing.T) {
+	st, _, err := loadFixture(t, "v2-skipped.json")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// C0002 was classified after being skipped, C0001 skipped twice
+	if !slices.Equal(st.Skipped, []string{"C0001.MP4", "C0003.MP4"}) {
+		t.Errorf("unexpected skipped files %v", st.Skipped)
+	}
+	if _, ok := st.GetClassification("C0002.MP4"); !ok {
+		t.Error("expected C0002.MP4 to stay classified")
+	}
+}
+
+func TestLoad_MigrationErrorKeepsFile(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := StateFilePath(tmpDir)
+	if err := NewState(tmpDir, SortByName).Save(path); err != nil {
+		t.Fatal(err)
+	}
+	if err := BackupState(tmpDir); err != nil {
+		t.Fatal(err)
+	}
+
+	// Valid JSON the skipped-files migration can't upgrade
+	doc := `{"schema_version": 2, "directory": "/card", "groups": [], "classifications": [], "skipped": [1]}`
+	if err := os.WriteFile(path, []byte(doc), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	st, err := Load(path)
+	if err == nil || !strings.Contains(err.Error(), "migrate schema 2 to 3") {
+		t.Fatalf("expected migration error, got %v (%+v)", err, st)
+	}
+
+	// Neither a backup restored nor the file moved aside
+	if _, err := os.Stat(path + ".corrupt"); !os.IsNotExist(err) {
+		t.Error("expected file not to be moved aside")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || string(data) != doc {
+		t.Errorf("expected file to be left alone, got %q (%v)", data, err)
+	}
+}
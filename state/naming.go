@@ -0,0 +1,154 @@
+// state/naming.go
+package state
+
+import (
+	"cmp"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"clip-tagger/renamer"
+)
+
+// Template returns the session's filename template, falling back to the
+// default when none is set or the saved one no longer parses. The parsed
+// template is cached until NameTemplate changes.
+func (s *State) Template() *renamer.Template {
+	if s.template != nil && s.templateSource == s.NameTemplate {
+		return s.template
+	}
+	tmpl := renamer.MustParseTemplate(renamer.DefaultTemplate)
+	if s.NameTemplate != "" {
+		if parsed, err := renamer.ParseTemplate(s.NameTemplate); err == nil {
+			tmpl = parsed
+		}
+	}
+	s.template, s.templateSource = tmpl, s.NameTemplate
+	return tmpl
+}
+
+// NameNumbers holds the values every name in a build shares: the padding
+// widths and each file's position in final name order. Compute it once
+// with State.NameNumbers and pass it to NameFields for each file.
+type NameNumbers struct {
+	OrderWidth   int
+	TakeWidth    int
+	CounterWidth int
+	Counters     map[string]int // Position in final name order by file, starting at 1
+}
+
+// noSeparators replaces path separators in free-text name fields
+var noSeparators = strings.NewReplacer("/", "-", "\\", "-")
+
+// NameFields collects the template values for a classification, using
+// numbers from NameNumbers. Returns false if the classification's group no
+// longer exists.
+func (s *State) NameFields(c Classification, numbers NameNumbers) (renamer.Fields, bool) {
+	group := s.FindGroupByID(c.GroupID)
+	if group == nil {
+		return renamer.Fields{}, false
+	}
+
+	original := c.File
+	if c.Original != "" {
+		original = c.Original
+	}
+
+	fields := renamer.Fields{
+		GroupOrder: group.Order,
+		GroupPath:  s.GroupPath(group.ID),
+		Take:       c.TakeNumber,
+		GroupName:  group.Name,
+		Stem:       strings.TrimSuffix(path.Base(original), path.Ext(original)),
+		Recorded:   s.recordedTime(c.File),
+		Counter:    numbers.Counters[c.File],
+		Rating:     c.Rating,
+		Circled:    c.Circled,
+	}
+	// Notes, tags and metadata are free text; keep them from adding
+	// folders to the path
+	fields.Notes = noSeparators.Replace(c.Notes)
+	for _, tag := range c.Tags {
+		fields.Tags = append(fields.Tags, noSeparators.Replace(tag))
+	}
+	fields.OrderWidth, fields.TakeWidth, fields.CounterWidth = numbers.OrderWidth, numbers.TakeWidth, numbers.CounterWidth
+	if md, ok := s.GetMetadata(c.File); ok {
+		fields.Camera = noSeparators.Replace(md.Camera)
+	}
+
+	return fields, true
+}
+
+// TargetPath returns the full path a classified file will be renamed to,
+// using numbers from NameNumbers. Returns false if the classification's
+// group no longer exists.
+func (s *State) TargetPath(c Classification, numbers NameNumbers) (string, bool) {
+	fields, ok := s.NameFields(c, numbers)
+	if !ok {
+		return "", false
+	}
+	return renamer.GenerateTargetPathFromTemplate(
+		s.Directory,
+		c.File,
+		s.Layout,
+		s.Template(),
+		fields,
+	), true
+}
+
+// recordedTime returns the embedded recording time of a file, falling back
+// to its modified time
+func (s *State) recordedTime(file string) time.Time {
+	if info, ok := s.Media[file]; ok {
+		if !info.Metadata.RecordedTime.IsZero() {
+			return info.Metadata.RecordedTime
+		}
+		return info.ModTime
+	}
+	if info, err := os.Stat(filepath.Join(s.Directory, filepath.FromSlash(file))); err == nil {
+		return info.ModTime()
+	}
+	return time.Time{}
+}
+
+// NameNumbers computes the padding needed for the highest group order, the
+// highest take in any group and the number of classified files, so that a
+// project with 100+ groups or takes still sorts alphabetically, and the
+// counter of every classification: its position in final name order (group
+// order with sub-groups after their parent, then take, then filename)
+func (s *State) NameNumbers() NameNumbers {
+	maxOrder, maxTake := 0, 0
+	for _, g := range s.Groups {
+		maxOrder = max(maxOrder, g.Order)
+	}
+	for _, c := range s.Classifications {
+		maxTake = max(maxTake, c.TakeNumber)
+	}
+
+	orders := make(map[string]int)
+	for i, g := range s.OrderedGroups() {
+		orders[g.ID] = i
+	}
+	sorted := slices.Clone(s.Classifications)
+	slices.SortFunc(sorted, func(a, b Classification) int {
+		return cmp.Or(
+			cmp.Compare(orders[a.GroupID], orders[b.GroupID]),
+			cmp.Compare(a.TakeNumber, b.TakeNumber),
+			strings.Compare(a.File, b.File),
+		)
+	})
+	counters := make(map[string]int, len(sorted))
+	for i, c := range sorted {
+		counters[c.File] = i + 1
+	}
+
+	return NameNumbers{
+		OrderWidth:   renamer.DigitWidth(maxOrder),
+		TakeWidth:    renamer.DigitWidth(maxTake),
+		CounterWidth: renamer.DigitWidth(len(s.Classifications)),
+		Counters:     counters,
+	}
+}
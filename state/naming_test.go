@@ -0,0 +1,191 @@
+// state/naming_test.go
+package state
+
+import (
+	"clip-tagger/metadata"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestState_TargetPath_Template(t *testing.T) {
+	st := NewState("/card", SortByName)
+	st.NameTemplate = "{date:2006-01-02}_{seq:03}-{take:02}_{group|slug}_{camera}_{stem}"
+	group := NewGroup("Magic Trick", 2)
+	st.Groups = []Group{group}
+	st.Classifications = []Classification{
+		{File: "C0001.MP4", GroupID: group.ID, TakeNumber: 1},
+	}
+
+	recorded := time.Date(2026, 1, 12, 14, 3, 22, 0, time.UTC)
+	st.CacheMetadata("C0001.MP4", 100, recorded, metadata.Metadata{RecordedTime: recorded, Camera: "FX3"})
+
+	target, ok := st.TargetPath(st.Classifications[0], st.NameNumbers())
+	if !ok {
+		t.Fatal("expected target path")
+	}
+	expected := filepath.Join("/card", "2026-01-12_002-01_magic-trick_FX3_C0001.MP4")
+	if target != expected {
+		t.Errorf("expected %s, got %s", expected, target)
+	}
+}
+
+func TestState_TargetPath_CameraWithSlash(t *testing.T) {
+	st := NewState("/card", SortByName)
+	st.NameTemplate = "{camera}_{take}"
+	group := NewGroup("intro", 1)
+	st.Groups = []Group{group}
+	st.Classifications = []Classification{{File: "C0001.MP4", GroupID: group.ID, TakeNumber: 1}}
+	st.CacheMetadata("C0001.MP4", 1, time.Time{}, metadata.Metadata{Camera: `ILCE-7SM3/FX3\A`})
+
+	target, _ := st.TargetPath(st.Classifications[0], st.NameNumbers())
+	if target != filepath.Join("/card", "ILCE-7SM3-FX3-A_01.MP4") {
+		t.Errorf("expected separators in the camera name replaced, got %s", target)
+	}
+}
+
+func TestState_NameFields_StemAfterRename(t *testing.T) {
+	st := NewState("/card", SortByName)
+	group := NewGroup("intro", 1)
+	st.Groups = []Group{group}
+	st.Classifications = []Classification{
+		{File: "C0001.MP4", GroupID: group.ID, TakeNumber: 1},
+	}
+
+	st.RenameFiles(map[string]string{"C0001.MP4": "[01_01] intro.MP4"})
+
+	fields, ok := st.NameFields(st.Classifications[0], st.NameNumbers())
+	if !ok {
+		t.Fatal("expected fields")
+	}
+	if fields.Stem != "C0001" {
+		t.Errorf("expected stem of the camera filename, got %s", fields.Stem)
+	}
+}
+
+func TestState_NameFields_Counter(t *testing.T) {
+	st := NewState("/card", SortByName)
+	intro := NewGroup("intro", 1)
+	outro := NewGroup("outro", 2)
+	st.Groups = []Group{intro, outro}
+	st.Classifications = []Classification{
+		{File: "c.mp4", GroupID: outro.ID, TakeNumber: 1},
+		{File: "b.mp4", GroupID: intro.ID, TakeNumber: 2},
+		{File: "a.mp4", GroupID: intro.ID, TakeNumber: 1},
+	}
+
+	expected := []int{3, 2, 1}
+	numbers := st.NameNumbers()
+	for i, c := range st.Classifications {
+		fields, _ := st.NameFields(c, numbers)
+		if fields.Counter != expected[i] {
+			t.Errorf("%s: expected counter %d, got %d", c.File, expected[i], fields.Counter)
+		}
+	}
+}
+
+func TestState_Template_FallsBackToDefault(t *testing.T) {
+	st := NewState("/card", SortByName)
+	st.NameTemplate = "{unknown}"
+
+	if tmpl := st.Template(); tmpl.String() != "[{seq}_{take}] {group}" {
+		t.Errorf("expected default template, got %s", tmpl)
+	}
+}
+
+func TestState_Template_Cached(t *testing.T) {
+	st := NewState("/card", SortByName)
+	st.NameTemplate = "{seq}-{take}"
+
+	first := st.Template()
+	if st.Template() != first {
+		t.Error("expected the parsed template to be reused")
+	}
+
+	st.NameTemplate = "{group}_{take}"
+	if tmpl := st.Template(); tmpl == first || tmpl.String() != "{group}_{take}" {
+		t.Errorf("expected the changed template to be parsed, got %s", tmpl)
+	}
+}
+
+func TestState_RepairRenamedFiles_Template(t *testing.T) {
+	st := NewState(t.TempDir(), SortByName)
+	st.NameTemplate = "{seq:03}-{take}_{group|slug}"
+	group := NewGroup("Wide Shot", 1)
+	st.Groups = []Group{group}
+	st.Classifications = []Classification{
+		{File: "C0001.MP4", GroupID: group.ID, TakeNumber: 1},
+		{File: "C0002.MP4", GroupID: group.ID, TakeNumber: 2},
+	}
+
+	// C0002 was renamed with an older, narrower padding width
+	scanned := []string{"001-01_wide-shot.MP4", "01-02_wide-shot.MP4"}
+
+	if repaired := st.RepairRenamedFiles(scanned); repaired != 2 {
+		t.Fatalf("expected 2 repairs, got %d", repaired)
+	}
+	if st.Classifications[0].File != "001-01_wide-shot.MP4" || st.Classifications[0].Original != "C0001.MP4" {
+		t.Errorf("unexpected first classification: %+v", st.Classifications[0])
+	}
+	if st.Classifications[1].File != "01-02_wide-shot.MP4" {
+		t.Errorf("unexpected second classification: %+v", st.Classifications[1])
+	}
+}
+
+func TestState_TargetPath_AutoPadding(t *testing.T) {
+	st := NewState("/card", SortByName)
+	for i := 1; i <= 100; i++ {
+		st.Groups = append(st.Groups, NewGroup("scene", i))
+	}
+	st.Classifications = []Classification{
+		{File: "a.mp4", GroupID: st.Groups[10].ID, TakeNumber: 1},
+		{File: "b.mp4", GroupID: st.Groups[99].ID, TakeNumber: 1},
+	}
+
+	numbers := st.NameNumbers()
+	first, _ := st.TargetPath(st.Classifications[0], numbers)
+	last, _ := st.TargetPath(st.Classifications[1], numbers)
+
+	if filepath.Base(first) != "[011_01] scene.mp4" {
+		t.Errorf("expected group 11 padded to three digits, got %s", filepath.Base(first))
+	}
+	if filepath.Base(last) != "[100_01] scene.mp4" {
+		t.Errorf("unexpected name for group 100: %s", filepath.Base(last))
+	}
+	if filepath.Base(first) > filepath.Base(last) {
+		t.Error("group 11 should sort before group 100")
+	}
+}
+
+func TestState_TargetPath_NestedGroups(t *testing.T) {
+	st, _, _, _, _ := nestedFixture()
+	st.Directory = "/card"
+
+	expected := map[string]string{
+		"a.mp4": "[01_01_01] wide.mp4",
+		"b.mp4": "[01_01_02] wide.mp4",
+		"c.mp4": "[01_02_01] close.mp4",
+		"d.mp4": "[02_01] scene 2.mp4",
+	}
+	numbers := st.NameNumbers()
+	for _, c := range st.Classifications {
+		target, _ := st.TargetPath(c, numbers)
+		if filepath.Base(target) != expected[c.File] {
+			t.Errorf("%s: expected %s, got %s", c.File, expected[c.File], filepath.Base(target))
+		}
+	}
+
+	st.NameTemplate = "[{seq:A}_{take}] {group}"
+	c, _ := st.GetClassification("c.mp4")
+	if target, _ := st.TargetPath(c, numbers); filepath.Base(target) != "[01B_01] close.mp4" {
+		t.Errorf("unexpected lettered name %s", filepath.Base(target))
+	}
+
+	// Shots number after their scene across the project
+	for file, want := range map[string]int{"a.mp4": 1, "c.mp4": 3, "d.mp4": 4} {
+		c, _ := st.GetClassification(file)
+		if fields, _ := st.NameFields(c, numbers); fields.Counter != want {
+			t.Errorf("%s: expected counter %d, got %d", file, want, fields.Counter)
+		}
+	}
+}
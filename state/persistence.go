@@ -3,41 +3,68 @@ package state
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
+	"time"
 )
 
 const StateFileName = ".clip-tagger-state.json"
 const LockFileName = ".clip-tagger.lock"
 
-// Save writes state to JSON file
+// MaxBackups is the number of timestamped backup generations kept
+const MaxBackups = 5
+
+// backupTimeLayout names backups so they sort chronologically by filename
+const backupTimeLayout = "20060102-150405.000"
+
+// Save writes state to JSON file. The data is written to a temporary file,
+// synced and renamed over the old file, so a crash or full disk leaves
+// either the old or the new state, never a truncated one.
 func (s *State) Save(path string) error {
+	s.SchemaVersion = SchemaVersion
 	data, err := json.MarshalIndent(s, "", "  ")
 	if err != nil {
 		return fmt.Errorf("marshal state: %w", err)
 	}
 
-	if err := os.WriteFile(path, data, 0644); err != nil {
+	if err := writeFileAtomic(path, data); err != nil {
 		return fmt.Errorf("write state file: %w", err)
 	}
 
 	return nil
 }
 
-// Load reads state from JSON file
+// Load reads state from JSON file, migrating files written with an older
+// schema and refusing ones written by a newer build. If the file is not
+// valid JSON, the newest valid backup is restored in its place and
+// RestoredFrom is set; the unreadable file is kept with a .corrupt suffix.
+// A file that parses but fails to migrate is returned as an error and left
+// alone.
 func Load(path string) (*State, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("read state file: %w", err)
 	}
 
-	var state State
-	if err := json.Unmarshal(data, &state); err != nil {
-		return nil, fmt.Errorf("unmarshal state: %w", err)
+	state, err := decodeState(data)
+	var syntaxErr *json.SyntaxError
+	if err != nil && !errors.As(err, &syntaxErr) {
+		// Readable, just not by this build or not as this schema; a backup
+		// would lose newer work
+		return nil, err
+	} else if err != nil {
+		restored, restoreErr := restoreBackup(path)
+		if restoreErr != nil {
+			return nil, fmt.Errorf("unmarshal state: %w (%v)", err, restoreErr)
+		}
+		return restored, nil
 	}
 
-	return &state, nil
+	return state, nil
 }
 
 // StateFilePath returns the state file path for a directory
@@ -51,23 +78,147 @@ func StateExists(dir string) bool {
 	return err == nil
 }
 
-// BackupState creates a backup of the state file
+// BackupState copies the state file to a new timestamped backup, keeping
+// the newest MaxBackups generations
 func BackupState(dir string) error {
 	if !StateExists(dir) {
 		return fmt.Errorf("state file does not exist")
 	}
 
 	statePath := StateFilePath(dir)
-	backupPath := statePath + ".bak"
 
 	data, err := os.ReadFile(statePath)
 	if err != nil {
 		return fmt.Errorf("read state: %w", err)
 	}
 
-	if err := os.WriteFile(backupPath, data, 0644); err != nil {
+	// Two backups within the same millisecond get distinct names
+	now := time.Now()
+	backupPath := backupFilePath(statePath, now)
+	for {
+		if _, err := os.Stat(backupPath); os.IsNotExist(err) {
+			break
+		}
+		now = now.Add(time.Millisecond)
+		backupPath = backupFilePath(statePath, now)
+	}
+
+	if err := writeFileAtomic(backupPath, data); err != nil {
 		return fmt.Errorf("write backup: %w", err)
 	}
 
+	// Drop the oldest generations
+	backups := Backups(dir)
+	for _, old := range backups[min(len(backups), MaxBackups):] {
+		if old == statePath+".bak" {
+			continue // Single backup from older versions; never rotated
+		}
+		if err := os.Remove(old); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("remove old backup: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Backups returns the directory's state backups, newest first. A .bak file
+// written by older versions comes last.
+func Backups(dir string) []string {
+	return backupsOf(StateFilePath(dir))
+}
+
+// backupFilePath returns the backup path for a state file at a time
+func backupFilePath(statePath string, t time.Time) string {
+	return fmt.Sprintf("%s.%s.bak", statePath, t.Format(backupTimeLayout))
+}
+
+// backupsOf lists the backups of a state file, newest first
+func backupsOf(statePath string) []string {
+	entries, _ := os.ReadDir(filepath.Dir(statePath))
+	prefix := filepath.Base(statePath) + "."
+	legacy := prefix + "bak"
+
+	var backups []string
+	hasLegacy := false
+	for _, entry := range entries {
+		name := entry.Name()
+		switch {
+		case name == legacy:
+			hasLegacy = true
+		case strings.HasPrefix(name, prefix) && strings.HasSuffix(name, ".bak"):
+			backups = append(backups, filepath.Join(filepath.Dir(statePath), name))
+		}
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(backups)))
+
+	if hasLegacy {
+		backups = append(backups, statePath+".bak")
+	}
+	return backups
+}
+
+// restoreBackup replaces an unreadable state file with its newest valid
+// backup, keeping the unreadable copy for inspection
+func restoreBackup(statePath string) (*State, error) {
+	for _, backup := range backupsOf(statePath) {
+		data, err := os.ReadFile(backup)
+		if err != nil {
+			continue
+		}
+		state, err := decodeState(data)
+		if err != nil {
+			continue
+		}
+
+		if err := os.Rename(statePath, statePath+".corrupt"); err != nil {
+			return nil, fmt.Errorf("keep corrupt state file: %w", err)
+		}
+		if err := writeFileAtomic(statePath, data); err != nil {
+			return nil, fmt.Errorf("restore backup: %w", err)
+		}
+		state.RestoredFrom = backup
+		return state, nil
+	}
+	return nil, fmt.Errorf("no valid backup to restore")
+}
+
+// writeFileAtomic replaces path with data via a synced temporary file in
+// the same directory
+func writeFileAtomic(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Chmod(tmpPath, 0644); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	// Persist the rename itself; not every platform can sync a directory
+	if d, err := os.Open(dir); err == nil {
+		_ = d.Sync()
+		d.Close()
+	}
 	return nil
 }
@@ -0,0 +1,134 @@
+// state/skipped.go
+package state
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+)
+
+// Disposition says what happens to a skipped clip when the rename batch runs
+type Disposition string
+
+const (
+	DispositionKeep   Disposition = ""       // Leave the clip under its original name
+	DispositionReject Disposition = "reject" // Move the clip to the rejects folder or the trash
+	DispositionDelete Disposition = "delete" // List the clip for deleting by hand
+)
+
+// RejectsDir is the default folder inside the session root rejected clips
+// are moved to, keeping their path relative to the root
+const RejectsDir = "_rejected"
+
+// RejectToTrash sends rejected clips to the desktop trash instead of a folder
+const RejectToTrash = "trash"
+
+// dispositions lists the dispositions in the order Next cycles through them
+var dispositions = []Disposition{DispositionKeep, DispositionReject, DispositionDelete}
+
+// Next returns the disposition after d, wrapping around
+func (d Disposition) Next() Disposition {
+	i := slices.Index(dispositions, d)
+	return dispositions[(i+1)%len(dispositions)]
+}
+
+// Description describes a disposition for display
+func (d Disposition) Description() string {
+	switch d {
+	case DispositionReject:
+		return "reject"
+	case DispositionDelete:
+		return "marked for deletion"
+	default:
+		return "keep original name"
+	}
+}
+
+// IsSkipped reports whether a file was skipped
+func (s *State) IsSkipped(filename string) bool {
+	return slices.Contains(s.Skipped, filename)
+}
+
+// Skip marks a file as skipped. Skipped files are never classified, so an
+// existing classification is removed and its group's takes renumbered.
+func (s *State) Skip(filename string) {
+	s.RemoveClassification(filename)
+	if !s.IsSkipped(filename) {
+		s.Skipped = append(s.Skipped, filename)
+	}
+}
+
+// Unskip returns a skipped file to the queue and forgets its disposition.
+// Returns false if the file wasn't skipped.
+func (s *State) Unskip(filename string) bool {
+	if !s.IsSkipped(filename) {
+		return false
+	}
+	s.Skipped = slices.DeleteFunc(s.Skipped, func(f string) bool { return f == filename })
+	delete(s.Dispositions, filename)
+	return true
+}
+
+// SetDisposition chooses what happens to a skipped file
+func (s *State) SetDisposition(filename string, d Disposition) error {
+	if !slices.Contains(dispositions, d) {
+		return fmt.Errorf("unknown disposition: %s", d)
+	}
+	if !s.IsSkipped(filename) {
+		return fmt.Errorf("file is not skipped: %s", filename)
+	}
+	if d == DispositionKeep {
+		delete(s.Dispositions, filename)
+		return nil
+	}
+	if s.Dispositions == nil {
+		s.Dispositions = make(map[string]Disposition)
+	}
+	s.Dispositions[filename] = d
+	return nil
+}
+
+// Disposition returns what happens to a skipped file
+func (s *State) Disposition(filename string) Disposition {
+	return s.Dispositions[filename]
+}
+
+// SkippedWith returns the skipped files with a disposition, in skip order
+func (s *State) SkippedWith(d Disposition) []string {
+	var files []string
+	for _, f := range s.Skipped {
+		if s.Disposition(f) == d {
+			files = append(files, f)
+		}
+	}
+	return files
+}
+
+// RejectFolder returns the folder inside the session root rejected clips
+// are moved to, or "" when they go to the trash
+func (s *State) RejectFolder() string {
+	switch s.RejectTo {
+	case "":
+		return RejectsDir
+	case RejectToTrash:
+		return ""
+	default:
+		return s.RejectTo
+	}
+}
+
+// RejectPath returns the full path a rejected file is moved to when
+// rejects go to a folder
+func (s *State) RejectPath(filename string) string {
+	return filepath.Join(s.Directory, filepath.FromSlash(s.RejectFolder()), filepath.FromSlash(filename))
+}
+
+// ScanExcludes returns the exclude patterns for scanning the session,
+// keeping the rejects folder out of it
+func (s *State) ScanExcludes() []string {
+	folder := s.RejectFolder()
+	if folder == "" {
+		return s.Exclude
+	}
+	return append(slices.Clone(s.Exclude), filepath.ToSlash(folder))
+}
@@ -0,0 +1,137 @@
+// state/skipped_test.go
+package state
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestState_SkipRemovesClassification(t *testing.T) {
+	st, intro, _, _ := groupFixture()
+
+	st.Skip("b.mp4")
+	st.Skip("b.mp4")
+	if _, ok := st.GetClassification("b.mp4"); ok {
+		t.Error("expected a skipped file to be unclassified")
+	}
+	if !slices.Equal(st.Skipped, []string{"b.mp4"}) {
+		t.Errorf("expected b.mp4 to be skipped once, got %v", st.Skipped)
+	}
+	if c, _ := st.GetClassification("c.mp4"); c.TakeNumber != 2 {
+		t.Errorf("expected takes after b.mp4 to close the gap, got take %d", c.TakeNumber)
+	}
+
+	// Classifying takes the file out of skipped, along with its disposition
+	if err := st.SetDisposition("b.mp4", DispositionReject); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	st.AddOrUpdateClassification("b.mp4", intro.ID)
+	if st.IsSkipped("b.mp4") || st.Disposition("b.mp4") != DispositionKeep {
+		t.Error("expected classifying to unskip the file")
+	}
+
+	st.Skip("a.mp4")
+	st.AssignClassification("a.mp4", intro.ID, []string{"a.mp4", "b.mp4", "c.mp4"})
+	if st.IsSkipped("a.mp4") {
+		t.Error("expected assigning to unskip the file")
+	}
+}
+
+func TestState_SetDisposition(t *testing.T) {
+	st := NewState("/tmp/test", SortByName)
+	st.Skip("a.mp4")
+
+	if err := st.SetDisposition("b.mp4", DispositionDelete); err == nil {
+		t.Error("expected error for a file that isn't skipped")
+	}
+	if err := st.SetDisposition("a.mp4", "shred"); err == nil {
+		t.Error("expected error for an unknown disposition")
+	}
+
+	d := DispositionKeep
+	for _, expected := range []Disposition{DispositionReject, DispositionDelete, DispositionKeep} {
+		d = d.Next()
+		if d != expected {
+			t.Fatalf("expected %q, got %q", expected, d)
+		}
+		if err := st.SetDisposition("a.mp4", d); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if st.Disposition("a.mp4") != d {
+			t.Errorf("expected %q to be stored, got %q", d, st.Disposition("a.mp4"))
+		}
+	}
+	if len(st.Dispositions) != 0 {
+		t.Errorf("expected keep not to be stored, got %v", st.Dispositions)
+	}
+
+	if !st.Unskip("a.mp4") || st.Unskip("a.mp4") {
+		t.Error("expected Unskip to report whether the file was skipped")
+	}
+}
+
+func TestState_SkippedWith(t *testing.T) {
+	st := NewState("/tmp/test", SortByName)
+	for _, f := range []string{"a.mp4", "b.mp4", "c.mp4"} {
+		st.Skip(f)
+	}
+	st.SetDisposition("c.mp4", DispositionReject)
+	st.SetDisposition("b.mp4", DispositionDelete)
+
+	if got := st.SkippedWith(DispositionReject); !slices.Equal(got, []string{"c.mp4"}) {
+		t.Errorf("unexpected rejects %v", got)
+	}
+	if got := st.SkippedWith(DispositionKeep); !slices.Equal(got, []string{"a.mp4"}) {
+		t.Errorf("unexpected kept files %v", got)
+	}
+
+	expected := filepath.Join("/tmp/test", RejectsDir, "day1", "c.mp4")
+	if got := st.RejectPath("day1/c.mp4"); got != expected {
+		t.Errorf("expected %s, got %s", expected, got)
+	}
+}
+
+func TestUndo_Disposition(t *testing.T) {
+	st := NewState("/tmp/test", SortByName)
+	st.Skip("a.mp4")
+
+	before := st.Checkpoint()
+	st.SetDisposition("a.mp4", DispositionReject)
+	if !st.Record("reject a.mp4", before) {
+		t.Fatal("expected a disposition change to be recorded")
+	}
+
+	st.Undo()
+	if st.Disposition("a.mp4") != DispositionKeep {
+		t.Errorf("expected undo to restore the disposition, got %q", st.Disposition("a.mp4"))
+	}
+	st.Redo()
+	if st.Disposition("a.mp4") != DispositionReject {
+		t.Errorf("expected redo to reapply the disposition, got %q", st.Disposition("a.mp4"))
+	}
+}
+
+func TestState_RejectFolder(t *testing.T) {
+	st := NewState("/tmp/test", SortByName)
+	st.Exclude = []string{"THMBNL"}
+	if st.RejectFolder() != RejectsDir || !slices.Equal(st.ScanExcludes(), []string{"THMBNL", RejectsDir}) {
+		t.Errorf("expected the default folder to be excluded, got %v", st.ScanExcludes())
+	}
+
+	st.RejectTo = "out/_rejected"
+	if got := st.RejectPath("c.mp4"); got != filepath.Join("/tmp/test", "out", "_rejected", "c.mp4") {
+		t.Errorf("unexpected reject path %s", got)
+	}
+	if got := st.ScanExcludes(); !slices.Equal(got, []string{"THMBNL", "out/_rejected"}) {
+		t.Errorf("expected the custom folder to be excluded, got %v", got)
+	}
+	if len(st.Exclude) != 1 {
+		t.Errorf("expected the session's excludes to be unchanged, got %v", st.Exclude)
+	}
+
+	st.RejectTo = RejectToTrash
+	if st.RejectFolder() != "" {
+		t.Errorf("expected no folder for the trash, got %s", st.RejectFolder())
+	}
+}
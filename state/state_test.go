@@ -2,9 +2,12 @@
 package state
 
 import (
+	"fmt"
 	"os"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestState_NextTakeNumber(t *testing.T) {
@@ -199,6 +202,97 @@ func TestState_AddOrUpdateClassification(t *testing.T) {
 	})
 }
 
+// takes returns each file's group name and take for comparison
+func takes(st *State) map[string]string {
+	result := make(map[string]string)
+	for _, c := range st.Classifications {
+		result[c.File] = fmt.Sprintf("%s/%d", st.FindGroupByID(c.GroupID).Name, c.TakeNumber)
+	}
+	return result
+}
+
+func TestState_AssignClassification(t *testing.T) {
+	order := []string{"a.mp4", "b.mp4", "c.mp4", "d.mp4", "e.mp4"}
+	setup := func() (*State, Group, Group) {
+		st := NewState("/tmp/test", SortByName)
+		intro := NewGroup("intro", 1)
+		outro := NewGroup("outro", 2)
+		st.Groups = []Group{intro, outro}
+		st.AssignClassification("a.mp4", intro.ID, order)
+		st.AssignClassification("b.mp4", intro.ID, order)
+		st.AssignClassification("c.mp4", intro.ID, order)
+		st.AssignClassification("e.mp4", outro.ID, order)
+		return st, intro, outro
+	}
+
+	t.Run("appends in order", func(t *testing.T) {
+		st, _, _ := setup()
+		expected := map[string]string{"a.mp4": "intro/1", "b.mp4": "intro/2", "c.mp4": "intro/3", "e.mp4": "outro/1"}
+		if got := takes(st); !reflect.DeepEqual(got, expected) {
+			t.Errorf("expected %v, got %v", expected, got)
+		}
+	})
+
+	t.Run("moving closes the gap and inserts by order", func(t *testing.T) {
+		st, _, outro := setup()
+		st.Classifications[1].Original = "CAM_B.MP4"
+
+		st.AssignClassification("b.mp4", outro.ID, order)
+
+		expected := map[string]string{"a.mp4": "intro/1", "c.mp4": "intro/2", "b.mp4": "outro/1", "e.mp4": "outro/2"}
+		if got := takes(st); !reflect.DeepEqual(got, expected) {
+			t.Errorf("expected %v, got %v", expected, got)
+		}
+		if c, _ := st.GetClassification("b.mp4"); c.Original != "CAM_B.MP4" {
+			t.Errorf("expected original name to be kept, got %q", c.Original)
+		}
+	})
+
+	t.Run("reassigning to the same group keeps the take", func(t *testing.T) {
+		st, intro, _ := setup()
+		before := takes(st)
+
+		st.AssignClassification("b.mp4", intro.ID, order)
+
+		if got := takes(st); !reflect.DeepEqual(got, before) {
+			t.Errorf("expected %v, got %v", before, got)
+		}
+	})
+
+	t.Run("earlier file is inserted before later takes", func(t *testing.T) {
+		st, _, outro := setup()
+
+		st.AssignClassification("d.mp4", outro.ID, order)
+		st.AssignClassification("a.mp4", outro.ID, order)
+
+		expected := map[string]string{"b.mp4": "intro/1", "c.mp4": "intro/2", "a.mp4": "outro/1", "d.mp4": "outro/2", "e.mp4": "outro/3"}
+		if got := takes(st); !reflect.DeepEqual(got, expected) {
+			t.Errorf("expected %v, got %v", expected, got)
+		}
+	})
+}
+
+func TestState_RemoveClassification(t *testing.T) {
+	st := NewState("/tmp/test", SortByName)
+	intro := NewGroup("intro", 1)
+	st.Groups = []Group{intro}
+	st.AddOrUpdateClassification("a.mp4", intro.ID)
+	st.AddOrUpdateClassification("b.mp4", intro.ID)
+	st.AddOrUpdateClassification("c.mp4", intro.ID)
+
+	if !st.RemoveClassification("a.mp4") {
+		t.Fatal("expected a.mp4 to be removed")
+	}
+	expected := map[string]string{"b.mp4": "intro/1", "c.mp4": "intro/2"}
+	if got := takes(st); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+
+	if st.RemoveClassification("a.mp4") {
+		t.Error("expected false for a file that isn't classified")
+	}
+}
+
 func TestNewState(t *testing.T) {
 	directory := "/test/dir"
 	sortBy := SortByModifiedTime
@@ -402,7 +496,6 @@ func TestBackupState(t *testing.T) {
 	t.Run("creates backup successfully", func(t *testing.T) {
 		tmpDir := t.TempDir()
 		statePath := StateFilePath(tmpDir)
-		backupPath := statePath + ".bak"
 
 		// Create a state file
 		original := NewState(tmpDir, SortByModifiedTime)
@@ -421,7 +514,11 @@ func TestBackupState(t *testing.T) {
 		}
 
 		// Verify backup file exists
-		if _, err := os.Stat(backupPath); os.IsNotExist(err) {
+		backups := Backups(tmpDir)
+		if len(backups) != 1 {
+			t.Fatalf("expected 1 backup, got %v", backups)
+		}
+		if _, err := os.Stat(backups[0]); os.IsNotExist(err) {
 			t.Fatal("expected backup file to exist")
 		}
 	})
@@ -429,7 +526,6 @@ func TestBackupState(t *testing.T) {
 	t.Run("backup content matches original", func(t *testing.T) {
 		tmpDir := t.TempDir()
 		statePath := StateFilePath(tmpDir)
-		backupPath := statePath + ".bak"
 
 		// Create a state file with specific content
 		original := NewState(tmpDir, SortByModifiedTime)
@@ -459,6 +555,7 @@ func TestBackupState(t *testing.T) {
 			t.Fatalf("failed to read original file: %v", err)
 		}
 
+		backupPath := Backups(tmpDir)[0]
 		backupContent, err := os.ReadFile(backupPath)
 		if err != nil {
 			t.Fatalf("failed to read backup file: %v", err)
@@ -486,3 +583,152 @@ func TestBackupState(t *testing.T) {
 		}
 	})
 }
+
+func TestBackupState_KeepsGenerations(t *testing.T) {
+	tmpDir := t.TempDir()
+	statePath := StateFilePath(tmpDir)
+	st := NewState(tmpDir, SortByName)
+
+	for i := 0; i < MaxBackups+3; i++ {
+		st.CurrentIndex = i
+		if err := st.Save(statePath); err != nil {
+			t.Fatal(err)
+		}
+		if err := BackupState(tmpDir); err != nil {
+			t.Fatalf("backup %d: %v", i, err)
+		}
+	}
+
+	backups := Backups(tmpDir)
+	if len(backups) != MaxBackups {
+		t.Fatalf("expected %d backups, got %d", MaxBackups, len(backups))
+	}
+	newest, err := Load(backups[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if newest.CurrentIndex != MaxBackups+2 {
+		t.Errorf("expected newest backup first, got index %d", newest.CurrentIndex)
+	}
+}
+
+func TestState_Save_Atomic(t *testing.T) {
+	tmpDir := t.TempDir()
+	statePath := StateFilePath(tmpDir)
+
+	st := NewState(tmpDir, SortByName)
+	for i := 0; i < 3; i++ {
+		if err := st.Save(statePath); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	entries, err := os.ReadDir(tmpDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name() != StateFileName {
+		t.Errorf("expected only the state file, got %v", entries)
+	}
+}
+
+func TestLoad_RestoresNewestValidBackup(t *testing.T) {
+	tmpDir := t.TempDir()
+	statePath := StateFilePath(tmpDir)
+
+	st := NewState(tmpDir, SortByName)
+	st.CurrentIndex = 7
+	if err := st.Save(statePath); err != nil {
+		t.Fatal(err)
+	}
+	if err := BackupState(tmpDir); err != nil {
+		t.Fatal(err)
+	}
+
+	// A newer backup that is itself damaged is passed over
+	damaged := backupFilePath(statePath, time.Now().Add(time.Hour))
+	if err := os.WriteFile(damaged, []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(statePath, []byte(`{"directory": "`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := Load(statePath)
+	if err != nil {
+		t.Fatalf("expected backup to be restored, got %v", err)
+	}
+	if loaded.CurrentIndex != 7 {
+		t.Errorf("expected restored index 7, got %d", loaded.CurrentIndex)
+	}
+	if loaded.RestoredFrom == "" || loaded.RestoredFrom == damaged {
+		t.Errorf("unexpected backup restored: %q", loaded.RestoredFrom)
+	}
+
+	// The state file is readable again and the damaged copy kept
+	if _, err := Load(statePath); err != nil {
+		t.Errorf("expected restored state file to load, got %v", err)
+	}
+	if _, err := os.Stat(statePath + ".corrupt"); err != nil {
+		t.Errorf("expected corrupt copy to be kept: %v", err)
+	}
+}
+
+func TestLoad_CorruptWithoutBackup(t *testing.T) {
+	tmpDir := t.TempDir()
+	statePath := StateFilePath(tmpDir)
+	if err := os.WriteFile(statePath, []byte("not json"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Load(statePath); err == nil || !strings.Contains(err.Error(), "unmarshal state") {
+		t.Errorf("expected unmarshal error, got %v", err)
+	}
+}
+
+func TestState_RepairRenamedFiles_Subdirectories(t *testing.T) {
+	state := &State{
+		Groups: []Group{
+			{ID: "g1", Name: "intro", Order: 1},
+		},
+		Classifications: []Classification{
+			{File: "DCIM/100CANON/MVI_0001.MOV", GroupID: "g1", TakeNumber: 1},
+			{File: "PRIVATE/CLIP/C0001.MP4", GroupID: "g1", TakeNumber: 2},
+		},
+	}
+
+	scanned := []string{
+		"DCIM/100CANON/[01_01] intro.MOV", // preserve layout
+		"[01_02] intro.MP4",               // flatten layout
+	}
+
+	repaired := state.RepairRenamedFiles(scanned)
+	if repaired != 2 {
+		t.Fatalf("expected 2 repaired files, got %d", repaired)
+	}
+	if state.Classifications[0].File != "DCIM/100CANON/[01_01] intro.MOV" {
+		t.Errorf("unexpected repaired file: %s", state.Classifications[0].File)
+	}
+	if state.Classifications[1].File != "[01_02] intro.MP4" {
+		t.Errorf("unexpected repaired file: %s", state.Classifications[1].File)
+	}
+}
+
+func TestState_RenameFiles(t *testing.T) {
+	st := NewState("/tmp/test", SortByName)
+	st.Classifications = []Classification{
+		{File: "a.mp4", GroupID: "g1", TakeNumber: 1},
+		{File: "b.mp4", GroupID: "g1", TakeNumber: 2},
+		{File: "c.mp4", GroupID: "g1", TakeNumber: 3},
+	}
+
+	// Swap a and b, leave c alone
+	st.RenameFiles(map[string]string{"a.mp4": "b.mp4", "b.mp4": "a.mp4"})
+
+	expected := []string{"b.mp4", "a.mp4", "c.mp4"}
+	for i, c := range st.Classifications {
+		if c.File != expected[i] {
+			t.Errorf("classification %d: expected %s, got %s", i, expected[i], c.File)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+// state/suggest.go
+package state
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// DefaultSuggestGap is the recording gap that suggests a new group when the
+// session doesn't set one. Takes of one shot are usually seconds to minutes
+// apart; moving to a new setup takes longer.
+const DefaultSuggestGap = 2 * time.Minute
+
+// Gap returns the session's suggestion gap, falling back to the default
+func (s *State) Gap() time.Duration {
+	if s.SuggestGap > 0 {
+		return s.SuggestGap
+	}
+	return DefaultSuggestGap
+}
+
+// StartsNewSetup reports whether the file at index was recorded more than
+// the suggestion gap after the file before it. The first file always
+// starts a new setup.
+func (s *State) StartsNewSetup(files []string, index int) bool {
+	if index <= 0 || index >= len(files) {
+		return true
+	}
+	return s.RecordingGap(files[index-1], files[index]) > s.Gap()
+}
+
+// ClusterByGap splits files, in session order, into runs recorded with no
+// gap longer than the suggestion gap between them
+func (s *State) ClusterByGap(files []string) [][]string {
+	var clusters [][]string
+	for i, f := range files {
+		if s.StartsNewSetup(files, i) {
+			clusters = append(clusters, nil)
+		}
+		clusters[len(clusters)-1] = append(clusters[len(clusters)-1], f)
+	}
+	return clusters
+}
+
+// PrefillGroups classifies every unclassified, unskipped file by time gap.
+// A file joins the group of the classified file before it in its cluster;
+// the rest of each cluster goes into a new top-level group named after its
+// recording time, added after the existing groups. Returns the groups
+// created, stopping at the first group that can't be added.
+func (s *State) PrefillGroups(files []string) ([]Group, error) {
+	var created []Group
+	for _, cluster := range s.ClusterByGap(files) {
+		groupID := ""
+		for _, f := range cluster {
+			if c, ok := s.GetClassification(f); ok {
+				groupID = c.GroupID
+				continue
+			}
+			if slices.Contains(s.Skipped, f) {
+				continue
+			}
+			if groupID == "" {
+				group := NewGroup(s.setupName(f, len(created)+1), len(s.Children(""))+1)
+				if err := s.InsertGroup(group); err != nil {
+					return created, fmt.Errorf("add group %s: %w", group.Name, err)
+				}
+				created = append(created, group)
+				groupID = group.ID
+			}
+			s.AssignClassification(f, groupID, files)
+		}
+	}
+	return created, nil
+}
+
+// setupName names a suggested group after the time its first clip was
+// recorded, or by number if the time is unknown
+func (s *State) setupName(file string, n int) string {
+	if recorded := s.recordedTime(file); !recorded.IsZero() {
+		return "setup " + recorded.Format("15:04")
+	}
+	return fmt.Sprintf("setup %d", n)
+}
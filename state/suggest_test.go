@@ -0,0 +1,76 @@
+// state/suggest_test.go
+package state
+
+import (
+	"clip-tagger/metadata"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// suggestFixture returns clips shot as two takes at 10:00, a third 30s
+// later, then two more after a break at 10:20
+func suggestFixture(t *testing.T) (*State, []string) {
+	st := NewState(t.TempDir(), SortByRecorded)
+	start := time.Date(2026, 1, 12, 10, 0, 0, 0, time.UTC)
+	offsets := []time.Duration{0, 40 * time.Second, 90 * time.Second, 20 * time.Minute, 21 * time.Minute}
+	var files []string
+	for i, offset := range offsets {
+		file := string(rune('a'+i)) + ".mp4"
+		st.CacheMetadata(file, 1, start, metadata.Metadata{RecordedTime: start.Add(offset), Duration: 20 * time.Second})
+		files = append(files, file)
+	}
+	return st, files
+}
+
+func TestState_ClusterByGap(t *testing.T) {
+	st, files := suggestFixture(t)
+
+	expected := [][]string{{"a.mp4", "b.mp4", "c.mp4"}, {"d.mp4", "e.mp4"}}
+	if clusters := st.ClusterByGap(files); !reflect.DeepEqual(clusters, expected) {
+		t.Errorf("expected %v, got %v", expected, clusters)
+	}
+	if !st.StartsNewSetup(files, 0) || st.StartsNewSetup(files, 1) || !st.StartsNewSetup(files, 3) {
+		t.Error("unexpected setup boundaries")
+	}
+
+	// The threshold is adjustable
+	st.SuggestGap = 35 * time.Second
+	expected = [][]string{{"a.mp4", "b.mp4", "c.mp4"}, {"d.mp4"}, {"e.mp4"}}
+	if clusters := st.ClusterByGap(files); !reflect.DeepEqual(clusters, expected) {
+		t.Errorf("expected %v, got %v", expected, clusters)
+	}
+}
+
+func TestState_PrefillGroups(t *testing.T) {
+	st, files := suggestFixture(t)
+	intro := NewGroup("intro", 1)
+	st.Groups = []Group{intro}
+	st.AddOrUpdateClassification("a.mp4", intro.ID)
+	st.Skipped = []string{"c.mp4"}
+
+	created, err := st.PrefillGroups(files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(created) != 1 || created[0].Name != "setup 10:20" || created[0].Order != 2 {
+		t.Fatalf("expected one new group after intro, got %+v", created)
+	}
+
+	// b.mp4 follows a.mp4 into intro; the skipped clip is left alone
+	if c, _ := st.GetClassification("b.mp4"); c.GroupID != intro.ID || c.TakeNumber != 2 {
+		t.Errorf("expected b.mp4 as take 2 of intro, got %+v", c)
+	}
+	if _, ok := st.GetClassification("c.mp4"); ok {
+		t.Error("expected the skipped clip to stay unclassified")
+	}
+	for i, f := range []string{"d.mp4", "e.mp4"} {
+		if c, _ := st.GetClassification(f); c.GroupID != created[0].ID || c.TakeNumber != i+1 {
+			t.Errorf("expected %s as take %d of the new group, got %+v", f, i+1, c)
+		}
+	}
+
+	if created, err := st.PrefillGroups(files); err != nil || len(created) != 0 {
+		t.Errorf("expected nothing left to group, got %+v (%v)", created, err)
+	}
+}
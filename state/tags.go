@@ -0,0 +1,57 @@
+// state/tags.go
+package state
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// SetTags replaces the tags of a classified file. Tags are trimmed, empty
+// ones dropped and duplicates (ignoring case) removed; the rest are kept
+// sorted.
+func (s *State) SetTags(filename string, tags []string) error {
+	for i := range s.Classifications {
+		if s.Classifications[i].File == filename {
+			s.Classifications[i].Tags = normalizeTags(tags)
+			return nil
+		}
+	}
+	return fmt.Errorf("file is not classified: %s", filename)
+}
+
+// AllTags returns every tag used in the session, sorted
+func (s *State) AllTags() []string {
+	var tags []string
+	for _, c := range s.Classifications {
+		tags = append(tags, c.Tags...)
+	}
+	return normalizeTags(tags)
+}
+
+// HasTag reports whether a file is classified with a tag, ignoring case
+func (s *State) HasTag(filename, tag string) bool {
+	c, ok := s.GetClassification(filename)
+	return ok && slices.ContainsFunc(c.Tags, func(t string) bool { return strings.EqualFold(t, tag) })
+}
+
+// normalizeTags trims, deduplicates and sorts tags, or returns nil if none
+// are left
+func normalizeTags(tags []string) []string {
+	var result []string
+	seen := make(map[string]bool)
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		key := strings.ToLower(tag)
+		if tag == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		result = append(result, tag)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return strings.ToLower(result[i]) < strings.ToLower(result[j])
+	})
+	return result
+}
@@ -0,0 +1,83 @@
+// state/tags_test.go
+package state
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestState_SetTags(t *testing.T) {
+	st, intro, middle, _ := groupFixture()
+
+	if err := st.SetTags("b.mp4", []string{" drone", "b-roll", "", "B-Roll", "talent: Alex "}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	c, _ := st.GetClassification("b.mp4")
+	expected := []string{"b-roll", "drone", "talent: Alex"}
+	if !reflect.DeepEqual(c.Tags, expected) {
+		t.Errorf("expected %v, got %v", expected, c.Tags)
+	}
+
+	if err := st.SetTags("missing.mp4", []string{"drone"}); err == nil {
+		t.Error("expected error for an unclassified file")
+	}
+
+	// Tags belong to the clip, so they follow it to another group
+	st.AssignClassification("b.mp4", middle.ID, []string{"a.mp4", "b.mp4", "c.mp4", "d.mp4"})
+	st.AddOrUpdateClassification("b.mp4", intro.ID)
+	if c, _ := st.GetClassification("b.mp4"); !reflect.DeepEqual(c.Tags, expected) {
+		t.Errorf("expected tags to survive reclassifying, got %v", c.Tags)
+	}
+
+	if err := st.SetTags("b.mp4", nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if c, _ := st.GetClassification("b.mp4"); c.Tags != nil {
+		t.Errorf("expected tags to be cleared, got %v", c.Tags)
+	}
+}
+
+func TestState_AllTags(t *testing.T) {
+	st, _, _, _ := groupFixture()
+	st.SetTags("a.mp4", []string{"drone", "b-roll"})
+	st.SetTags("d.mp4", []string{"Drone", "needs audio fix"})
+
+	expected := []string{"b-roll", "drone", "needs audio fix"}
+	if tags := st.AllTags(); !reflect.DeepEqual(tags, expected) {
+		t.Errorf("expected %v, got %v", expected, tags)
+	}
+
+	if !st.HasTag("d.mp4", "drone") || !st.HasTag("a.mp4", "B-ROLL") {
+		t.Error("expected tags to match ignoring case")
+	}
+	if st.HasTag("c.mp4", "drone") || st.HasTag("missing.mp4", "drone") {
+		t.Error("expected untagged files not to match")
+	}
+}
+
+func TestState_TargetPath_Tags(t *testing.T) {
+	st := NewState("/card", SortByName)
+	st.NameTemplate = "[{seq}_{take}] {group} {tags}"
+	group := NewGroup("intro", 1)
+	st.Groups = []Group{group}
+	st.Classifications = []Classification{
+		{File: "C0001.MP4", GroupID: group.ID, TakeNumber: 1, Tags: []string{"b-roll", "wide/tight"}},
+	}
+
+	target, ok := st.TargetPath(st.Classifications[0], st.NameNumbers())
+	if !ok {
+		t.Fatal("expected target path")
+	}
+	expected := filepath.Join("/card", "[01_01] intro b-roll+wide-tight.MP4")
+	if target != expected {
+		t.Errorf("expected %s, got %s", expected, target)
+	}
+
+	data, _ := json.Marshal(Classification{File: "a.mp4"})
+	if strings.Contains(string(data), "tags") {
+		t.Errorf("expected empty tags to be omitted, got %s", data)
+	}
+}
@@ -2,8 +2,9 @@
 package state
 
 import (
-	"fmt"
-	"path/filepath"
+	"clip-tagger/renamer"
+	"path"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -15,35 +16,63 @@ const (
 	SortByModifiedTime SortBy = "modified_time"
 	SortByCreatedTime  SortBy = "created_time"
 	SortByName         SortBy = "name"
+	SortByRecorded     SortBy = "recorded"
+	SortByTimecode     SortBy = "timecode"
 )
 
 // State represents the complete session state
 type State struct {
-	Directory       string           `json:"directory"`
-	SortBy          SortBy           `json:"sort_by"`
-	CurrentIndex    int              `json:"current_index"`
-	Groups          []Group          `json:"groups"`
-	Classifications []Classification `json:"classifications"`
-	Skipped         []string         `json:"skipped"`
+	SchemaVersion   int                    `json:"schema_version"`
+	Directory       string                 `json:"directory"`
+	SortBy          SortBy                 `json:"sort_by"`
+	Recursive       bool                   `json:"recursive,omitempty"`
+	Include         []string               `json:"include,omitempty"`
+	Exclude         []string               `json:"exclude,omitempty"`
+	Layout          renamer.Layout         `json:"layout,omitempty"`
+	NameTemplate    string                 `json:"name_template,omitempty"`
+	SuggestGap      time.Duration          `json:"suggest_gap,omitempty"` // Recording gap that suggests a new group
+	RejectTo        string                 `json:"reject_to,omitempty"`   // Folder for rejected clips, or "trash"; "" for RejectsDir
+	CurrentIndex    int                    `json:"current_index"`
+	Groups          []Group                `json:"groups"`
+	Classifications []Classification       `json:"classifications"`
+	Skipped         []string               `json:"skipped"`
+	Dispositions    map[string]Disposition `json:"dispositions,omitempty"` // What happens to skipped files
+	Media           map[string]MediaInfo   `json:"media,omitempty"`
+	UndoStack       []Change               `json:"undo,omitempty"`
+	RedoStack       []Change               `json:"redo,omitempty"`
+
+	// RestoredFrom is the backup Load restored because the state file
+	// could not be read; empty otherwise
+	RestoredFrom string `json:"-"`
+
+	// Parsed NameTemplate, reused until the template changes
+	template       *renamer.Template
+	templateSource string
 }
 
-// Group represents a semantic group of clips
+// Group represents a semantic group of clips. Groups can be nested, e.g.
+// shots inside a scene.
 type Group struct {
-	ID    string `json:"id"`
-	Name  string `json:"name"`
-	Order int    `json:"order"`
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Order    int    `json:"order"`               // Position among groups with the same parent
+	ParentID string `json:"parent_id,omitempty"` // Enclosing group, "" at the top level
 }
 
 // Classification links a file to a group with take number
 type Classification struct {
-	File       string `json:"file"`
-	GroupID    string `json:"group_id"`
-	TakeNumber int    `json:"take_number"`
+	File       string   `json:"file"` // Path relative to the session root
+	GroupID    string   `json:"group_id"`
+	TakeNumber int      `json:"take_number"`
+	Original   string   `json:"original,omitempty"` // Camera filename before the first rename
+	Tags       []string `json:"tags,omitempty"`     // Free-form labels across groups, e.g. "b-roll"
+	Annotation
 }
 
 // NewState creates a new empty state
 func NewState(directory string, sortBy SortBy) *State {
 	return &State{
+		SchemaVersion:   SchemaVersion,
 		Directory:       directory,
 		SortBy:          sortBy,
 		CurrentIndex:    0,
@@ -53,7 +82,7 @@ func NewState(directory string, sortBy SortBy) *State {
 	}
 }
 
-// NewGroup creates a new group with generated UUID
+// NewGroup creates a new top-level group with generated UUID
 func NewGroup(name string, order int) Group {
 	return Group{
 		ID:    uuid.New().String(),
@@ -62,6 +91,13 @@ func NewGroup(name string, order int) Group {
 	}
 }
 
+// NewSubGroup creates a new group inside a parent group
+func NewSubGroup(name, parentID string, order int) Group {
+	group := NewGroup(name, order)
+	group.ParentID = parentID
+	return group
+}
+
 // FindGroupByID finds a group by its ID
 func (s *State) FindGroupByID(id string) *Group {
 	for i := range s.Groups {
@@ -94,28 +130,121 @@ func (s *State) GetClassification(filename string) (Classification, bool) {
 	return Classification{}, false
 }
 
-// AddOrUpdateClassification adds or updates a classification
+// AddOrUpdateClassification adds or updates a classification. A skipped
+// file is no longer skipped.
 func (s *State) AddOrUpdateClassification(filename, groupID string) {
-	// Remove existing classification if present by building new slice
-	newClassifications := make([]Classification, 0, len(s.Classifications))
-	for _, c := range s.Classifications {
-		if c.File != filename {
-			newClassifications = append(newClassifications, c)
-		}
+	s.Unskip(filename)
+
+	// Remove existing classification if present, closing the gap it leaves
+	// and keeping the camera filename and annotation
+	classification := Classification{File: filename}
+	if existing, ok := s.GetClassification(filename); ok {
+		classification = existing
+		s.RemoveClassification(filename)
 	}
-	s.Classifications = newClassifications
 
 	// Add new classification
+	classification.GroupID = groupID
+	classification.TakeNumber = s.NextTakeNumber(groupID)
+	s.Classifications = append(s.Classifications, classification)
+}
+
+// AssignClassification classifies a file, or moves an already classified
+// file to another group. The file takes the place in its new group given by
+// order (the session's file order): before the first take recorded after
+// it, or last. Takes after it are renumbered, and the gap it leaves in its
+// old group is closed. The camera filename and annotation are kept, and a
+// skipped file is no longer skipped.
+func (s *State) AssignClassification(filename, groupID string, order []string) {
+	s.Unskip(filename)
+
+	classification := Classification{File: filename}
+	if existing, ok := s.GetClassification(filename); ok {
+		classification = existing
+		s.RemoveClassification(filename)
+	}
+
+	position := make(map[string]int, len(order))
+	for i, f := range order {
+		position[f] = i
+	}
+	filePos, known := position[filename]
+
+	// Find the first take in the group that comes after the file
 	takeNum := s.NextTakeNumber(groupID)
-	s.Classifications = append(s.Classifications, Classification{
-		File:       filename,
-		GroupID:    groupID,
-		TakeNumber: takeNum,
-	})
+	if known {
+		for _, c := range s.Classifications {
+			if c.GroupID != groupID {
+				continue
+			}
+			if pos, ok := position[c.File]; ok && pos > filePos && c.TakeNumber < takeNum {
+				takeNum = c.TakeNumber
+			}
+		}
+	}
+
+	// Make room for it
+	for i := range s.Classifications {
+		c := &s.Classifications[i]
+		if c.GroupID == groupID && c.TakeNumber >= takeNum {
+			c.TakeNumber++
+		}
+	}
+
+	classification.GroupID = groupID
+	classification.TakeNumber = takeNum
+	s.Classifications = append(s.Classifications, classification)
+}
+
+// RemoveClassification unclassifies a file and closes the gap it leaves in
+// its group's take numbers. Returns false if the file wasn't classified.
+func (s *State) RemoveClassification(filename string) bool {
+	for i, c := range s.Classifications {
+		if c.File != filename {
+			continue
+		}
+		s.Classifications = append(s.Classifications[:i], s.Classifications[i+1:]...)
+		for j := range s.Classifications {
+			other := &s.Classifications[j]
+			if other.GroupID == c.GroupID && other.TakeNumber > c.TakeNumber {
+				other.TakeNumber--
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// RenameFiles points Classifications and cached metadata at new filenames.
+// The renames map old names to new names and is applied as one step, so
+// swaps and chains are handled correctly. The camera filename is kept in
+// Original until the file gets that name back.
+func (s *State) RenameFiles(renames map[string]string) {
+	for i := range s.Classifications {
+		s.Classifications[i].rename(renames)
+	}
+	s.RenameMedia(renames)
+	s.renameHistory(renames)
+}
+
+// rename moves a classification to its new filename, if it has one
+func (c *Classification) rename(renames map[string]string) {
+	newFile, exists := renames[c.File]
+	if !exists {
+		return
+	}
+	if c.Original == "" {
+		c.Original = c.File
+	}
+	c.File = newFile
+	if c.File == c.Original {
+		c.Original = ""
+	}
 }
 
 // RepairRenamedFiles attempts to fix Classifications that reference old filenames
-// by matching them to renamed files that follow the [XX_YY] Name pattern
+// by matching them to renamed files that follow the session's name template.
+// Numbers are matched at any padding width.
 func (s *State) RepairRenamedFiles(scannedFiles []string) int {
 	// Build map of scanned files for quick lookup
 	scannedMap := make(map[string]bool)
@@ -123,7 +252,16 @@ func (s *State) RepairRenamedFiles(scannedFiles []string) int {
 		scannedMap[f] = true
 	}
 
+	// Files already referenced by a classification can't be the renamed version of another
+	claimed := make(map[string]bool)
+	for _, c := range s.Classifications {
+		claimed[c.File] = true
+	}
+
+	tmpl := s.Template()
+	numbers := s.NameNumbers()
 	repairedCount := 0
+	repaired := make(map[string]string)
 
 	// Check each Classification
 	for i := range s.Classifications {
@@ -135,32 +273,59 @@ func (s *State) RepairRenamedFiles(scannedFiles []string) int {
 		}
 
 		// File is missing - try to find renamed version
-		group := s.FindGroupByID(classification.GroupID)
-		if group == nil {
+		fields, ok := s.NameFields(*classification, numbers)
+		if !ok {
 			continue
 		}
 
 		// Generate expected renamed filename
-		ext := filepath.Ext(classification.File)
-		groupNum := formatNumber(group.Order)
-		takeNum := formatNumber(classification.TakeNumber)
-		expectedName := fmt.Sprintf("[%s_%s] %s%s", groupNum, takeNum, group.Name, ext)
+		ext := path.Ext(classification.File)
+		expectedName := tmpl.Filename(fields, ext)
+
+		// Look for the renamed file either next to the original (preserve
+		// layout) or in the session root (flatten layout)
+		dirs := []string{"."}
+		if dir := path.Dir(classification.File); dir != "." {
+			dirs = []string{dir, "."}
+		}
+
+		found := ""
+		for _, dir := range dirs {
+			if candidate := path.Join(dir, expectedName); scannedMap[candidate] && !claimed[candidate] {
+				found = candidate
+				break
+			}
+		}
+		if found == "" {
+			// Fall back to parsing names, which accepts other padding widths
+			for _, dir := range dirs {
+				for _, f := range scannedFiles {
+					if claimed[f] || path.Dir(f) != dir || path.Ext(f) != ext {
+						continue
+					}
+					if tmpl.Matches(path.Base(f), fields) {
+						found = f
+						break
+					}
+				}
+				if found != "" {
+					break
+				}
+			}
+		}
 
-		// Check if expected renamed file exists
-		if scannedMap[expectedName] {
+		if found != "" {
 			// Update classification to use new filename
-			classification.File = expectedName
+			repaired[classification.File] = found
+			classification.rename(map[string]string{classification.File: found})
+			claimed[found] = true
 			repairedCount++
 		}
 	}
 
-	return repairedCount
-}
+	// Carry cached metadata over to the renamed files
+	s.RenameMedia(repaired)
+	s.renameHistory(repaired)
 
-// formatNumber formats a number with leading zero (01, 02, ..., 10, 11, ...)
-func formatNumber(n int) string {
-	if n < 10 {
-		return fmt.Sprintf("0%d", n)
-	}
-	return fmt.Sprintf("%d", n)
+	return repairedCount
 }
@@ -0,0 +1,196 @@
+// state/undo.go
+package state
+
+import (
+	"maps"
+	"reflect"
+	"slices"
+)
+
+// MaxUndo is the number of changes kept on the undo stack
+const MaxUndo = 100
+
+// Change restores part of the state to how it was before an edit. Only what
+// the edit touched is recorded, so long sessions stay small on disk.
+type Change struct {
+	Label           string                     `json:"label"`
+	Classifications map[string]*Classification `json:"classifications,omitempty"` // nil: the file was unclassified
+	Groups          *[]Group                   `json:"groups,omitempty"`          // nil: groups were unchanged
+	Skipped         *[]string                  `json:"skipped,omitempty"`         // nil: skipped files were unchanged
+	Dispositions    *map[string]Disposition    `json:"dispositions,omitempty"`    // nil: dispositions were unchanged
+	CurrentIndex    int                        `json:"current_index"`
+}
+
+// Checkpoint is a copy of the editable parts of the state, taken before an
+// edit and passed to Record afterwards
+type Checkpoint struct {
+	groups          []Group
+	classifications map[string]Classification
+	skipped         []string
+	dispositions    map[string]Disposition
+	currentIndex    int
+}
+
+// Checkpoint copies the state an edit may change
+func (s *State) Checkpoint() Checkpoint {
+	cp := Checkpoint{
+		groups:          slices.Clone(s.Groups),
+		classifications: make(map[string]Classification, len(s.Classifications)),
+		skipped:         slices.Clone(s.Skipped),
+		dispositions:    maps.Clone(s.Dispositions),
+		currentIndex:    s.CurrentIndex,
+	}
+	for _, c := range s.Classifications {
+		cp.classifications[c.File] = c
+	}
+	return cp
+}
+
+// Record pushes what changed since a checkpoint onto the undo stack and
+// clears the redo stack. Returns false if the edit changed nothing.
+func (s *State) Record(label string, before Checkpoint) bool {
+	change := Change{Label: label, CurrentIndex: before.currentIndex}
+
+	current := make(map[string]Classification, len(s.Classifications))
+	for _, c := range s.Classifications {
+		current[c.File] = c
+		if old, ok := before.classifications[c.File]; !ok || !reflect.DeepEqual(old, c) {
+			change.setClassification(c.File, old, ok)
+		}
+	}
+	for file, old := range before.classifications {
+		if _, ok := current[file]; !ok {
+			change.setClassification(file, old, true)
+		}
+	}
+
+	if !reflect.DeepEqual(before.groups, s.Groups) {
+		groups := before.groups
+		change.Groups = &groups
+	}
+	if !slices.Equal(before.skipped, s.Skipped) {
+		skipped := before.skipped
+		change.Skipped = &skipped
+	}
+	if !maps.Equal(before.dispositions, s.Dispositions) {
+		// Non-nil so an empty map survives the state file
+		dispositions := make(map[string]Disposition, len(before.dispositions))
+		maps.Copy(dispositions, before.dispositions)
+		change.Dispositions = &dispositions
+	}
+
+	if change.Classifications == nil && change.Groups == nil && change.Skipped == nil && change.Dispositions == nil {
+		return false
+	}
+	s.UndoStack = pushChange(s.UndoStack, change)
+	s.RedoStack = nil
+	return true
+}
+
+// Undo reverts the most recent change and returns its label
+func (s *State) Undo() (string, bool) {
+	if len(s.UndoStack) == 0 {
+		return "", false
+	}
+	change := s.UndoStack[len(s.UndoStack)-1]
+	s.UndoStack = s.UndoStack[:len(s.UndoStack)-1]
+	s.RedoStack = pushChange(s.RedoStack, s.apply(change))
+	return change.Label, true
+}
+
+// Redo reapplies the most recently undone change and returns its label
+func (s *State) Redo() (string, bool) {
+	if len(s.RedoStack) == 0 {
+		return "", false
+	}
+	change := s.RedoStack[len(s.RedoStack)-1]
+	s.RedoStack = s.RedoStack[:len(s.RedoStack)-1]
+	s.UndoStack = pushChange(s.UndoStack, s.apply(change))
+	return change.Label, true
+}
+
+// apply restores what a change recorded and returns the change that
+// reverses it
+func (s *State) apply(change Change) Change {
+	inverse := Change{Label: change.Label, CurrentIndex: s.CurrentIndex}
+
+	for file, restored := range change.Classifications {
+		existing, ok := s.GetClassification(file)
+		inverse.setClassification(file, existing, ok)
+
+		s.Classifications = slices.DeleteFunc(s.Classifications, func(c Classification) bool {
+			return c.File == file
+		})
+		if restored != nil {
+			s.Classifications = append(s.Classifications, *restored)
+		}
+	}
+	if change.Groups != nil {
+		groups := s.Groups
+		inverse.Groups = &groups
+		s.Groups = slices.Clone(*change.Groups)
+	}
+	if change.Skipped != nil {
+		skipped := s.Skipped
+		inverse.Skipped = &skipped
+		s.Skipped = slices.Clone(*change.Skipped)
+	}
+	if change.Dispositions != nil {
+		dispositions := make(map[string]Disposition, len(s.Dispositions))
+		maps.Copy(dispositions, s.Dispositions)
+		inverse.Dispositions = &dispositions
+		s.Dispositions = maps.Clone(*change.Dispositions)
+	}
+	s.CurrentIndex = change.CurrentIndex
+
+	return inverse
+}
+
+// renameHistory points recorded changes at renamed files, so undo keeps
+// working after a rename on disk
+func (s *State) renameHistory(renames map[string]string) {
+	for _, stack := range [][]Change{s.UndoStack, s.RedoStack} {
+		for i := range stack {
+			stack[i].rename(renames)
+		}
+	}
+}
+
+// setClassification records a file's classification before the change
+func (c *Change) setClassification(file string, old Classification, classified bool) {
+	if c.Classifications == nil {
+		c.Classifications = make(map[string]*Classification)
+	}
+	if classified {
+		c.Classifications[file] = &old
+	} else {
+		c.Classifications[file] = nil
+	}
+}
+
+// rename rewrites the files a change refers to
+func (c *Change) rename(renames map[string]string) {
+	if len(c.Classifications) == 0 {
+		return
+	}
+	renamed := make(map[string]*Classification, len(c.Classifications))
+	for file, classification := range c.Classifications {
+		if newName, ok := renames[file]; ok {
+			file = newName
+			if classification != nil {
+				classification.rename(renames)
+			}
+		}
+		renamed[file] = classification
+	}
+	c.Classifications = renamed
+}
+
+// pushChange appends a change, dropping the oldest beyond MaxUndo
+func pushChange(stack []Change, change Change) []Change {
+	stack = append(stack, change)
+	if len(stack) > MaxUndo {
+		stack = slices.Delete(stack, 0, len(stack)-MaxUndo)
+	}
+	return stack
+}
@@ -0,0 +1,140 @@
+// state/undo_test.go
+package state
+
+import (
+	"testing"
+)
+
+func TestState_UndoRedo(t *testing.T) {
+	st := NewState("/tmp/test", SortByName)
+	intro := NewGroup("intro", 1)
+	st.Groups = []Group{intro}
+	order := []string{"a.mp4", "b.mp4", "c.mp4"}
+
+	before := st.Checkpoint()
+	st.AssignClassification("a.mp4", intro.ID, order)
+	st.CurrentIndex = 1
+	st.Record("classify a.mp4", before)
+
+	before = st.Checkpoint()
+	outro := NewGroup("outro", 2)
+	st.Groups = append(st.Groups, outro)
+	st.AssignClassification("b.mp4", outro.ID, order)
+	st.CurrentIndex = 2
+	st.Record("create group outro", before)
+
+	label, ok := st.Undo()
+	if !ok || label != "create group outro" {
+		t.Fatalf("expected to undo group creation, got %q", label)
+	}
+	if len(st.Groups) != 1 || st.CurrentIndex != 1 {
+		t.Errorf("expected group and position restored, got %d groups at %d", len(st.Groups), st.CurrentIndex)
+	}
+	if _, ok := st.GetClassification("b.mp4"); ok {
+		t.Error("expected b.mp4 to be unclassified")
+	}
+
+	if label, ok := st.Redo(); !ok || label != "create group outro" {
+		t.Fatalf("expected to redo group creation, got %q", label)
+	}
+	if c, ok := st.GetClassification("b.mp4"); !ok || c.GroupID != outro.ID || st.CurrentIndex != 2 {
+		t.Errorf("expected b.mp4 back in outro, got %+v at %d", c, st.CurrentIndex)
+	}
+
+	// A new edit clears the redo stack
+	st.Undo()
+	before = st.Checkpoint()
+	st.Skipped = append(st.Skipped, "b.mp4")
+	st.Record("skip b.mp4", before)
+	if _, ok := st.Redo(); ok {
+		t.Error("expected redo stack to be cleared by a new edit")
+	}
+
+	st.Undo()
+	st.Undo()
+	if len(st.Classifications) != 0 || len(st.Skipped) != 0 {
+		t.Errorf("expected empty state after undoing everything, got %+v", st)
+	}
+	if _, ok := st.Undo(); ok {
+		t.Error("expected nothing left to undo")
+	}
+}
+
+func TestState_Record_NoChange(t *testing.T) {
+	st := NewState("/tmp/test", SortByName)
+	before := st.Checkpoint()
+	st.CurrentIndex = 5
+
+	if st.Record("navigate", before) {
+		t.Error("expected a change of position alone not to be recorded")
+	}
+	if len(st.UndoStack) != 0 {
+		t.Errorf("expected empty undo stack, got %d", len(st.UndoStack))
+	}
+}
+
+func TestState_Record_Limit(t *testing.T) {
+	st := NewState("/tmp/test", SortByName)
+	for i := 0; i < MaxUndo+10; i++ {
+		before := st.Checkpoint()
+		st.Skipped = append(st.Skipped, "x.mp4")
+		st.Record("skip", before)
+	}
+	if len(st.UndoStack) != MaxUndo {
+		t.Errorf("expected %d changes kept, got %d", MaxUndo, len(st.UndoStack))
+	}
+}
+
+func TestState_Undo_Persisted(t *testing.T) {
+	dir := t.TempDir()
+	st := NewState(dir, SortByName)
+	intro := NewGroup("intro", 1)
+	st.Groups = []Group{intro}
+
+	before := st.Checkpoint()
+	st.AddOrUpdateClassification("a.mp4", intro.ID)
+	st.Record("classify a.mp4", before)
+
+	if err := st.Save(StateFilePath(dir)); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := Load(StateFilePath(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if label, ok := loaded.Undo(); !ok || label != "classify a.mp4" {
+		t.Fatalf("expected undo after reload, got %q", label)
+	}
+	if len(loaded.Classifications) != 0 {
+		t.Errorf("expected classification undone, got %+v", loaded.Classifications)
+	}
+}
+
+func TestState_Undo_AfterRename(t *testing.T) {
+	st := NewState("/tmp/test", SortByName)
+	intro := NewGroup("intro", 1)
+	st.Groups = []Group{intro}
+
+	before := st.Checkpoint()
+	st.AddOrUpdateClassification("C0001.MP4", intro.ID)
+	st.Record("classify C0001.MP4", before)
+
+	before = st.Checkpoint()
+	st.RemoveClassification("C0001.MP4")
+	st.Record("unclassify C0001.MP4", before)
+	st.Undo()
+
+	st.RenameFiles(map[string]string{"C0001.MP4": "[01_01] intro.MP4"})
+
+	// Redo and undo refer to the file's new name
+	st.Redo()
+	if len(st.Classifications) != 0 {
+		t.Fatalf("expected redo to unclassify the renamed file, got %+v", st.Classifications)
+	}
+	st.Undo()
+	c, ok := st.GetClassification("[01_01] intro.MP4")
+	if !ok || c.Original != "C0001.MP4" {
+		t.Errorf("expected renamed classification restored, got %+v", c)
+	}
+}
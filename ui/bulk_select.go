@@ -0,0 +1,232 @@
+// ui/bulk_select.go
+package ui
+
+import (
+	"clip-tagger/state"
+	"fmt"
+	"time"
+)
+
+// BulkFile is one clip in the multi-select list
+type BulkFile struct {
+	File       string
+	GroupName  string        // Full group name, "" if unclassified
+	TakeNumber int           // Take, if classified
+	Gap        time.Duration // Recording gap since the previous clip
+}
+
+// BulkSelectData contains the data needed to render the multi-select screen
+type BulkSelectData struct {
+	Files          []BulkFile
+	Selected       map[int]bool
+	Cursor         int
+	Anchor         int           // Where a shift range starts
+	RunGap         time.Duration // A gap longer than this ends a run
+	ScrollOffset   int
+	ViewportHeight int
+	Width          int    // Terminal width, 0 if unknown
+	Keys           Keymap // Active key bindings for the hints, nil for the defaults
+}
+
+// BulkSelectUpdateResult contains the result of a multi-select update
+type BulkSelectUpdateResult struct {
+	Screen Screen   // -1 for quit, -2 for no screen change, >= 0 for screen transition
+	Files  []string // Selected files in file order, when choosing a group
+}
+
+// NewBulkSelectData creates multi-select data for the session's files,
+// starting with the current file selected
+func NewBulkSelectData(appState *state.State, files []string, currentIndex int) *BulkSelectData {
+	data := &BulkSelectData{
+		Files:          make([]BulkFile, len(files)),
+		Selected:       make(map[int]bool),
+		RunGap:         appState.Gap(),
+		ViewportHeight: 15,
+	}
+	for i, f := range files {
+		data.Files[i].File = f
+		if c, ok := appState.GetClassification(f); ok {
+			data.Files[i].GroupName = appState.GroupFullName(c.GroupID)
+			data.Files[i].TakeNumber = c.TakeNumber
+		}
+		if i > 0 {
+			data.Files[i].Gap = appState.RecordingGap(files[i-1], f)
+		}
+	}
+
+	if currentIndex >= 0 && currentIndex < len(files) {
+		data.Cursor = currentIndex
+		data.Anchor = currentIndex
+		data.Selected[currentIndex] = true
+		data.scrollToCursor()
+	}
+	return data
+}
+
+// SelectedFiles returns the selected files in file order
+func (data *BulkSelectData) SelectedFiles() []string {
+	var files []string
+	for i, f := range data.Files {
+		if data.Selected[i] {
+			files = append(files, f.File)
+		}
+	}
+	return files
+}
+
+// runEnd returns the index of the last clip in the run that starts at
+// start: the clips recorded with no gap longer than RunGap between them
+func (data *BulkSelectData) runEnd(start int) int {
+	end := start
+	for end+1 < len(data.Files) && data.Files[end+1].Gap <= data.RunGap {
+		end++
+	}
+	return end
+}
+
+// selectRange selects every clip between two indexes, inclusive
+func (data *BulkSelectData) selectRange(from, to int) {
+	if from > to {
+		from, to = to, from
+	}
+	for i := from; i <= to; i++ {
+		data.Selected[i] = true
+	}
+}
+
+// scrollToCursor keeps the cursor inside the viewport
+func (data *BulkSelectData) scrollToCursor() {
+	if data.Cursor < data.ScrollOffset {
+		data.ScrollOffset = data.Cursor
+	}
+	if data.Cursor >= data.ScrollOffset+data.ViewportHeight {
+		data.ScrollOffset = data.Cursor - data.ViewportHeight + 1
+	}
+}
+
+// BulkSelectView renders the multi-select screen
+func BulkSelectView(data *BulkSelectData) string {
+	output := bulkSelectHeader(data)
+
+	startIdx := data.ScrollOffset
+	endIdx := min(data.ScrollOffset+data.ViewportHeight, len(data.Files))
+
+	if data.ScrollOffset > 0 {
+		output += RenderMuted("  ... (more items above)") + "\n"
+	}
+
+	for i := startIdx; i < endIdx; i++ {
+		f := data.Files[i]
+		if i > startIdx && f.Gap > data.RunGap {
+			output += RenderMuted(fmt.Sprintf("    ── %s gap ──", f.Gap.Round(time.Second))) + "\n"
+		}
+
+		box := "[ ]"
+		if data.Selected[i] {
+			box = RenderSuccess("[x]")
+		}
+		status := RenderMuted("unclassified")
+		if f.GroupName != "" {
+			status = RenderMuted(fmt.Sprintf("%s, take %d", f.GroupName, f.TakeNumber))
+		}
+
+		name := truncateMiddle(f.File, nameWidth(data.Width, 8+len(f.GroupName)+12))
+		if i == data.Cursor {
+			output += fmt.Sprintf("%s %s %s  %s\n", RenderCursor(">"), box, RenderHighlight(name), status)
+		} else {
+			output += fmt.Sprintf("  %s %s  %s\n", box, name, status)
+		}
+	}
+
+	if endIdx < len(data.Files) {
+		output += RenderMuted("  ... (more items below)") + "\n"
+	}
+
+	return output + bulkSelectFooter(data)
+}
+
+// bulkSelectHeader renders the selection count above the clip list
+func bulkSelectHeader(data *BulkSelectData) string {
+	return RenderHeader(fmt.Sprintf("=== Multi-Select: %d of %d clips ===", len(data.SelectedFiles()), len(data.Files))) + "\n\n"
+}
+
+// bulkSelectFooter renders the instructions below the clip list
+func bulkSelectFooter(data *BulkSelectData) string {
+	output := "\n"
+	key := data.Keys.hints(KeysMultiSelect)
+	output += RenderMuted("Instructions:") + "\n"
+	output += RenderKeyHint("  "+key("toggle")+" - Select / deselect clip") + "\n"
+	output += RenderKeyHint(fmt.Sprintf("  %s / %s - Extend selection", key("extend_up"), key("extend_down"))) + "\n"
+	output += RenderKeyHint(fmt.Sprintf("  %s - Select until the next gap over %s (%s / %s to change)",
+		key("select_run"), data.RunGap, key("shorter_gap"), key("longer_gap"))) + "\n"
+	output += RenderKeyHint("  "+key("clear")+" - Clear selection") + "\n"
+	output += RenderKeyHint(fmt.Sprintf("  %s - Assign to an existing group, %s - New group", key("choose_group"), key("new_group"))) + "\n"
+	output += RenderKeyHint("  "+key("back")+" to cancel") + "\n"
+
+	return output
+}
+
+// BulkSelectUpdate handles input for the multi-select screen
+func BulkSelectUpdate(data *BulkSelectData, msg string) BulkSelectUpdateResult {
+	switch msg {
+	case "up", "down", "shift+up", "shift+down", "K", "J":
+		delta := 1
+		if msg == "up" || msg == "shift+up" || msg == "K" {
+			delta = -1
+		}
+		cursor := data.Cursor + delta
+		if cursor < 0 || cursor >= len(data.Files) {
+			return BulkSelectUpdateResult{Screen: -2}
+		}
+		data.Cursor = cursor
+		if msg == "up" || msg == "down" {
+			data.Anchor = cursor
+		} else {
+			data.selectRange(data.Anchor, cursor)
+		}
+		data.scrollToCursor()
+
+	case " ":
+		if len(data.Files) > 0 {
+			data.Selected[data.Cursor] = !data.Selected[data.Cursor]
+			data.Anchor = data.Cursor
+		}
+
+	case "r":
+		if len(data.Files) > 0 {
+			end := data.runEnd(data.Cursor)
+			data.selectRange(data.Cursor, end)
+			data.Cursor = end
+			data.Anchor = end
+			data.scrollToCursor()
+		}
+
+	case "[":
+		data.RunGap = max(data.RunGap/2, time.Second)
+
+	case "]":
+		data.RunGap *= 2
+
+	case "c":
+		clear(data.Selected)
+
+	case "enter", "2", "3":
+		files := data.SelectedFiles()
+		if len(files) == 0 {
+			return BulkSelectUpdateResult{Screen: -2}
+		}
+		screen := ScreenGroupSelection
+		if msg == "3" {
+			screen = ScreenGroupInsertion
+		}
+		return BulkSelectUpdateResult{Screen: screen, Files: files}
+
+	case "esc", "q":
+		return BulkSelectUpdateResult{Screen: ScreenClassification}
+
+	case "ctrl+c":
+		return BulkSelectUpdateResult{Screen: -1}
+	}
+
+	return BulkSelectUpdateResult{Screen: -2}
+}
@@ -0,0 +1,155 @@
+// ui/bulk_select_test.go
+package ui
+
+import (
+	"clip-tagger/metadata"
+	"clip-tagger/state"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// bulkFixture returns a session of six clips: three recorded back to back,
+// then a long break, then three more
+func bulkFixture(t *testing.T) (*state.State, []string) {
+	appState := state.NewState(t.TempDir(), state.SortByRecorded)
+	start := time.Date(2026, 1, 12, 10, 0, 0, 0, time.UTC)
+	offsets := []time.Duration{0, 20 * time.Second, 40 * time.Second, 20 * time.Minute, 21 * time.Minute, 22 * time.Minute}
+	var files []string
+	for i, offset := range offsets {
+		file := string(rune('a'+i)) + ".mp4"
+		appState.CacheMetadata(file, 1, start, metadata.Metadata{RecordedTime: start.Add(offset), Duration: 10 * time.Second})
+		files = append(files, file)
+	}
+	return appState, files
+}
+
+func TestBulkSelectUpdate(t *testing.T) {
+	appState, files := bulkFixture(t)
+	data := NewBulkSelectData(appState, files, 1)
+
+	if !reflect.DeepEqual(data.SelectedFiles(), []string{"b.mp4"}) {
+		t.Fatalf("expected the current file selected, got %v", data.SelectedFiles())
+	}
+
+	// Shift extends from where the selection started
+	BulkSelectUpdate(data, "shift+down")
+	BulkSelectUpdate(data, "J")
+	if !reflect.DeepEqual(data.SelectedFiles(), []string{"b.mp4", "c.mp4", "d.mp4"}) {
+		t.Errorf("unexpected range: %v", data.SelectedFiles())
+	}
+
+	// Space toggles one clip
+	BulkSelectUpdate(data, " ")
+	if data.Selected[3] {
+		t.Error("expected d.mp4 to be deselected")
+	}
+
+	// 'r' selects until the next long gap
+	BulkSelectUpdate(data, "c")
+	BulkSelectUpdate(data, "up")
+	BulkSelectUpdate(data, "up")
+	BulkSelectUpdate(data, "up")
+	BulkSelectUpdate(data, "r")
+	if !reflect.DeepEqual(data.SelectedFiles(), []string{"a.mp4", "b.mp4", "c.mp4"}) || data.Cursor != 2 {
+		t.Errorf("expected the first run, got %v at %d", data.SelectedFiles(), data.Cursor)
+	}
+
+	// A shorter gap threshold ends the run sooner
+	BulkSelectUpdate(data, "c")
+	BulkSelectUpdate(data, "up")
+	BulkSelectUpdate(data, "up")
+	for range 4 {
+		BulkSelectUpdate(data, "[")
+	}
+	BulkSelectUpdate(data, "r")
+	if !reflect.DeepEqual(data.SelectedFiles(), []string{"a.mp4"}) {
+		t.Errorf("expected only a.mp4 with a %v gap, got %v", data.RunGap, data.SelectedFiles())
+	}
+
+	result := BulkSelectUpdate(data, "3")
+	if result.Screen != ScreenGroupInsertion || !reflect.DeepEqual(result.Files, []string{"a.mp4"}) {
+		t.Errorf("unexpected result: %+v", result)
+	}
+
+	BulkSelectUpdate(data, "c")
+	if result := BulkSelectUpdate(data, "enter"); result.Screen != -2 {
+		t.Errorf("expected nothing to assign without a selection, got %+v", result)
+	}
+	if result := BulkSelectUpdate(data, "esc"); result.Screen != ScreenClassification {
+		t.Errorf("expected esc to cancel, got %+v", result)
+	}
+}
+
+func TestBulkSelectView_ShowsGaps(t *testing.T) {
+	appState, files := bulkFixture(t)
+	view := BulkSelectView(NewBulkSelectData(appState, files, 0))
+	if !strings.Contains(view, "19m10s gap") {
+		t.Errorf("expected the long gap to be shown, got:\n%s", view)
+	}
+	if strings.Count(view, "gap ──") != 1 {
+		t.Errorf("expected one gap marker, got:\n%s", view)
+	}
+}
+
+func TestModel_BulkAssign(t *testing.T) {
+	appState, files := bulkFixture(t)
+	group := state.NewGroup("b-roll", 1)
+	appState.Groups = []state.Group{group}
+
+	model := NewModel(appState, appState.Directory)
+	model.files = files
+	model.currentScreen = ScreenClassification
+	model.classificationData = NewClassificationData(appState, files, 0, "")
+	send := func(msg tea.Msg) {
+		t.Helper()
+		updated, cmd := model.Update(msg)
+		model = updated.(Model)
+		if cmd != nil {
+			if next := cmd(); next != nil {
+				updated, _ = model.Update(next)
+				model = updated.(Model)
+			}
+		}
+	}
+	press := func(key string) {
+		send(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)})
+	}
+
+	press("m")
+	if model.currentScreen != ScreenBulkSelect {
+		t.Fatalf("expected multi-select screen, got %v", model.currentScreen)
+	}
+	press("r")
+
+	// Cancelling the group choice goes back to the selection
+	send(tea.KeyMsg{Type: tea.KeyEnter})
+	if model.currentScreen != ScreenGroupSelection {
+		t.Fatalf("expected group selection, got %v", model.currentScreen)
+	}
+	send(tea.KeyMsg{Type: tea.KeyEsc})
+	if model.currentScreen != ScreenBulkSelect || len(model.bulkSelectData.SelectedFiles()) != 3 {
+		t.Fatalf("expected to return to the selection, got %v", model.currentScreen)
+	}
+
+	send(tea.KeyMsg{Type: tea.KeyEnter})
+	send(tea.KeyMsg{Type: tea.KeyEnter})
+	for i, f := range files[:3] {
+		c, ok := appState.GetClassification(f)
+		if !ok || c.GroupID != group.ID || c.TakeNumber != i+1 {
+			t.Errorf("expected %s as take %d, got %+v", f, i+1, c)
+		}
+	}
+	if model.currentScreen != ScreenClassification || model.currentFileIndex != 3 {
+		t.Errorf("expected to continue at d.mp4, got %v at %d", model.currentScreen, model.currentFileIndex)
+	}
+
+	// The whole batch is one undo step
+	press("u")
+	if len(appState.Classifications) != 0 {
+		t.Errorf("expected the batch to be undone, got %+v", appState.Classifications)
+	}
+}
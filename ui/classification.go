@@ -2,9 +2,12 @@
 package ui
 
 import (
+	"clip-tagger/metadata"
 	"clip-tagger/state"
 	"fmt"
 	"path/filepath"
+	"strings"
+	"time"
 )
 
 // ClassificationAction represents the action taken on the classification screen
@@ -17,6 +20,18 @@ const (
 	ClassificationActionSelectGroup
 	ClassificationActionCreateGroup
 	ClassificationActionSkip
+	ClassificationActionPrevious
+	ClassificationActionNext
+	ClassificationActionUnclassify
+	ClassificationActionUndo
+	ClassificationActionRedo
+	ClassificationActionAnnotate
+	ClassificationActionTags
+	ClassificationActionFilterTag
+	ClassificationActionSetGap
+	ClassificationActionPrefill
+	ClassificationActionDisposition
+	ClassificationActionReject
 )
 
 // ClassificationData contains the data needed to render the classification screen
@@ -28,6 +43,26 @@ type ClassificationData struct {
 	HasPreviousClassification bool
 	PreviousGroupName        string
 	PreviousGroupID          string
+	Metadata                 *metadata.Metadata // Container metadata, nil if unavailable
+	IsClassified             bool   // The current file already has a group
+	GroupName                string // Group of the current file, if classified
+	TakeNumber               int    // Take of the current file, if classified
+	Notice                   string // Result of the last undo or redo
+	Annotation               state.Annotation // Rating, circle and notes; kept until classified for a new file
+	EditingNotes             bool             // Notes are being typed
+	NotesInput               string
+	Tags                     []string // Tags of the current file; kept until classified for a new file
+	TagFilter                string   // Only clips with this tag are shown, "" for all
+	RevisitSkipped           bool     // Only skipped clips are shown
+	FilterPosition           int      // 1-based position among the clips shown
+	FilterTotal              int      // Number of clips shown
+	IsSkipped                bool              // The current file was skipped
+	Disposition              state.Disposition // What happens to the file if it stays skipped
+	Suggestion               ClassificationAction // SameAsLast or CreateGroup, taken with Enter
+	GapBefore                time.Duration        // Recording gap since the previous file
+	SuggestGap               time.Duration        // A longer gap suggests a new group
+	Width                    int                  // Terminal width, 0 if unknown
+	Keys                     Keymap               // Active key bindings for the hints, nil for the defaults
 }
 
 // ClassificationUpdateResult contains the result of a classification update
@@ -52,13 +87,28 @@ func NewClassificationData(appState *state.State, files []string, fileIndex int,
 		FilePath:     filepath.Join(appState.Directory, currentFile),
 	}
 
+	if md, ok := appState.GetMetadata(currentFile); ok {
+		data.Metadata = md
+	}
+
+	// Show where an already classified file currently sits
+	if classification, ok := appState.GetClassification(currentFile); ok {
+		data.IsClassified = true
+		data.TakeNumber = classification.TakeNumber
+		data.Annotation = classification.Annotation
+		data.Tags = classification.Tags
+		data.GroupName = appState.GroupFullName(classification.GroupID)
+	}
+	if appState.IsSkipped(currentFile) {
+		data.IsSkipped = true
+		data.Disposition = appState.Disposition(currentFile)
+	}
+
 	// Use the last classified group ID if provided, otherwise search backwards
 	if lastClassifiedGroupID != "" {
 		data.HasPreviousClassification = true
 		data.PreviousGroupID = lastClassifiedGroupID
-		if group := appState.FindGroupByID(lastClassifiedGroupID); group != nil {
-			data.PreviousGroupName = group.Name
-		}
+		data.PreviousGroupName = appState.GroupFullName(lastClassifiedGroupID)
 	} else if fileIndex > 0 {
 		// Fallback: Look for the most recent classified file before current index
 		for i := fileIndex - 1; i >= 0; i-- {
@@ -66,52 +116,181 @@ func NewClassificationData(appState *state.State, files []string, fileIndex int,
 			if classification, ok := appState.GetClassification(prevFile); ok {
 				data.HasPreviousClassification = true
 				data.PreviousGroupID = classification.GroupID
-				if group := appState.FindGroupByID(classification.GroupID); group != nil {
-					data.PreviousGroupName = group.Name
-				}
+				data.PreviousGroupName = appState.GroupFullName(classification.GroupID)
 				break
 			}
 		}
 	}
 
+	data.suggest(appState, files, fileIndex)
 	return data
 }
 
+// suggest picks the default action from the recording gap before the file:
+// "same as last" for another take of the same setup, or a new group
+func (data *ClassificationData) suggest(appState *state.State, files []string, fileIndex int) {
+	data.SuggestGap = appState.Gap()
+	data.GapBefore = 0
+	if fileIndex > 0 {
+		data.GapBefore = appState.RecordingGap(files[fileIndex-1], files[fileIndex])
+	}
+	data.Suggestion = ClassificationActionCreateGroup
+	if data.HasPreviousClassification && !appState.StartsNewSetup(files, fileIndex) {
+		data.Suggestion = ClassificationActionSameAsLast
+	}
+}
+
 // ClassificationView renders the classification screen
 func ClassificationView(data *ClassificationData) string {
 	var output string
 
 	// Header with progress
 	output += RenderHeader(fmt.Sprintf("=== Classification: File %d of %d ===", data.CurrentIndex, data.TotalFiles)) + "\n\n"
+	if data.TagFilter != "" {
+		output += fmt.Sprintf("%s %s (clip %d of %d)\n\n", RenderMuted("Tag filter:"),
+			RenderHighlight(data.TagFilter), data.FilterPosition, data.FilterTotal)
+	}
+	if data.RevisitSkipped {
+		output += fmt.Sprintf("%s skipped clip %d of %d\n\n", RenderMuted("Revisiting:"),
+			data.FilterPosition, data.FilterTotal)
+	}
 
 	// Current file info
-	output += fmt.Sprintf("%s %s\n", RenderMuted("File:"), RenderSubheader(data.CurrentFile))
-	output += fmt.Sprintf("%s %s\n\n", RenderMuted("Path:"), RenderMuted(data.FilePath))
+	output += fmt.Sprintf("%s %s\n", RenderMuted("File:"), RenderSubheader(truncateMiddle(data.CurrentFile, nameWidth(data.Width, 6))))
+	output += fmt.Sprintf("%s %s\n", RenderMuted("Path:"), RenderMuted(truncateMiddle(data.FilePath, nameWidth(data.Width, 6))))
+	if data.Metadata != nil {
+		if !data.Metadata.RecordedTime.IsZero() {
+			output += fmt.Sprintf("%s %s\n", RenderMuted("Recorded:"), data.Metadata.RecordedTime.Format("2006-01-02 15:04:05 -0700"))
+		}
+		if summary := data.Metadata.Summary(); summary != "" {
+			output += fmt.Sprintf("%s %s\n", RenderMuted("Media:"), summary)
+		}
+	}
+	if data.IsClassified {
+		output += fmt.Sprintf("%s %s, take %d\n", RenderMuted("Classified:"),
+			RenderSuccess(data.GroupName), data.TakeNumber)
+	}
+	if data.IsSkipped {
+		output += fmt.Sprintf("%s %s\n", RenderMuted("Skipped:"), RenderWarning(data.Disposition.Description()))
+	}
+	if summary := annotationSummary(data.Annotation); summary != "" {
+		output += fmt.Sprintf("%s %s\n", RenderMuted("Take notes:"), summary)
+	}
+	if len(data.Tags) > 0 {
+		output += fmt.Sprintf("%s %s\n", RenderMuted("Tags:"), strings.Join(data.Tags, ", "))
+	}
+	output += "\n"
+
+	if data.EditingNotes {
+		output += RenderHighlight("Notes:") + "\n"
+		output += fmt.Sprintf("%s %s\n\n", RenderCursor(">"), RenderSubheader(data.NotesInput))
+		output += RenderKeyHint("Enter to save, Esc to cancel") + "\n"
+		return output
+	}
+
+	if data.Notice != "" {
+		output += RenderMuted(data.Notice) + "\n\n"
+	}
 
 	// Progress indicator
-	progressBar := makeProgressBar(data.CurrentIndex, data.TotalFiles, 30)
+	barWidth := 30
+	if data.Width > 0 {
+		barWidth = min(max(data.Width-10, 10), 60)
+	}
+	progressBar := makeProgressBar(data.CurrentIndex, data.TotalFiles, barWidth)
 	output += RenderProgress(data.CurrentIndex, data.TotalFiles) + "\n"
 	output += progressBar + "\n\n"
 
-	// Available actions
+	// Suggested action from the recording gap
+	output += RenderMuted(suggestionReason(data)) + "\n\n"
+
+	// Available actions, named by the keys they are bound to
+	key := data.Keys.hints(KeysClassification)
+	accept := key("accept_suggestion")
 	output += RenderHighlight("Actions:") + "\n"
-	output += RenderKeyHint("  'p' - Preview file") + "\n"
+	output += RenderKeyHint("  "+key("preview")+" - Preview file") + "\n"
 
 	// "Same as last" only if previous classification exists
 	if data.HasPreviousClassification {
-		output += RenderKeyHint(fmt.Sprintf("  '1' - Same as last (%s)", data.PreviousGroupName)) + "\n"
+		output += actionHint(fmt.Sprintf("%s - Same as last (%s)", key("same_as_last"), data.PreviousGroupName),
+			data.Suggestion == ClassificationActionSameAsLast, accept)
 	}
 
-	output += RenderKeyHint("  '2' - Select from existing groups") + "\n"
-	output += RenderKeyHint("  '3' - Create new group") + "\n"
-	output += RenderKeyHint("  's' - Skip this file") + "\n"
-	output += RenderKeyHint("  'q' - Quit") + "\n"
+	output += RenderKeyHint("  "+key("choose_group")+" - Select from existing groups") + "\n"
+	output += actionHint(key("new_group")+" - Create new group", data.Suggestion == ClassificationActionCreateGroup, accept)
+	output += RenderKeyHint("  "+key("manage_groups")+" - Manage groups") + "\n"
+	output += RenderKeyHint(fmt.Sprintf("  %s - Skip this file, %s - Reject it", key("skip"), key("reject"))) + "\n"
+	if data.IsSkipped {
+		output += RenderKeyHint("  "+key("disposition")+" - Keep name / reject / mark for deletion") + "\n"
+	}
+	output += RenderKeyHint("  "+key("multi_select")+" - Select several clips for one group") + "\n"
+	output += RenderKeyHint("  "+key("auto_group")+" - Auto-group the remaining clips by time gap") + "\n"
+	output += RenderKeyHint(fmt.Sprintf("  %s - Circle take, %s / %s - Rating, %s - Notes",
+		key("circle"), key("rating_up"), key("rating_down"), key("notes"))) + "\n"
+	output += RenderKeyHint(fmt.Sprintf("  %s - Tags, %s - Filter queue by tag", key("tags"), key("filter"))) + "\n"
+	if data.IsClassified {
+		output += RenderKeyHint("  "+key("unclassify")+" - Unclassify this file") + "\n"
+	}
+	output += RenderKeyHint(fmt.Sprintf("  %s / %s - Previous / next file", key("previous"), key("next"))) + "\n"
+	output += RenderKeyHint(fmt.Sprintf("  %s / %s - Undo / redo", key("undo"), key("redo"))) + "\n"
+	output += RenderKeyHint("  "+key("quit")+" - Quit") + "\n"
+	if data.IsClassified {
+		output += "\n" + RenderMuted("Choosing a group moves this file; take numbers are renumbered to match") + "\n"
+	}
 
 	return output
 }
 
+// actionHint renders an action line, marked with the accept key when it is
+// the suggested one
+func actionHint(text string, suggested bool, accept string) string {
+	if suggested {
+		return RenderCursor("> ") + RenderHighlight(text) + RenderMuted("  ("+accept+")") + "\n"
+	}
+	return RenderKeyHint("  "+text) + "\n"
+}
+
+// suggestionReason explains the suggested action
+func suggestionReason(data *ClassificationData) string {
+	var reason string
+	switch {
+	case data.CurrentIndex <= 1:
+		reason = "First clip"
+	case data.GapBefore > data.SuggestGap:
+		reason = fmt.Sprintf("Recorded %s after the previous clip, likely a new setup", data.GapBefore.Round(time.Second))
+	case !data.HasPreviousClassification:
+		reason = "No group used yet"
+	default:
+		reason = fmt.Sprintf("Recorded %s after the previous clip, likely another take", max(data.GapBefore, 0).Round(time.Second))
+	}
+	key := data.Keys.hints(KeysClassification)
+	return fmt.Sprintf("%s (new group after gaps over %s; %s / %s to change)",
+		reason, data.SuggestGap, key("shorter_gap"), key("longer_gap"))
+}
+
+// annotationSummary describes a rating, circle and notes in one line, or
+// returns "" if there are none
+func annotationSummary(a state.Annotation) string {
+	var parts []string
+	if a.Rating > 0 {
+		parts = append(parts, RenderHighlight(strings.Repeat("★", a.Rating))+
+			RenderMuted(strings.Repeat("☆", max(state.MaxRating-a.Rating, 0))))
+	}
+	if a.Circled {
+		parts = append(parts, RenderSuccess("circled"))
+	}
+	if a.Notes != "" {
+		parts = append(parts, fmt.Sprintf("%q", a.Notes))
+	}
+	return strings.Join(parts, "  ")
+}
+
 // ClassificationUpdate handles input for the classification screen
 func ClassificationUpdate(data *ClassificationData, msg string) ClassificationUpdateResult {
+	if data.EditingNotes {
+		return handleNotesInput(data, msg)
+	}
+
 	switch msg {
 	case "p":
 		return ClassificationUpdateResult{
@@ -145,6 +324,145 @@ func ClassificationUpdate(data *ClassificationData, msg string) ClassificationUp
 			Action: ClassificationActionSkip,
 			Screen: -2, // Action handled, will move to next file
 		}
+	case "r":
+		return ClassificationUpdateResult{
+			Action: ClassificationActionReject,
+			Screen: -2, // Action handled, will move to next file
+		}
+	case "d":
+		// Cycle what happens to a skipped file
+		if data.IsSkipped {
+			data.Disposition = data.Disposition.Next()
+			return ClassificationUpdateResult{
+				Action: ClassificationActionDisposition,
+				Screen: -2,
+			}
+		}
+		return ClassificationUpdateResult{
+			Action: ClassificationActionNone,
+			Screen: -2,
+		}
+	case "left":
+		// Move back, unless this is the first file
+		if data.CurrentIndex > 1 {
+			return ClassificationUpdateResult{
+				Action: ClassificationActionPrevious,
+				Screen: -2,
+			}
+		}
+		return ClassificationUpdateResult{
+			Action: ClassificationActionNone,
+			Screen: -2,
+		}
+	case "right":
+		// Move forward, unless this is the last file
+		if data.CurrentIndex < data.TotalFiles {
+			return ClassificationUpdateResult{
+				Action: ClassificationActionNext,
+				Screen: -2,
+			}
+		}
+		return ClassificationUpdateResult{
+			Action: ClassificationActionNone,
+			Screen: -2,
+		}
+	case "x":
+		// Only a classified file can be unclassified
+		if data.IsClassified {
+			return ClassificationUpdateResult{
+				Action: ClassificationActionUnclassify,
+				Screen: -2,
+			}
+		}
+		return ClassificationUpdateResult{
+			Action: ClassificationActionNone,
+			Screen: -2,
+		}
+	case "g":
+		return ClassificationUpdateResult{
+			Action: ClassificationActionNone,
+			Screen: ScreenGroupManagement,
+		}
+	case "c":
+		data.Annotation.Circled = !data.Annotation.Circled
+		return ClassificationUpdateResult{
+			Action: ClassificationActionAnnotate,
+			Screen: -2,
+		}
+	case "+", "=", "-":
+		rating := data.Annotation.Rating + 1
+		if msg == "-" {
+			rating = data.Annotation.Rating - 1
+		}
+		if rating < 0 || rating > state.MaxRating {
+			return ClassificationUpdateResult{
+				Action: ClassificationActionNone,
+				Screen: -2,
+			}
+		}
+		data.Annotation.Rating = rating
+		return ClassificationUpdateResult{
+			Action: ClassificationActionAnnotate,
+			Screen: -2,
+		}
+	case "n":
+		data.EditingNotes = true
+		data.NotesInput = data.Annotation.Notes
+		return ClassificationUpdateResult{
+			Action: ClassificationActionNone,
+			Screen: -2,
+		}
+	case "enter":
+		if data.Suggestion == ClassificationActionSameAsLast && data.HasPreviousClassification {
+			return ClassificationUpdateResult{
+				Action: ClassificationActionSameAsLast,
+				Screen: -2,
+			}
+		}
+		return ClassificationUpdateResult{
+			Action: ClassificationActionCreateGroup,
+			Screen: ScreenGroupInsertion,
+		}
+	case "[", "]":
+		if msg == "[" {
+			data.SuggestGap = max(data.SuggestGap/2, time.Second)
+		} else {
+			data.SuggestGap *= 2
+		}
+		return ClassificationUpdateResult{
+			Action: ClassificationActionSetGap,
+			Screen: -2,
+		}
+	case "a":
+		return ClassificationUpdateResult{
+			Action: ClassificationActionPrefill,
+			Screen: -2,
+		}
+	case "m":
+		return ClassificationUpdateResult{
+			Action: ClassificationActionNone,
+			Screen: ScreenBulkSelect,
+		}
+	case "t":
+		return ClassificationUpdateResult{
+			Action: ClassificationActionTags,
+			Screen: ScreenTagPicker,
+		}
+	case "f":
+		return ClassificationUpdateResult{
+			Action: ClassificationActionFilterTag,
+			Screen: ScreenTagPicker,
+		}
+	case "u":
+		return ClassificationUpdateResult{
+			Action: ClassificationActionUndo,
+			Screen: -2,
+		}
+	case "ctrl+r":
+		return ClassificationUpdateResult{
+			Action: ClassificationActionRedo,
+			Screen: -2,
+		}
 	case "q", "ctrl+c":
 		return ClassificationUpdateResult{
 			Action: ClassificationActionNone,
@@ -158,6 +476,30 @@ func ClassificationUpdate(data *ClassificationData, msg string) ClassificationUp
 	}
 }
 
+// handleNotesInput handles typing notes for the current file
+func handleNotesInput(data *ClassificationData, msg string) ClassificationUpdateResult {
+	switch msg {
+	case "ctrl+c":
+		return ClassificationUpdateResult{Action: ClassificationActionNone, Screen: -1}
+	case "enter":
+		data.EditingNotes = false
+		data.Annotation.Notes = strings.TrimSpace(data.NotesInput)
+		return ClassificationUpdateResult{Action: ClassificationActionAnnotate, Screen: -2}
+	case "esc":
+		data.EditingNotes = false
+	case "backspace":
+		if len(data.NotesInput) > 0 {
+			runes := []rune(data.NotesInput)
+			data.NotesInput = string(runes[:len(runes)-1])
+		}
+	default:
+		if len(msg) == 1 || msg == " " {
+			data.NotesInput += msg
+		}
+	}
+	return ClassificationUpdateResult{Action: ClassificationActionNone, Screen: -2}
+}
+
 // makeProgressBar creates a simple text progress bar
 func makeProgressBar(current, total, width int) string {
 	if total == 0 {
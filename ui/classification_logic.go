@@ -1,21 +1,83 @@
 // ui/classification_logic.go
 package ui
 
+import (
+	"clip-tagger/state"
+	"fmt"
+	"slices"
+)
+
 // findNextUnclassifiedFile advances currentFileIndex to the next unclassified file
-// Returns true if found, false if all remaining files are classified
+// Returns true if found, false if all remaining files are classified or skipped
 func (m *Model) findNextUnclassifiedFile() bool {
 	for m.currentFileIndex < len(m.files) {
 		currentFile := m.files[m.currentFileIndex]
 		_, classified := m.state.GetClassification(currentFile)
-		if !classified {
+		if !classified && !m.state.IsSkipped(currentFile) {
 			return true
 		}
-		// This file is already classified, skip to next
+		// This file is already classified or skipped, skip to next
 		m.currentFileIndex++
 	}
 	return false
 }
 
+// advanceQueue moves on from the current file: to the next skipped clip
+// while revisiting them, to the next clip with the tag filter's tag, to the
+// next file after reclassifying, or else to the next unclassified file.
+// Returns false at the end of the queue.
+func (m *Model) advanceQueue(reclassified bool) bool {
+	if m.revisitSkipped {
+		if index := m.nextInQueue(m.currentFileIndex, 1); index >= 0 {
+			m.currentFileIndex = index
+			return true
+		}
+		// Every skipped clip has been revisited; back to review
+		m.revisitSkipped = false
+		m.currentFileIndex = len(m.files)
+		return false
+	}
+	if m.tagFilter != "" {
+		if index := m.nextInQueue(m.currentFileIndex, 1); index >= 0 {
+			m.currentFileIndex = index
+			return true
+		}
+		// Every tagged clip has been visited; carry on with the whole queue
+		m.tagFilter = ""
+	}
+
+	m.currentFileIndex++
+	if reclassified {
+		return m.currentFileIndex < len(m.files)
+	}
+	return m.findNextUnclassifiedFile()
+}
+
+// nextInQueue returns the index of the nearest file before (negative delta)
+// or after index that the filtered queue visits, or -1 if there is none
+func (m Model) nextInQueue(index, delta int) int {
+	for i := index + delta; i >= 0 && i < len(m.files); i += delta {
+		if m.inQueue(m.files[i]) {
+			return i
+		}
+	}
+	return -1
+}
+
+// queueFiltered reports whether only some clips are visited: the skipped
+// ones, or those with the tag filter's tag
+func (m Model) queueFiltered() bool {
+	return m.revisitSkipped || m.tagFilter != ""
+}
+
+// inQueue reports whether the filtered queue visits a file
+func (m Model) inQueue(file string) bool {
+	if m.revisitSkipped {
+		return m.state.IsSkipped(file)
+	}
+	return m.state.HasTag(file, m.tagFilter)
+}
+
 // handleClassificationSameAsLast handles the "Same as Last" classification action
 // It uses the most recently classified group from the current session
 func (m Model) handleClassificationSameAsLast() Model {
@@ -41,15 +103,18 @@ func (m Model) handleClassificationSameAsLast() Model {
 	}
 
 	// If we found a previous classification, apply it to current file
+	reclassified := false
 	if lastGroupID != "" {
-		m.state.AddOrUpdateClassification(currentFile, lastGroupID)
+		_, reclassified = m.state.GetClassification(currentFile)
+		m.state.AssignClassification(currentFile, lastGroupID, m.files)
+		m = m.applyPendingAnnotation(currentFile)
 		// Update lastClassifiedGroupID for next "Same as Last"
 		m.lastClassifiedGroupID = lastGroupID
 	}
 
-	// Advance to next unclassified file
-	m.currentFileIndex++
-	hasNext := m.findNextUnclassifiedFile()
+	// Advance to the next unclassified file, or just the next file after
+	// reclassifying, so earlier files can be revisited in sequence
+	hasNext := m.advanceQueue(reclassified)
 	m.state.CurrentIndex = m.currentFileIndex
 
 	// Check if we're done with all files
@@ -59,7 +124,7 @@ func (m Model) handleClassificationSameAsLast() Model {
 		m.reviewData = NewReviewData(m.state, m.files)
 	} else {
 		// Update classification data for next file
-		m.classificationData = NewClassificationData(m.state, m.files, m.currentFileIndex, m.lastClassifiedGroupID)
+		m.classificationData = m.newClassificationData()
 	}
 
 	return m
@@ -75,13 +140,15 @@ func (m Model) handleGroupSelected(groupID string) Model {
 	currentFile := m.files[m.currentFileIndex]
 
 	// Classify the current file with the selected group
-	m.state.AddOrUpdateClassification(currentFile, groupID)
+	_, reclassified := m.state.GetClassification(currentFile)
+	m.state.AssignClassification(currentFile, groupID, m.files)
+	m = m.applyPendingAnnotation(currentFile)
 	// Track for "Same as Last"
 	m.lastClassifiedGroupID = groupID
 
-	// Advance to next unclassified file
-	m.currentFileIndex++
-	hasNext := m.findNextUnclassifiedFile()
+	// Advance to the next unclassified file, or just the next file after
+	// reclassifying, so earlier files can be revisited in sequence
+	hasNext := m.advanceQueue(reclassified)
 	m.state.CurrentIndex = m.currentFileIndex
 
 	// Check if we're done with all files
@@ -91,7 +158,7 @@ func (m Model) handleGroupSelected(groupID string) Model {
 		m.reviewData = NewReviewData(m.state, m.files)
 	} else {
 		// Update classification data for next file
-		m.classificationData = NewClassificationData(m.state, m.files, m.currentFileIndex, m.lastClassifiedGroupID)
+		m.classificationData = m.newClassificationData()
 		// Transition back to classification screen
 		m.currentScreen = ScreenClassification
 	}
@@ -110,13 +177,15 @@ func (m Model) handleGroupInserted(groupID, groupName string, order int) Model {
 
 	// The group has already been added to state by the GroupInserted message handler
 	// Now classify the current file with the new group
-	m.state.AddOrUpdateClassification(currentFile, groupID)
+	_, reclassified := m.state.GetClassification(currentFile)
+	m.state.AssignClassification(currentFile, groupID, m.files)
+	m = m.applyPendingAnnotation(currentFile)
 	// Track for "Same as Last"
 	m.lastClassifiedGroupID = groupID
 
-	// Advance to next unclassified file
-	m.currentFileIndex++
-	hasNext := m.findNextUnclassifiedFile()
+	// Advance to the next unclassified file, or just the next file after
+	// reclassifying, so earlier files can be revisited in sequence
+	hasNext := m.advanceQueue(reclassified)
 	m.state.CurrentIndex = m.currentFileIndex
 
 	// Check if we're done with all files
@@ -126,7 +195,7 @@ func (m Model) handleGroupInserted(groupID, groupName string, order int) Model {
 		m.reviewData = NewReviewData(m.state, m.files)
 	} else {
 		// Update classification data for next file
-		m.classificationData = NewClassificationData(m.state, m.files, m.currentFileIndex, m.lastClassifiedGroupID)
+		m.classificationData = m.newClassificationData()
 		// Transition back to classification screen
 		m.currentScreen = ScreenClassification
 	}
@@ -134,8 +203,63 @@ func (m Model) handleGroupInserted(groupID, groupName string, order int) Model {
 	return m
 }
 
-// handleClassificationSkip handles when a user skips a file
-func (m Model) handleClassificationSkip() Model {
+// handleBulkAssigned classifies the clips chosen on the multi-select screen
+// and returns to the current file, or the next unclassified one if the
+// current file was among them
+func (m Model) handleBulkAssigned(groupID string) Model {
+	files := m.bulkFiles
+	m.bulkFiles = nil
+	if err := m.state.AssignClassifications(files, groupID, m.files); err != nil {
+		m.err = fmt.Sprintf("Failed to classify clips: %v", err)
+		return m
+	}
+	// Track for "Same as Last"
+	m.lastClassifiedGroupID = groupID
+
+	hasNext := m.currentFileIndex < len(m.files)
+	if !m.queueFiltered() {
+		hasNext = m.findNextUnclassifiedFile()
+	}
+	m.state.CurrentIndex = m.currentFileIndex
+
+	if !hasNext {
+		m.currentScreen = ScreenReview
+		m.reviewData = NewReviewData(m.state, m.files)
+	} else {
+		m.classificationData = m.newClassificationData()
+		m.currentScreen = ScreenClassification
+	}
+	return m
+}
+
+// handleClassificationPrefill classifies every remaining clip into groups
+// proposed by time gap and shows the result on the review screen, where it
+// can be accepted or edited
+func (m Model) handleClassificationPrefill() Model {
+	classified := len(m.state.Classifications)
+	created, err := m.state.PrefillGroups(m.files)
+	if err != nil {
+		m.err = fmt.Sprintf("Failed to group clips: %v", err)
+		return m
+	}
+	if len(m.state.Classifications) == classified {
+		m.classificationData.Notice = "No clips left to group"
+		return m
+	}
+	if len(created) > 0 {
+		m.lastClassifiedGroupID = created[len(created)-1].ID
+	}
+
+	m.currentFileIndex = len(m.files)
+	m.state.CurrentIndex = m.currentFileIndex
+	m.currentScreen = ScreenReview
+	m.reviewData = NewReviewData(m.state, m.files)
+	return m
+}
+
+// handleClassificationSkip handles when a user skips a file, and with reject
+// set marks it to be moved out of the session when renaming
+func (m Model) handleClassificationSkip(reject bool) Model {
 	if m.currentFileIndex >= len(m.files) {
 		// No current file to skip
 		return m
@@ -144,11 +268,13 @@ func (m Model) handleClassificationSkip() Model {
 	currentFile := m.files[m.currentFileIndex]
 
 	// Add file to skipped list
-	m.state.Skipped = append(m.state.Skipped, currentFile)
+	m.state.Skip(currentFile)
+	if reject {
+		m.state.SetDisposition(currentFile, state.DispositionReject)
+	}
 
 	// Advance to next unclassified file
-	m.currentFileIndex++
-	hasNext := m.findNextUnclassifiedFile()
+	hasNext := m.advanceQueue(false)
 	m.state.CurrentIndex = m.currentFileIndex
 
 	// Check if we're done with all files
@@ -158,8 +284,264 @@ func (m Model) handleClassificationSkip() Model {
 		m.reviewData = NewReviewData(m.state, m.files)
 	} else {
 		// Update classification data for next file
-		m.classificationData = NewClassificationData(m.state, m.files, m.currentFileIndex, m.lastClassifiedGroupID)
+		m.classificationData = m.newClassificationData()
+	}
+
+	return m
+}
+
+// handleClassificationNavigate moves to the previous or next file, whether
+// or not it is classified
+func (m Model) handleClassificationNavigate(delta int) Model {
+	index := m.currentFileIndex + delta
+	if m.queueFiltered() {
+		index = m.nextInQueue(m.currentFileIndex, delta)
+	}
+	if index < 0 || index >= len(m.files) {
+		return m
+	}
+
+	m.currentFileIndex = index
+	m.state.CurrentIndex = index
+	m.classificationData = m.newClassificationData()
+	return m
+}
+
+// handleClassificationUnclassify removes the current file's classification
+// and stays on it so it can be classified again
+func (m Model) handleClassificationUnclassify() Model {
+	if m.currentFileIndex >= len(m.files) {
+		return m
+	}
+
+	m.state.RemoveClassification(m.files[m.currentFileIndex])
+	m.classificationData = m.newClassificationData()
+	return m
+}
+
+// handleClassificationAnnotate stores the rating, circle and notes entered
+// for the current file. An unclassified file keeps them on the screen until
+// it is classified.
+func (m Model) handleClassificationAnnotate() Model {
+	file := m.currentFile()
+	if _, ok := m.state.GetClassification(file); !ok {
+		return m
+	}
+
+	before := m.checkpoint()
+	if err := m.state.Annotate(file, m.classificationData.Annotation); err != nil {
+		m.err = fmt.Sprintf("Failed to save notes: %v", err)
+		return m
+	}
+	m.state.Record("annotate "+file, before)
+	return m.autoSaveState()
+}
+
+// applyPendingAnnotation stores a rating, circle, notes or tags entered
+// before the file was classified
+func (m Model) applyPendingAnnotation(file string) Model {
+	data := m.classificationData
+	if data == nil || data.CurrentFile != file {
+		return m
+	}
+	if !data.Annotation.IsZero() {
+		if err := m.state.Annotate(file, data.Annotation); err != nil {
+			m.err = fmt.Sprintf("Failed to save notes: %v", err)
+			return m
+		}
+	}
+	if len(data.Tags) > 0 {
+		if err := m.state.SetTags(file, data.Tags); err != nil {
+			m.err = fmt.Sprintf("Failed to save tags: %v", err)
+		}
+	}
+	return m
+}
+
+// handleTagsChosen stores the tags picked for the current file. An
+// unclassified file keeps them on the screen until it is classified.
+func (m Model) handleTagsChosen(tags []string) Model {
+	file := m.currentFile()
+	if m.classificationData == nil || m.classificationData.CurrentFile != file {
+		return m
+	}
+
+	c, ok := m.state.GetClassification(file)
+	if !ok {
+		m.classificationData.Tags = tags
+		return m
+	}
+	if slices.Equal(c.Tags, tags) {
+		return m
+	}
+
+	before := m.checkpoint()
+	if err := m.state.SetTags(file, tags); err != nil {
+		m.err = fmt.Sprintf("Failed to save tags: %v", err)
+		return m
+	}
+	m.state.Record("tag "+file, before)
+	c, _ = m.state.GetClassification(file)
+	m.classificationData.Tags = c.Tags
+	return m.autoSaveState()
+}
+
+// handleTagFilter limits the classification queue to the clips with a tag,
+// or with "" visits every file again. The current file stays if it has
+// the tag; otherwise the next tagged clip, or the first, is shown.
+func (m Model) handleTagFilter(tag string) Model {
+	m.tagFilter = tag
+	m.revisitSkipped = false
+	if tag == "" || m.state.HasTag(m.currentFile(), tag) {
+		// Same file; keep anything entered for it
+		if m.classificationData != nil {
+			m.setFilterProgress(m.classificationData)
+		}
+		return m
+	}
+
+	index := m.nextInQueue(m.currentFileIndex, 1)
+	if index < 0 {
+		index = m.nextInQueue(-1, 1)
+	}
+	if index < 0 {
+		m.tagFilter = ""
+		return m
+	}
+	m.currentFileIndex = index
+	m.state.CurrentIndex = index
+	m.classificationData = m.newClassificationData()
+	return m
+}
+
+// handleRevisitSkipped limits the classification queue to the skipped clips,
+// starting with the first. Classifying a clip takes it out of the queue;
+// skipping it again keeps it skipped.
+func (m Model) handleRevisitSkipped() Model {
+	m.revisitSkipped = true
+	m.tagFilter = ""
+	index := m.nextInQueue(-1, 1)
+	if index < 0 {
+		m.revisitSkipped = false
+		return m
+	}
+	m.currentFileIndex = index
+	m.state.CurrentIndex = index
+	m.currentScreen = ScreenClassification
+	m.classificationData = m.newClassificationData()
+	return m
+}
+
+// handleDisposition stores what happens to the current file if it stays
+// skipped, as cycled on the classification screen
+func (m Model) handleDisposition() Model {
+	return m.setDisposition(m.currentFile(), m.classificationData.Disposition)
+}
+
+// setDisposition records a skipped file's disposition as an undoable change
+func (m Model) setDisposition(file string, d state.Disposition) Model {
+	before := m.checkpoint()
+	if err := m.state.SetDisposition(file, d); err != nil {
+		m.err = fmt.Sprintf("Failed to mark skipped file: %v", err)
+		return m
+	}
+	m.state.Record(fmt.Sprintf("%s: %s", file, d.Description()), before)
+	return m.autoSaveState()
+}
+
+// newClassificationData builds the classification screen for the current
+// file, with the filtered queue's progress
+func (m Model) newClassificationData() *ClassificationData {
+	data := NewClassificationData(m.state, m.files, m.currentFileIndex, m.lastClassifiedGroupID)
+	m.setFilterProgress(data)
+	return data
+}
+
+// setFilterProgress shows the queue filter and where the current file is
+// among the clips it visits
+func (m Model) setFilterProgress(data *ClassificationData) {
+	data.TagFilter = m.tagFilter
+	data.RevisitSkipped = m.revisitSkipped
+	data.FilterPosition = 0
+	data.FilterTotal = 0
+	if !m.queueFiltered() {
+		return
+	}
+
+	for i, f := range m.files {
+		if m.inQueue(f) {
+			data.FilterTotal++
+			if i <= m.currentFileIndex {
+				data.FilterPosition++
+			}
+		}
+	}
+}
+
+// currentFile returns the file being classified, or "" past the end
+func (m Model) currentFile() string {
+	if m.currentFileIndex < 0 || m.currentFileIndex >= len(m.files) {
+		return ""
+	}
+	return m.files[m.currentFileIndex]
+}
+
+// checkpoint copies the state before an undoable action, so undo returns
+// to the file the action was taken on
+func (m Model) checkpoint() state.Checkpoint {
+	m.state.CurrentIndex = m.currentFileIndex
+	return m.state.Checkpoint()
+}
+
+// handleUndo reverts (or with redo, reapplies) the last recorded change and
+// returns to the file it touched
+func (m Model) handleUndo(redo bool) Model {
+	var label string
+	var ok bool
+	if redo {
+		label, ok = m.state.Redo()
+	} else {
+		label, ok = m.state.Undo()
+	}
+
+	notice := "Nothing to undo"
+	switch {
+	case ok && redo:
+		notice = "Redid: " + label
+	case ok:
+		notice = "Undid: " + label
+	case redo:
+		notice = "Nothing to redo"
+	}
+
+	// "Same as last" can't point at a group the undo removed
+	if m.lastClassifiedGroupID != "" && m.state.FindGroupByID(m.lastClassifiedGroupID) == nil {
+		m.lastClassifiedGroupID = ""
+	}
+
+	if !ok {
+		if m.currentScreen == ScreenClassification && m.classificationData != nil {
+			m.classificationData.Notice = notice
+		}
+		if m.currentScreen == ScreenReview && m.reviewData != nil {
+			m.reviewData.Notice = notice
+		}
+		return m
+	}
+
+	m.currentFileIndex = min(max(m.state.CurrentIndex, 0), len(m.files))
+	m = m.autoSaveState()
+
+	if m.currentFileIndex >= len(m.files) {
+		// The change was the last file; stay on review
+		m.currentScreen = ScreenReview
+		m = m.refreshReview("")
+		m.reviewData.Notice = notice
+		return m
 	}
 
+	m.currentScreen = ScreenClassification
+	m.classificationData = m.newClassificationData()
+	m.classificationData.Notice = notice
 	return m
 }
@@ -2,8 +2,11 @@
 package ui
 
 import (
+	"clip-tagger/scanner"
 	"clip-tagger/state"
+	"strings"
 	"testing"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 )
@@ -423,7 +426,7 @@ func TestClassificationLogic_Skip(t *testing.T) {
 		model.classificationData = NewClassificationData(appState, model.files, model.currentFileIndex, "")
 
 		// Perform skip action
-		updated := model.handleClassificationSkip()
+		updated := model.handleClassificationSkip(false)
 
 		// Verify file1.mp4 is in skipped list
 		if len(updated.state.Skipped) != 1 {
@@ -445,7 +448,7 @@ func TestClassificationLogic_Skip(t *testing.T) {
 		model.classificationData = NewClassificationData(appState, model.files, model.currentFileIndex, "")
 
 		// Perform skip action
-		updated := model.handleClassificationSkip()
+		updated := model.handleClassificationSkip(false)
 
 		// Verify file1.mp4 is NOT classified
 		_, found := updated.state.GetClassification("file1.mp4")
@@ -465,7 +468,7 @@ func TestClassificationLogic_Skip(t *testing.T) {
 		model.classificationData = NewClassificationData(appState, model.files, model.currentFileIndex, "")
 
 		// Perform skip action
-		updated := model.handleClassificationSkip()
+		updated := model.handleClassificationSkip(false)
 
 		// Verify currentFileIndex advanced
 		if updated.currentFileIndex != 1 {
@@ -484,7 +487,7 @@ func TestClassificationLogic_Skip(t *testing.T) {
 		model.classificationData = NewClassificationData(appState, model.files, model.currentFileIndex, "")
 
 		// Perform skip action
-		updated := model.handleClassificationSkip()
+		updated := model.handleClassificationSkip(false)
 
 		// Verify classification data is updated
 		if updated.classificationData == nil {
@@ -506,9 +509,9 @@ func TestClassificationLogic_Skip(t *testing.T) {
 		model.classificationData = NewClassificationData(appState, model.files, model.currentFileIndex, "")
 
 		// Skip first file
-		updated := model.handleClassificationSkip()
+		updated := model.handleClassificationSkip(false)
 		// Skip second file
-		updated = updated.handleClassificationSkip()
+		updated = updated.handleClassificationSkip(false)
 
 		// Verify both files are in skipped list
 		if len(updated.state.Skipped) != 2 {
@@ -578,7 +581,7 @@ func TestClassificationLogic_TransitionToReview(t *testing.T) {
 		model.classificationData = NewClassificationData(appState, model.files, model.currentFileIndex, "")
 
 		// Skip the last file
-		updated := model.handleClassificationSkip()
+		updated := model.handleClassificationSkip(false)
 
 		// Verify screen transitions to review
 		if updated.currentScreen != ScreenReview {
@@ -683,3 +686,416 @@ func TestClassificationLogic_Integration(t *testing.T) {
 	})
 }
 
+
+// TestClassificationLogic_Navigation tests stepping through classified and
+// unclassified files and reclassifying in place
+func TestClassificationLogic_Navigation(t *testing.T) {
+	setup := func(t *testing.T) (Model, state.Group, state.Group) {
+		tmpDir := t.TempDir()
+		appState := state.NewState(tmpDir, state.SortByModifiedTime)
+		intro := state.NewGroup("intro", 1)
+		outro := state.NewGroup("outro", 2)
+		appState.Groups = []state.Group{intro, outro}
+		appState.AddOrUpdateClassification("file1.mp4", intro.ID)
+		appState.AddOrUpdateClassification("file2.mp4", intro.ID)
+		appState.AddOrUpdateClassification("file3.mp4", outro.ID)
+
+		model := NewModel(appState, tmpDir)
+		model.files = []string{"file1.mp4", "file2.mp4", "file3.mp4", "file4.mp4"}
+		model.currentFileIndex = 3
+		model.currentScreen = ScreenClassification
+		model.classificationData = NewClassificationData(appState, model.files, 3, "")
+		return model, intro, outro
+	}
+
+	t.Run("back and forward visit classified files", func(t *testing.T) {
+		model, _, _ := setup(t)
+
+		model = model.handleClassificationNavigate(-1)
+		model = model.handleClassificationNavigate(-1)
+		if model.currentFileIndex != 1 || model.classificationData.CurrentFile != "file2.mp4" {
+			t.Fatalf("expected file2.mp4, got %s", model.classificationData.CurrentFile)
+		}
+		if !model.classificationData.IsClassified || model.classificationData.GroupName != "intro" {
+			t.Errorf("expected classification to be shown, got %+v", model.classificationData)
+		}
+
+		model = model.handleClassificationNavigate(1)
+		if model.currentFileIndex != 2 || model.state.CurrentIndex != 2 {
+			t.Errorf("expected index 2, got %d", model.currentFileIndex)
+		}
+
+		// Bounds are respected
+		model.currentFileIndex = 0
+		if model.handleClassificationNavigate(-1).currentFileIndex != 0 {
+			t.Error("expected to stay on the first file")
+		}
+	})
+
+	t.Run("reassigning renumbers both groups and moves to the next file", func(t *testing.T) {
+		model, intro, outro := setup(t)
+		model = model.handleClassificationNavigate(-3) // file1.mp4
+
+		model = model.handleGroupSelected(outro.ID)
+
+		c1, _ := model.state.GetClassification("file1.mp4")
+		c2, _ := model.state.GetClassification("file2.mp4")
+		c3, _ := model.state.GetClassification("file3.mp4")
+		if c1.GroupID != outro.ID || c1.TakeNumber != 1 || c3.TakeNumber != 2 {
+			t.Errorf("expected file1 take 1 and file3 take 2 in outro, got %+v, %+v", c1, c3)
+		}
+		if c2.GroupID != intro.ID || c2.TakeNumber != 1 {
+			t.Errorf("expected file2 to become intro take 1, got %+v", c2)
+		}
+		if model.currentFileIndex != 1 {
+			t.Errorf("expected to move to the next file, got index %d", model.currentFileIndex)
+		}
+	})
+
+	t.Run("unclassify stays on the file", func(t *testing.T) {
+		model, _, _ := setup(t)
+		model = model.handleClassificationNavigate(-3)
+
+		model = model.handleClassificationUnclassify()
+
+		if _, ok := model.state.GetClassification("file1.mp4"); ok {
+			t.Error("expected file1.mp4 to be unclassified")
+		}
+		if c, _ := model.state.GetClassification("file2.mp4"); c.TakeNumber != 1 {
+			t.Errorf("expected file2 to become take 1, got %d", c.TakeNumber)
+		}
+		if model.currentFileIndex != 0 || model.classificationData.IsClassified {
+			t.Errorf("expected to stay on the unclassified file, got %+v", model.classificationData)
+		}
+	})
+
+	t.Run("arrow keys navigate through Update", func(t *testing.T) {
+		model, _, _ := setup(t)
+
+		updated, _ := model.Update(tea.KeyMsg{Type: tea.KeyLeft})
+		if updated.(Model).currentFileIndex != 2 {
+			t.Errorf("expected left arrow to move back, got index %d", updated.(Model).currentFileIndex)
+		}
+	})
+}
+
+// TestClassificationLogic_Undo tests undoing and redoing classification
+// actions through the model
+func TestClassificationLogic_Undo(t *testing.T) {
+	setup := func(t *testing.T) Model {
+		tmpDir := t.TempDir()
+		appState := state.NewState(tmpDir, state.SortByModifiedTime)
+		group := state.NewGroup("intro", 1)
+		appState.Groups = []state.Group{group}
+		appState.AddOrUpdateClassification("file1.mp4", group.ID)
+
+		model := NewModel(appState, tmpDir)
+		model.files = []string{"file1.mp4", "file2.mp4", "file3.mp4"}
+		model.currentFileIndex = 1
+		model.currentScreen = ScreenClassification
+		model.classificationData = NewClassificationData(appState, model.files, 1, "")
+		return model
+	}
+	press := func(m Model, msg tea.KeyMsg) Model {
+		updated, _ := m.Update(msg)
+		return updated.(Model)
+	}
+	undoKey := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("u")}
+	redoKey := tea.KeyMsg{Type: tea.KeyCtrlR}
+
+	t.Run("undo same as last returns to the file", func(t *testing.T) {
+		model := setup(t)
+
+		model = press(model, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("1")})
+		if _, ok := model.state.GetClassification("file2.mp4"); !ok || model.currentFileIndex != 2 {
+			t.Fatal("expected file2.mp4 to be classified")
+		}
+
+		model = press(model, undoKey)
+		if _, ok := model.state.GetClassification("file2.mp4"); ok {
+			t.Error("expected classification to be undone")
+		}
+		if model.currentFileIndex != 1 || model.classificationData.CurrentFile != "file2.mp4" {
+			t.Errorf("expected to return to file2.mp4, got %s", model.classificationData.CurrentFile)
+		}
+		if !strings.Contains(ClassificationView(model.classificationData), "Undid: classify file2.mp4") {
+			t.Error("expected undo notice in view")
+		}
+
+		model = press(model, redoKey)
+		if _, ok := model.state.GetClassification("file2.mp4"); !ok || model.currentFileIndex != 2 {
+			t.Error("expected redo to classify file2.mp4 again")
+		}
+	})
+
+	t.Run("undo skip", func(t *testing.T) {
+		model := setup(t)
+
+		model = press(model, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("s")})
+		model = press(model, undoKey)
+
+		if len(model.state.Skipped) != 0 || model.currentFileIndex != 1 {
+			t.Errorf("expected skip to be undone, got %v at %d", model.state.Skipped, model.currentFileIndex)
+		}
+	})
+
+	t.Run("undo group insertion removes the group", func(t *testing.T) {
+		model := setup(t)
+
+		updated, _ := model.Update(GroupInserted{GroupID: "new-group", GroupName: "outro", Order: 1})
+		model = updated.(Model)
+		if len(model.state.Groups) != 2 {
+			t.Fatalf("expected 2 groups, got %d", len(model.state.Groups))
+		}
+
+		model = press(model, undoKey)
+		if len(model.state.Groups) != 1 || model.state.Groups[0].Order != 1 {
+			t.Errorf("expected group insertion undone, got %+v", model.state.Groups)
+		}
+		if model.lastClassifiedGroupID != "" {
+			t.Error("expected same-as-last to forget the removed group")
+		}
+	})
+
+	t.Run("nothing to undo", func(t *testing.T) {
+		model := setup(t)
+
+		model = press(model, undoKey)
+		if model.classificationData.Notice != "Nothing to undo" {
+			t.Errorf("unexpected notice: %q", model.classificationData.Notice)
+		}
+	})
+}
+
+func TestClassificationLogic_Annotation(t *testing.T) {
+	tmpDir := t.TempDir()
+	appState := state.NewState(tmpDir, state.SortByModifiedTime)
+	group := state.NewGroup("intro", 1)
+	appState.Groups = []state.Group{group}
+	appState.AddOrUpdateClassification("file1.mp4", group.ID)
+
+	model := NewModel(appState, tmpDir)
+	model.files = []string{"file1.mp4", "file2.mp4", "file3.mp4"}
+	model.currentScreen = ScreenClassification
+	model.classificationData = NewClassificationData(appState, model.files, 0, "")
+	press := func(key string) {
+		updated, _ := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)})
+		model = updated.(Model)
+	}
+
+	// A classified file is annotated straight away, and can be undone
+	press("c")
+	press("+")
+	if c, _ := appState.GetClassification("file1.mp4"); !c.Circled || c.Rating != 1 {
+		t.Errorf("expected file1.mp4 to be circled and rated, got %+v", c.Annotation)
+	}
+	press("u")
+	if c, _ := appState.GetClassification("file1.mp4"); c.Rating != 0 || !c.Circled {
+		t.Errorf("expected the rating to be undone, got %+v", c.Annotation)
+	}
+
+	// A new file keeps its annotation until it is classified
+	updated, _ := model.Update(tea.KeyMsg{Type: tea.KeyRight})
+	model = updated.(Model)
+	press("c")
+	if _, ok := appState.GetClassification("file2.mp4"); ok {
+		t.Fatal("expected file2.mp4 to stay unclassified")
+	}
+	press("1")
+	if c, _ := appState.GetClassification("file2.mp4"); c.GroupID != group.ID || !c.Circled {
+		t.Errorf("expected file2.mp4 to be classified circled, got %+v", c)
+	}
+}
+
+func TestClassificationLogic_Suggestion(t *testing.T) {
+	appState, files := bulkFixture(t)
+	group := state.NewGroup("wide", 1)
+	appState.Groups = []state.Group{group}
+	appState.AddOrUpdateClassification("a.mp4", group.ID)
+
+	model := NewModel(appState, appState.Directory)
+	model.files = files
+	model.currentFileIndex = 1
+	model.currentScreen = ScreenClassification
+	model.classificationData = NewClassificationData(appState, files, 1, group.ID)
+	send := func(msg tea.Msg) {
+		t.Helper()
+		updated, cmd := model.Update(msg)
+		model = updated.(Model)
+		if cmd != nil {
+			if next := cmd(); next != nil {
+				updated, _ = model.Update(next)
+				model = updated.(Model)
+			}
+		}
+	}
+
+	// Another take a few seconds later: Enter means "same as last"
+	if model.classificationData.Suggestion != ClassificationActionSameAsLast {
+		t.Fatalf("expected same as last, got %v", model.classificationData.Suggestion)
+	}
+	send(tea.KeyMsg{Type: tea.KeyEnter})
+	send(tea.KeyMsg{Type: tea.KeyEnter})
+	if c, _ := appState.GetClassification("c.mp4"); c.GroupID != group.ID || c.TakeNumber != 3 {
+		t.Fatalf("expected c.mp4 as take 3, got %+v", c)
+	}
+
+	// After the long break Enter creates a group
+	if model.currentFileIndex != 3 || model.classificationData.Suggestion != ClassificationActionCreateGroup {
+		t.Fatalf("expected a new group suggested for d.mp4, got %v", model.classificationData.Suggestion)
+	}
+	if view := ClassificationView(model.classificationData); !strings.Contains(view, "likely a new setup") {
+		t.Errorf("expected the suggestion to be explained, got:\n%s", view)
+	}
+
+	// Raising the gap turns it into another take, and is saved
+	for range 4 {
+		send(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("]")})
+	}
+	if appState.SuggestGap != 32*time.Minute || model.classificationData.Suggestion != ClassificationActionSameAsLast {
+		t.Errorf("expected same as last with a 32m gap, got %v with %v", model.classificationData.Suggestion, appState.SuggestGap)
+	}
+	send(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("[")})
+	send(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("[")})
+	send(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("[")})
+	send(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("[")})
+	send(tea.KeyMsg{Type: tea.KeyEnter})
+	if model.currentScreen != ScreenGroupInsertion {
+		t.Errorf("expected Enter to create a group, got %v", model.currentScreen)
+	}
+}
+
+func TestClassificationLogic_Prefill(t *testing.T) {
+	appState, files := bulkFixture(t)
+
+	model := NewModel(appState, appState.Directory)
+	model.files = files
+	model.currentScreen = ScreenClassification
+	model.classificationData = NewClassificationData(appState, files, 0, "")
+
+	updated, _ := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("a")})
+	model = updated.(Model)
+	if model.currentScreen != ScreenReview {
+		t.Fatalf("expected the proposal on the review screen, got %v", model.currentScreen)
+	}
+	if len(appState.Groups) != 2 || len(appState.Classifications) != len(files) {
+		t.Fatalf("expected two groups for every clip, got %d groups and %d clips",
+			len(appState.Groups), len(appState.Classifications))
+	}
+	first, _ := appState.GetClassification("c.mp4")
+	second, _ := appState.GetClassification("d.mp4")
+	if first.GroupID == second.GroupID {
+		t.Error("expected the break to start a new group")
+	}
+
+	// The proposal is one undo step
+	updated, _ = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("u")})
+	model = updated.(Model)
+	if len(appState.Groups) != 0 || len(appState.Classifications) != 0 {
+		t.Errorf("expected the proposal to be undone, got %+v", appState.Classifications)
+	}
+}
+
+func TestClassificationLogic_RevisitSkipped(t *testing.T) {
+	tmpDir := t.TempDir()
+	appState := state.NewState(tmpDir, state.SortByName)
+	group := state.NewGroup("intro", 1)
+	appState.Groups = []state.Group{group}
+	files := []string{"file1.mp4", "file2.mp4", "file3.mp4", "file4.mp4"}
+	appState.AddOrUpdateClassification("file1.mp4", group.ID)
+	appState.AddOrUpdateClassification("file3.mp4", group.ID)
+	appState.Skip("file2.mp4")
+	appState.Skip("file4.mp4")
+
+	model := NewModel(appState, tmpDir)
+	model.files = files
+	model.currentFileIndex = len(files)
+	model.currentScreen = ScreenReview
+	model.reviewData = NewReviewData(appState, files)
+
+	press := func(key string) {
+		t.Helper()
+		updated, _ := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)})
+		model = updated.(Model)
+	}
+
+	press("s")
+	if model.currentScreen != ScreenClassification || model.currentFile() != "file2.mp4" {
+		t.Fatalf("expected to revisit file2.mp4, got %s on %v", model.currentFile(), model.currentScreen)
+	}
+	data := model.classificationData
+	if !data.RevisitSkipped || !data.IsSkipped || data.FilterPosition != 1 || data.FilterTotal != 2 {
+		t.Errorf("unexpected revisit progress %+v", data)
+	}
+
+	// Marking a skipped clip is undoable
+	press("d")
+	if appState.Disposition("file2.mp4") != state.DispositionReject {
+		t.Fatalf("expected file2.mp4 to be rejected, got %q", appState.Disposition("file2.mp4"))
+	}
+	press("u")
+	if appState.Disposition("file2.mp4") != state.DispositionKeep {
+		t.Errorf("expected undo to keep file2.mp4, got %q", appState.Disposition("file2.mp4"))
+	}
+
+	// Classifying takes the clip out of skipped and moves past the
+	// classified file3.mp4
+	model = model.handleGroupSelected(group.ID)
+	if appState.IsSkipped("file2.mp4") {
+		t.Error("expected classifying to unskip file2.mp4")
+	}
+	if model.currentFile() != "file4.mp4" {
+		t.Fatalf("expected the next skipped clip, got %s", model.currentFile())
+	}
+
+	// Skipping the last one again ends the pass
+	press("s")
+	if model.currentScreen != ScreenReview || model.revisitSkipped {
+		t.Errorf("expected the pass to end on review, got %v", model.currentScreen)
+	}
+	if !appState.IsSkipped("file4.mp4") || len(appState.Skipped) != 1 {
+		t.Errorf("expected only file4.mp4 to stay skipped, got %v", appState.Skipped)
+	}
+}
+
+func TestClassificationLogic_QueuePassesSkipped(t *testing.T) {
+	tmpDir := t.TempDir()
+	appState := state.NewState(tmpDir, state.SortByName)
+	appState.Skip("file1.mp4")
+
+	model := NewModel(appState, tmpDir)
+	updated, _ := model.Update(StartupInitialized{Scanned: []scanner.FileInfo{{Name: "file1.mp4"}, {Name: "file2.mp4"}}})
+	model = updated.(Model)
+	if model.currentFile() != "file2.mp4" {
+		t.Errorf("expected the queue to start after the skipped clip, got %s", model.currentFile())
+	}
+}
+
+func TestClassificationLogic_Reject(t *testing.T) {
+	tmpDir := t.TempDir()
+	appState := state.NewState(tmpDir, state.SortByName)
+
+	model := NewModel(appState, tmpDir)
+	model.files = []string{"file1.mp4", "file2.mp4"}
+	model.currentScreen = ScreenClassification
+	model.classificationData = NewClassificationData(appState, model.files, 0, "")
+
+	updated, _ := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("r")})
+	model = updated.(Model)
+	if !appState.IsSkipped("file1.mp4") || appState.Disposition("file1.mp4") != state.DispositionReject {
+		t.Fatalf("expected file1.mp4 to be rejected, got %v %v", appState.Skipped, appState.Dispositions)
+	}
+	if model.currentFile() != "file2.mp4" {
+		t.Errorf("expected to move on to file2.mp4, got %s", model.currentFile())
+	}
+
+	// Rejecting is one undo step
+	updated, _ = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("u")})
+	model = updated.(Model)
+	if appState.IsSkipped("file1.mp4") || len(appState.Dispositions) != 0 {
+		t.Errorf("expected undo to restore file1.mp4, got %v %v", appState.Skipped, appState.Dispositions)
+	}
+	if model.currentFile() != "file1.mp4" {
+		t.Errorf("expected undo to return to file1.mp4, got %s", model.currentFile())
+	}
+}
@@ -2,8 +2,11 @@
 package ui
 
 import (
+	"clip-tagger/metadata"
 	"clip-tagger/state"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestClassificationData_NewSession(t *testing.T) {
@@ -306,3 +309,131 @@ func TestClassificationUpdate_OtherKeys(t *testing.T) {
 		t.Errorf("expected no action (-2), got %v", result.Screen)
 	}
 }
+
+func TestClassificationView_ShowsMetadata(t *testing.T) {
+	appState := state.NewState("/test/dir", state.SortByModifiedTime)
+	recorded := time.Date(2026, 1, 12, 14, 3, 22, 0, time.UTC)
+	appState.CacheMetadata("file1.mp4", 10, time.Now(), metadata.Metadata{
+		RecordedTime: recorded,
+		Width:        3840,
+		Height:       2160,
+		Codec:        "H.265",
+		Timecode:     "01:00:00:00",
+	})
+
+	data := NewClassificationData(appState, []string{"file1.mp4", "file2.mp4"}, 0, "")
+	if data.Metadata == nil {
+		t.Fatal("expected metadata to be loaded from state")
+	}
+
+	view := ClassificationView(data)
+	for _, expected := range []string{"2026-01-12 14:03:22", "3840x2160", "H.265", "TC 01:00:00:00"} {
+		if !strings.Contains(view, expected) {
+			t.Errorf("expected view to contain %q", expected)
+		}
+	}
+
+	// A file without metadata shows no media line
+	data = NewClassificationData(appState, []string{"file1.mp4", "file2.mp4"}, 1, "")
+	if strings.Contains(ClassificationView(data), "Media:") {
+		t.Error("expected no media line for file without metadata")
+	}
+}
+
+func TestClassificationUpdate_Navigation(t *testing.T) {
+	appState := state.NewState("/test/dir", state.SortByModifiedTime)
+	files := []string{"file1.mp4", "file2.mp4", "file3.mp4"}
+
+	first := NewClassificationData(appState, files, 0, "")
+	if result := ClassificationUpdate(first, "left"); result.Action != ClassificationActionNone {
+		t.Errorf("expected left on the first file to do nothing, got %v", result.Action)
+	}
+	if result := ClassificationUpdate(first, "right"); result.Action != ClassificationActionNext || result.Screen != -2 {
+		t.Errorf("expected next action, got %+v", result)
+	}
+
+	last := NewClassificationData(appState, files, 2, "")
+	if result := ClassificationUpdate(last, "right"); result.Action != ClassificationActionNone {
+		t.Errorf("expected right on the last file to do nothing, got %v", result.Action)
+	}
+	if result := ClassificationUpdate(last, "left"); result.Action != ClassificationActionPrevious || result.Screen != -2 {
+		t.Errorf("expected previous action, got %+v", result)
+	}
+}
+
+func TestClassificationView_ShowsCurrentClassification(t *testing.T) {
+	appState := state.NewState("/test/dir", state.SortByModifiedTime)
+	group := state.NewGroup("card trick", 1)
+	appState.Groups = []state.Group{group}
+	appState.AddOrUpdateClassification("file1.mp4", group.ID)
+	appState.AddOrUpdateClassification("file2.mp4", group.ID)
+
+	data := NewClassificationData(appState, []string{"file1.mp4", "file2.mp4", "file3.mp4"}, 1, "")
+	if !data.IsClassified || data.GroupName != "card trick" || data.TakeNumber != 2 {
+		t.Fatalf("unexpected classification data: %+v", data)
+	}
+
+	view := ClassificationView(data)
+	if !strings.Contains(view, "card trick") || !strings.Contains(view, "take 2") {
+		t.Error("expected view to show the current group and take")
+	}
+	if !strings.Contains(view, "'x' - Unclassify") {
+		t.Error("expected unclassify hint for a classified file")
+	}
+	if result := ClassificationUpdate(data, "x"); result.Action != ClassificationActionUnclassify {
+		t.Errorf("expected unclassify action, got %v", result.Action)
+	}
+
+	// Unclassified files offer no unclassify action
+	data = NewClassificationData(appState, []string{"file1.mp4", "file2.mp4", "file3.mp4"}, 2, "")
+	if data.IsClassified || strings.Contains(ClassificationView(data), "Unclassify") {
+		t.Error("expected no classification for file3.mp4")
+	}
+}
+
+func TestClassificationUpdate_Annotation(t *testing.T) {
+	appState := state.NewState("/test/dir", state.SortByModifiedTime)
+	data := NewClassificationData(appState, []string{"file1.mp4"}, 0, "")
+
+	if result := ClassificationUpdate(data, "c"); result.Action != ClassificationActionAnnotate || !data.Annotation.Circled {
+		t.Errorf("expected c to circle the take, got %+v", result)
+	}
+	if result := ClassificationUpdate(data, "-"); result.Action != ClassificationActionNone {
+		t.Error("expected rating not to go below 0")
+	}
+	for i := 0; i < state.MaxRating+2; i++ {
+		ClassificationUpdate(data, "+")
+	}
+	if data.Annotation.Rating != state.MaxRating {
+		t.Errorf("expected rating capped at %d, got %d", state.MaxRating, data.Annotation.Rating)
+	}
+
+	ClassificationUpdate(data, "n")
+	if !data.EditingNotes {
+		t.Fatal("expected notes entry")
+	}
+	// Keys are typed while editing, including ones that are actions otherwise
+	for _, key := range []string{"q", "u", "i", "t", " ", "x"} {
+		if result := ClassificationUpdate(data, key); result.Screen != -2 {
+			t.Fatalf("expected %q to be typed, got %+v", key, result)
+		}
+	}
+	ClassificationUpdate(data, "backspace")
+	if result := ClassificationUpdate(data, "enter"); result.Action != ClassificationActionAnnotate || data.Annotation.Notes != "quit" {
+		t.Errorf("expected notes to be saved, got %+v %q", result, data.Annotation.Notes)
+	}
+
+	view := ClassificationView(data)
+	for _, expected := range []string{"★★★★★", "circled", `"quit"`} {
+		if !strings.Contains(view, expected) {
+			t.Errorf("expected view to contain %q", expected)
+		}
+	}
+
+	ClassificationUpdate(data, "n")
+	ClassificationUpdate(data, "z")
+	ClassificationUpdate(data, "esc")
+	if data.EditingNotes || data.Annotation.Notes != "quit" {
+		t.Errorf("expected esc to discard the edit, got %q", data.Annotation.Notes)
+	}
+}
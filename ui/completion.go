@@ -6,6 +6,7 @@ import (
 	"clip-tagger/state"
 	"fmt"
 	"path/filepath"
+	"slices"
 	"time"
 )
 
@@ -19,12 +20,19 @@ const (
 
 // CompletionData contains the data needed to render the completion screen
 type CompletionData struct {
+	Directory       string
 	Renames         []renamer.Rename
+	Rejects         []renamer.Rename // Skipped clips moved to the rejects folder or the trash, rename mode only
+	RejectFolder    string           // Where rejects go, "" for the trash
+	RejectError     error            // Why rejects can't be moved; they stay in place
+	ToDelete        []string         // Skipped clips marked for deletion, listed but never deleted
 	Conflicts       []renamer.Rename
 	HasConflicts    bool
 	SelectedMode    int
 	OutputDirectory string
 	ExecutionResult *CompletionExecutionResult
+	Width           int    // Terminal width, 0 if unknown
+	Keys            Keymap // Active key bindings for the hints, nil for the defaults
 }
 
 // CompletionExecutionResult contains the result of executing rename operations
@@ -33,6 +41,7 @@ type CompletionExecutionResult struct {
 	FilesChanged int
 	Mode         string
 	Error        error
+	Warning      string // Set when the files changed but the history couldn't be recorded
 }
 
 // CompletionUpdateResult contains the result of a completion update
@@ -44,21 +53,14 @@ type CompletionUpdateResult struct {
 func NewCompletionData(appState *state.State) *CompletionData {
 	// Build list of rename operations from classifications
 	var renames []renamer.Rename
+	numbers := appState.NameNumbers()
 	for _, classification := range appState.Classifications {
-		group := appState.FindGroupByID(classification.GroupID)
-		if group == nil {
+		targetPath, ok := appState.TargetPath(classification, numbers)
+		if !ok {
 			// Skip if group not found (shouldn't happen)
 			continue
 		}
-
 		originalPath := filepath.Join(appState.Directory, classification.File)
-		targetPath := renamer.GenerateTargetPath(
-			appState.Directory,
-			originalPath,
-			group.Order,
-			classification.TakeNumber,
-			group.Name,
-		)
 
 		renames = append(renames, renamer.Rename{
 			OriginalPath: originalPath,
@@ -66,15 +68,23 @@ func NewCompletionData(appState *state.State) *CompletionData {
 		})
 	}
 
+	// Skipped clips the user rejected
+	rejects, rejectErr := rejectRenames(appState)
+
 	// Detect conflicts
-	conflicts := renamer.DetectConflicts(renames)
+	conflicts := renamer.DetectConflicts(append(slices.Clone(renames), rejects...))
 
 	// Generate output directory name with timestamp
 	timestamp := time.Now().Format("2006-01-02_15-04-05")
 	outputDir := filepath.Join(appState.Directory, fmt.Sprintf("renamed_%s", timestamp))
 
 	return &CompletionData{
+		Directory:       appState.Directory,
 		Renames:         renames,
+		Rejects:         rejects,
+		RejectFolder:    appState.RejectFolder(),
+		RejectError:     rejectErr,
+		ToDelete:        appState.SkippedWith(state.DispositionDelete),
 		Conflicts:       conflicts,
 		HasConflicts:    len(conflicts) > 0,
 		SelectedMode:    0, // Default to rename in place
@@ -83,6 +93,44 @@ func NewCompletionData(appState *state.State) *CompletionData {
 	}
 }
 
+// rejectRenames moves the rejected clips to the session's rejects folder,
+// or to free names in the trash. The trash is only created once the renames
+// run, see executeOperation.
+func rejectRenames(appState *state.State) ([]renamer.Rename, error) {
+	files := appState.SkippedWith(state.DispositionReject)
+	if len(files) == 0 {
+		return nil, nil
+	}
+
+	originals := make([]string, len(files))
+	targets := make([]string, len(files))
+	for i, file := range files {
+		originals[i] = filepath.Join(appState.Directory, file)
+		targets[i] = appState.RejectPath(file)
+	}
+	if appState.RejectFolder() == "" {
+		var err error
+		if targets, err = renamer.TrashTargets(originals); err != nil {
+			return nil, err
+		}
+	}
+
+	rejects := make([]renamer.Rename, len(files))
+	for i := range files {
+		rejects[i] = renamer.Rename{OriginalPath: originals[i], TargetPath: targets[i], Reject: true}
+	}
+	return rejects, nil
+}
+
+// operations returns the file operations the selected mode performs. Copy
+// mode only copies classified clips, so rejects are left where they are.
+func (data *CompletionData) operations() []renamer.Rename {
+	if data.SelectedMode == int(CompletionModeCopyToDirectory) {
+		return data.Renames
+	}
+	return append(slices.Clone(data.Renames), data.Rejects...)
+}
+
 // CompletionView renders the completion screen
 func CompletionView(data *CompletionData) string {
 	var output string
@@ -120,6 +168,26 @@ func CompletionView(data *CompletionData) string {
 
 	output += "\n"
 
+	// Skipped clips with a disposition
+	if len(data.Rejects) > 0 {
+		destination := data.RejectFolder + "/"
+		if data.RejectFolder == "" {
+			destination = "the trash"
+		}
+		output += RenderWarning(fmt.Sprintf("%d rejected clip(s) will be moved to %s (rename in place only)",
+			len(data.Rejects), destination)) + "\n\n"
+	}
+	if data.RejectError != nil {
+		output += RenderDanger(fmt.Sprintf("Rejected clips will stay in place: %v", data.RejectError)) + "\n\n"
+	}
+	if len(data.ToDelete) > 0 {
+		output += RenderWarning(fmt.Sprintf("%d skipped clip(s) marked for deletion; delete them yourself:", len(data.ToDelete))) + "\n"
+		for _, file := range data.ToDelete {
+			output += fmt.Sprintf("  %s %s\n", RenderMuted("-"), truncateMiddle(file, nameWidth(data.Width, 4)))
+		}
+		output += "\n"
+	}
+
 	// Show conflict warning if any
 	if data.HasConflicts {
 		output += RenderDanger("WARNING: Conflicts Detected!") + "\n"
@@ -135,23 +203,25 @@ func CompletionView(data *CompletionData) string {
 			}
 			output += fmt.Sprintf("  %s %s %s %s\n",
 				RenderMuted("-"),
-				RenderMuted(filepath.Base(conflict.OriginalPath)),
+				RenderMuted(truncateMiddle(filepath.Base(conflict.OriginalPath), nameWidth(data.Width, 8)/2)),
 				RenderMuted("->"),
-				filepath.Base(conflict.TargetPath))
+				truncateMiddle(filepath.Base(conflict.TargetPath), nameWidth(data.Width, 8)/2))
 		}
 
 		output += "\n"
-		output += RenderDanger("These files will be OVERWRITTEN if you proceed.") + "\n\n"
+		output += RenderDanger("Rename in place will refuse to run until these are resolved.") + "\n"
+		output += RenderMuted("Existing files are never overwritten; copy mode is unaffected.") + "\n\n"
 	}
 
 	// Instructions
+	key := data.Keys.hints(KeysCompletion)
 	output += RenderMuted("Controls:") + "\n"
-	output += RenderKeyHint("  Up/Down - Select mode") + "\n"
-	output += RenderKeyHint("  Enter - Execute operation") + "\n"
+	output += RenderKeyHint(fmt.Sprintf("  %s/%s - Select mode", key("up"), key("down"))) + "\n"
+	output += RenderKeyHint("  "+key("confirm")+" - Execute operation") + "\n"
 	if data.HasConflicts {
-		output += RenderKeyHint("  Esc - Go back (abort)") + "\n"
+		output += RenderKeyHint("  "+key("back")+" - Go back (abort)") + "\n"
 	}
-	output += RenderKeyHint("  q - Quit") + "\n"
+	output += RenderKeyHint("  "+key("quit")+" - Quit") + "\n"
 
 	return output
 }
@@ -167,6 +237,11 @@ func renderExecutionResult(result *CompletionExecutionResult) string {
 			RenderMuted("Files changed:"),
 			RenderSuccess(fmt.Sprintf("%d", result.FilesChanged)))
 		output += RenderSuccess("All files have been successfully renamed.") + "\n\n"
+		if result.Warning != "" {
+			output += RenderWarning(result.Warning) + "\n\n"
+		} else {
+			output += RenderMuted("Run 'clip-tagger undo <directory>' to restore the original names.") + "\n\n"
+		}
 	} else {
 		output += RenderDanger("=== Error ===") + "\n\n"
 		output += RenderDanger("Failed to complete operation.") + "\n\n"
@@ -228,7 +303,8 @@ func executeOperation(data *CompletionData) {
 	filesChanged := 0
 
 	// Count actual files that will be changed (exclude no-ops)
-	for _, r := range data.Renames {
+	operations := data.operations()
+	for _, r := range operations {
 		if r.OriginalPath != r.TargetPath {
 			filesChanged++
 		}
@@ -236,10 +312,14 @@ func executeOperation(data *CompletionData) {
 
 	if data.SelectedMode == int(CompletionModeRenameInPlace) {
 		mode = "Rename in place"
-		err = renamer.RenameInPlace(data.Renames)
+		journalPath := filepath.Join(data.Directory, renamer.JournalFileName)
+		err = renamer.CreateTrash(operations)
+		if err == nil {
+			err = renamer.RenameInPlaceWithJournal(operations, journalPath)
+		}
 	} else {
 		mode = "Copy to new directory"
-		err = renamer.CopyToDirectory(data.Renames, data.OutputDirectory)
+		err = renamer.CopyTreeToDirectory(operations, data.Directory, data.OutputDirectory)
 	}
 
 	data.ExecutionResult = &CompletionExecutionResult{
@@ -248,26 +328,53 @@ func executeOperation(data *CompletionData) {
 		Mode:         mode,
 		Error:        err,
 	}
+
+	if err == nil {
+		if recordErr := recordBatch(data); recordErr != nil {
+			data.ExecutionResult.Warning = fmt.Sprintf("Could not record this batch for undo: %v", recordErr)
+		}
+	}
 }
 
-// updateStateAfterRename updates state Classifications to use new filenames after successful rename
+// recordBatch appends the executed operation to the session's rename history
+func recordBatch(data *CompletionData) error {
+	historyPath := filepath.Join(data.Directory, renamer.HistoryFileName)
+	history, err := renamer.LoadHistory(historyPath)
+	if err != nil {
+		return err
+	}
+
+	batchMode := renamer.BatchModeRename
+	if data.SelectedMode == int(CompletionModeCopyToDirectory) {
+		batchMode = renamer.BatchModeCopy
+	}
+
+	if _, err := history.Record(batchMode, data.Directory, data.OutputDirectory, data.operations()); err != nil {
+		return err
+	}
+	return history.Save(historyPath)
+}
+
+// updateStateAfterRename updates state Classifications to use new filenames after successful rename.
+// Rejected clips among the renames were moved out and leave the session.
 func updateStateAfterRename(appState *state.State, renames []renamer.Rename, mode string, outputDir string) {
-	// Build mapping from old filename to new filename
+	// Build mapping from old filename to new filename, both relative to the
+	// session root (copy mode recreates the same relative layout in outputDir)
 	filenameMap := make(map[string]string)
 	for _, r := range renames {
-		oldFilename := filepath.Base(r.OriginalPath)
-		newFilename := filepath.Base(r.TargetPath)
+		oldFilename := renamer.RelativePath(appState.Directory, r.OriginalPath)
+		newFilename := renamer.RelativePath(appState.Directory, r.TargetPath)
+		if r.Reject {
+			appState.Unskip(oldFilename)
+			continue
+		}
 		if oldFilename != newFilename {
 			filenameMap[oldFilename] = newFilename
 		}
 	}
 
-	// Update all Classifications to use new filenames
-	for i := range appState.Classifications {
-		if newFilename, exists := filenameMap[appState.Classifications[i].File]; exists {
-			appState.Classifications[i].File = newFilename
-		}
-	}
+	// Update all Classifications and cached metadata to use new filenames
+	appState.RenameFiles(filenameMap)
 
 	// If copy to directory mode, update the directory path
 	if mode == "Copy to new directory" {
@@ -6,6 +6,7 @@ import (
 	"clip-tagger/state"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
 	"testing"
 )
@@ -249,6 +250,19 @@ func TestCompletionUpdateExecuteRenameInPlace(t *testing.T) {
 	if _, err := os.Stat(srcFile); !os.IsNotExist(err) {
 		t.Error("source file should not exist")
 	}
+
+	// Check the batch was recorded for undo
+	history, err := renamer.LoadHistory(filepath.Join(tmpDir, renamer.HistoryFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	batch := history.Latest()
+	if batch == nil || batch.Mode != renamer.BatchModeRename || len(batch.Entries) != 1 {
+		t.Fatalf("expected one recorded rename batch, got %+v", batch)
+	}
+	if batch.Entries[0].Original != "clip1.mp4" {
+		t.Errorf("expected original clip1.mp4, got %s", batch.Entries[0].Original)
+	}
 }
 
 func TestCompletionUpdateExecuteCopyToDirectory(t *testing.T) {
@@ -444,3 +458,139 @@ func TestCompletionViewAfterFailedExecution(t *testing.T) {
 		t.Error("view should show the error details")
 	}
 }
+
+func TestUpdateStateAfterRename_Subdirectories(t *testing.T) {
+	tmpDir := t.TempDir()
+	appState := state.NewState(tmpDir, state.SortByName)
+	appState.Classifications = []state.Classification{
+		{File: "DCIM/MVI_0001.MOV", GroupID: "g1", TakeNumber: 1},
+		{File: "DCIM/MVI_0002.MOV", GroupID: "g1", TakeNumber: 2},
+	}
+
+	renames := []renamer.Rename{
+		{
+			OriginalPath: filepath.Join(tmpDir, "DCIM", "MVI_0001.MOV"),
+			TargetPath:   filepath.Join(tmpDir, "DCIM", "[01_01] intro.MOV"),
+		},
+		{
+			OriginalPath: filepath.Join(tmpDir, "DCIM", "MVI_0002.MOV"),
+			TargetPath:   filepath.Join(tmpDir, "[01_02] intro.MOV"),
+		},
+	}
+
+	updateStateAfterRename(appState, renames, "Rename in place", "")
+
+	if appState.Classifications[0].File != "DCIM/[01_01] intro.MOV" {
+		t.Errorf("expected preserved subfolder, got %s", appState.Classifications[0].File)
+	}
+	if appState.Classifications[1].File != "[01_02] intro.MOV" {
+		t.Errorf("expected flattened file, got %s", appState.Classifications[1].File)
+	}
+}
+
+func TestCompletionUpdateMovesRejects(t *testing.T) {
+	tmpDir := t.TempDir()
+	appState := state.NewState(tmpDir, state.SortByName)
+	group := state.NewGroup("intro", 1)
+	appState.Groups = []state.Group{group}
+	appState.AddOrUpdateClassification("clip1.mp4", group.ID)
+	for _, f := range []string{"clip2.mp4", "clip3.mp4", "clip4.mp4"} {
+		appState.Skip(f)
+	}
+	appState.SetDisposition("clip2.mp4", state.DispositionReject)
+	appState.SetDisposition("clip3.mp4", state.DispositionDelete)
+
+	for _, f := range []string{"clip1.mp4", "clip2.mp4", "clip3.mp4", "clip4.mp4"} {
+		if err := os.WriteFile(filepath.Join(tmpDir, f), []byte(f), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	data := NewCompletionData(appState)
+	if len(data.Rejects) != 1 || len(data.ToDelete) != 1 || data.ToDelete[0] != "clip3.mp4" {
+		t.Fatalf("unexpected rejects %v, to delete %v", data.Rejects, data.ToDelete)
+	}
+	view := CompletionView(data)
+	if !strings.Contains(view, "moved to _rejected/") || !strings.Contains(view, "clip3.mp4") {
+		t.Errorf("expected the view to list rejects and deletions, got:\n%s", view)
+	}
+
+	CompletionUpdate(data, "enter")
+	if data.ExecutionResult == nil || !data.ExecutionResult.Success {
+		t.Fatalf("expected success, got %+v", data.ExecutionResult)
+	}
+	if data.ExecutionResult.FilesChanged != 2 {
+		t.Errorf("expected 2 files changed, got %d", data.ExecutionResult.FilesChanged)
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, state.RejectsDir, "clip2.mp4")); err != nil {
+		t.Error("expected the rejected clip in the rejects folder")
+	}
+	for _, f := range []string{"clip3.mp4", "clip4.mp4"} {
+		if _, err := os.Stat(filepath.Join(tmpDir, f)); err != nil {
+			t.Errorf("expected %s to stay in place", f)
+		}
+	}
+
+	updateStateAfterRename(appState, data.operations(), data.ExecutionResult.Mode, data.OutputDirectory)
+	if appState.IsSkipped("clip2.mp4") {
+		t.Error("expected the rejected clip to leave the session")
+	}
+	if !appState.IsSkipped("clip3.mp4") || appState.Disposition("clip3.mp4") != state.DispositionDelete {
+		t.Error("expected the clip marked for deletion to stay skipped")
+	}
+}
+
+func TestCompletionCopyIgnoresRejects(t *testing.T) {
+	tmpDir := t.TempDir()
+	appState := state.NewState(tmpDir, state.SortByName)
+	appState.Skip("clip1.mp4")
+	appState.SetDisposition("clip1.mp4", state.DispositionReject)
+
+	data := NewCompletionData(appState)
+	data.SelectedMode = int(CompletionModeCopyToDirectory)
+	if ops := data.operations(); len(ops) != 0 {
+		t.Errorf("expected copy mode to leave rejects alone, got %v", ops)
+	}
+}
+
+func TestCompletionCreatesTrashOnlyWhenRenaming(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("the trash is only supported on Linux")
+	}
+	tmpDir := t.TempDir()
+	trash := filepath.Join(tmpDir, "data", "Trash")
+	t.Setenv("XDG_DATA_HOME", filepath.Join(tmpDir, "data"))
+	root := filepath.Join(tmpDir, "card")
+	if err := os.MkdirAll(root, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "clip1.mp4"), []byte("clip1"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	appState := state.NewState(root, state.SortByName)
+	appState.RejectTo = state.RejectToTrash
+	appState.Skip("clip1.mp4")
+	appState.SetDisposition("clip1.mp4", state.DispositionReject)
+
+	// Opening the screen, leaving it or copying doesn't touch the trash
+	data := NewCompletionData(appState)
+	if data.RejectError != nil || len(data.Rejects) != 1 {
+		t.Fatalf("expected one reject, got %v (%v)", data.Rejects, data.RejectError)
+	}
+	CompletionUpdate(data, "esc")
+	data.SelectedMode = int(CompletionModeCopyToDirectory)
+	CompletionUpdate(data, "enter")
+	if _, err := os.Stat(trash); !os.IsNotExist(err) {
+		t.Fatalf("expected no trash before renaming, got %v", err)
+	}
+
+	data = NewCompletionData(appState)
+	CompletionUpdate(data, "enter")
+	if data.ExecutionResult == nil || !data.ExecutionResult.Success {
+		t.Fatalf("expected success, got %+v", data.ExecutionResult)
+	}
+	if _, err := os.Stat(filepath.Join(trash, "files", "clip1.mp4")); err != nil {
+		t.Errorf("expected the reject in the trash: %v", err)
+	}
+}
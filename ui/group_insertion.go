@@ -18,17 +18,28 @@ const (
 	ModeGroupSelection
 )
 
+// insertionOptions are the choices offered for where a new group goes
+var insertionOptions = []string{
+	"1. Add to end",
+	"2. Insert after existing group",
+	"3. Add inside existing group (sub-group)",
+}
+
 // GroupInsertionData contains the data needed to render the group insertion screen
 type GroupInsertionData struct {
 	CurrentFile      string
 	Mode             GroupInsertionMode
 	GroupName        string
 	ExistingGroups   []state.Group
-	FilteredGroups   []state.Group // Filtered list based on FilterQuery
-	FilterQuery      string        // Query text for filtering groups
-	SelectedPosition int           // Index for cursor position in choice/selection modes
-	ScrollOffset     int           // Track scroll position for group lists
-	ViewportHeight   int           // Number of items to show (default: 10)
+	FilteredGroups   []state.Group     // Filtered list based on FilterQuery
+	FilterQuery      string            // Query text for filtering groups
+	Numbers          map[string]string // Displayed group numbers, e.g. "3.2"
+	SubGroup         bool              // The group being picked becomes the parent
+	SelectedPosition int               // Index for cursor position in choice/selection modes
+	ScrollOffset     int               // Track scroll position for group lists
+	ViewportHeight   int               // Number of items to show (default: 10)
+	Width            int               // Terminal width, 0 if unknown
+	Keys             Keymap            // Active key bindings for the hints, nil for the defaults
 }
 
 // GroupInsertionUpdateResult contains the result of a group insertion update
@@ -37,17 +48,20 @@ type GroupInsertionUpdateResult struct {
 	InsertedGroupID   string
 	InsertedGroupName string
 	InsertedOrder     int
+	InsertedParentID  string // Parent group for a sub-group, "" at the top level
 }
 
 // NewGroupInsertionData creates group insertion data from state and current file
 func NewGroupInsertionData(appState *state.State, currentFile string) *GroupInsertionData {
+	groups := appState.OrderedGroups()
 	return &GroupInsertionData{
 		CurrentFile:      currentFile,
 		Mode:             ModeNameEntry,
 		GroupName:        "",
-		ExistingGroups:   appState.Groups,
-		FilteredGroups:   appState.Groups, // Initially show all groups
+		ExistingGroups:   groups,
+		FilteredGroups:   groups, // Initially show all groups
 		FilterQuery:      "",
+		Numbers:          groupNumbers(appState),
 		SelectedPosition: 0,
 		ScrollOffset:     0,
 		ViewportHeight:   10,
@@ -57,28 +71,19 @@ func NewGroupInsertionData(appState *state.State, currentFile string) *GroupInse
 // GroupInsertionView renders the group insertion screen
 func GroupInsertionView(data *GroupInsertionData) string {
 	var output strings.Builder
-
-	// Header
-	output.WriteString(RenderHeader("=== Group Insertion ===") + "\n\n")
-	output.WriteString(fmt.Sprintf("%s %s\n\n", RenderMuted("Classifying:"), RenderSubheader(data.CurrentFile)))
+	output.WriteString(groupInsertionHeader(data))
 
 	switch data.Mode {
 	case ModeNameEntry:
-		// Name entry mode
-		output.WriteString(RenderHighlight("Enter new group name:") + "\n")
-		output.WriteString(fmt.Sprintf("%s %s\n\n", RenderCursor(">"), RenderSubheader(data.GroupName)))
-
 		// Show existing groups if any (with viewport to keep input on screen)
 		if len(data.ExistingGroups) > 0 {
-			output.WriteString(RenderMuted("Existing groups:") + "\n")
-
 			// Show maximum 5 groups to keep input on screen
-			maxShow := 5
+			maxShow := min(5, data.ViewportHeight)
 			if len(data.ExistingGroups) > maxShow {
 				for i := 0; i < maxShow; i++ {
 					group := data.ExistingGroups[i]
 					output.WriteString(fmt.Sprintf("  %s %s\n",
-						RenderMuted(fmt.Sprintf("[%d]", group.Order)),
+						groupTag(data.Numbers, group),
 						group.Name))
 				}
 				remaining := len(data.ExistingGroups) - maxShow
@@ -86,52 +91,25 @@ func GroupInsertionView(data *GroupInsertionData) string {
 			} else {
 				for _, group := range data.ExistingGroups {
 					output.WriteString(fmt.Sprintf("  %s %s\n",
-						RenderMuted(fmt.Sprintf("[%d]", group.Order)),
+						groupTag(data.Numbers, group),
 						group.Name))
 				}
 			}
 			output.WriteString("\n")
 		}
 
-		output.WriteString(RenderMuted("Instructions:") + "\n")
-		output.WriteString(RenderKeyHint("  Type to enter group name") + "\n")
-		output.WriteString(RenderKeyHint("  Enter to proceed") + "\n")
-		output.WriteString(RenderKeyHint("  Backspace to delete characters") + "\n")
-		output.WriteString(RenderKeyHint("  Esc to cancel") + "\n")
-		output.WriteString(RenderKeyHint("  Ctrl+C to quit") + "\n")
-
 	case ModeInsertionChoice:
-		// Insertion choice mode
-		output.WriteString(RenderHighlight(fmt.Sprintf("Where should \"%s\" be added?", data.GroupName)) + "\n\n")
-
 		// Show options
-		options := []string{
-			"1. Add to end",
-			"2. Insert after existing group",
-		}
-
-		for i, option := range options {
+		for i, option := range insertionOptions {
 			if i == data.SelectedPosition {
 				output.WriteString(fmt.Sprintf("%s %s\n", RenderCursor(">"), RenderHighlight(option)))
 			} else {
 				output.WriteString(fmt.Sprintf("  %s\n", option))
 			}
 		}
-
 		output.WriteString("\n")
-		output.WriteString(RenderMuted("Instructions:") + "\n")
-		output.WriteString(RenderKeyHint("  1-2 or Up/Down to select") + "\n")
-		output.WriteString(RenderKeyHint("  Enter to confirm") + "\n")
-		output.WriteString(RenderKeyHint("  Esc to go back") + "\n")
-		output.WriteString(RenderKeyHint("  Ctrl+C to quit") + "\n")
 
 	case ModeGroupSelection:
-		// Group selection mode
-		output.WriteString(RenderHighlight("Select group to insert after:") + "\n\n")
-
-		// Filter input
-		output.WriteString(fmt.Sprintf("%s %s\n\n", RenderMuted("Filter:"), RenderSubheader(data.FilterQuery)))
-
 		// Show filtered groups with viewport
 		if len(data.FilteredGroups) == 0 {
 			output.WriteString(RenderWarning("No groups match your filter.") + "\n")
@@ -154,11 +132,11 @@ func GroupInsertionView(data *GroupInsertionData) string {
 				if i == data.SelectedPosition {
 					output.WriteString(fmt.Sprintf("%s %s %s\n",
 						RenderCursor(">"),
-						RenderMuted(fmt.Sprintf("[%d]", group.Order)),
+						groupTag(data.Numbers, group),
 						RenderHighlight(group.Name)))
 				} else {
 					output.WriteString(fmt.Sprintf("  %s %s\n",
-						RenderMuted(fmt.Sprintf("[%d]", group.Order)),
+						groupTag(data.Numbers, group),
 						group.Name))
 				}
 			}
@@ -168,16 +146,77 @@ func GroupInsertionView(data *GroupInsertionData) string {
 				output.WriteString(RenderMuted("  ... (more items below)") + "\n")
 			}
 		}
-
 		output.WriteString("\n")
-		output.WriteString(RenderMuted("Instructions:") + "\n")
+	}
+
+	output.WriteString(groupInsertionFooter(data))
+	return output.String()
+}
+
+// groupInsertionHeader renders the current file and the current mode's
+// prompt above its list
+func groupInsertionHeader(data *GroupInsertionData) string {
+	var output strings.Builder
+
+	// Header
+	output.WriteString(RenderHeader("=== Group Insertion ===") + "\n\n")
+	output.WriteString(fmt.Sprintf("%s %s\n\n", RenderMuted("Classifying:"), RenderSubheader(truncateMiddle(data.CurrentFile, nameWidth(data.Width, 13)))))
+
+	switch data.Mode {
+	case ModeNameEntry:
+		// Name entry mode
+		output.WriteString(RenderHighlight("Enter new group name:") + "\n")
+		output.WriteString(fmt.Sprintf("%s %s\n\n", RenderCursor(">"), RenderSubheader(data.GroupName)))
+		if len(data.ExistingGroups) > 0 {
+			output.WriteString(RenderMuted("Existing groups:") + "\n")
+		}
+
+	case ModeInsertionChoice:
+		// Insertion choice mode
+		output.WriteString(RenderHighlight(fmt.Sprintf("Where should \"%s\" be added?", data.GroupName)) + "\n\n")
+
+	case ModeGroupSelection:
+		// Group selection mode
+		if data.SubGroup {
+			output.WriteString(RenderHighlight(fmt.Sprintf("Select group to add \"%s\" inside:", data.GroupName)) + "\n\n")
+		} else {
+			output.WriteString(RenderHighlight("Select group to insert after:") + "\n\n")
+		}
+
+		// Filter input
+		output.WriteString(fmt.Sprintf("%s %s\n\n", RenderMuted("Filter:"), RenderSubheader(data.FilterQuery)))
+	}
+
+	return output.String()
+}
+
+// groupInsertionFooter renders the current mode's instructions below its
+// list
+func groupInsertionFooter(data *GroupInsertionData) string {
+	var output strings.Builder
+	key := data.Keys.hints(KeysGroupInsertion)
+
+	output.WriteString(RenderMuted("Instructions:") + "\n")
+	switch data.Mode {
+	case ModeNameEntry:
+		output.WriteString(RenderKeyHint("  Type to enter group name") + "\n")
+		output.WriteString(RenderKeyHint("  "+key("select")+" to proceed") + "\n")
+		output.WriteString(RenderKeyHint("  Backspace to delete characters") + "\n")
+		output.WriteString(RenderKeyHint("  "+key("back")+" to cancel") + "\n")
+
+	case ModeInsertionChoice:
+		output.WriteString(RenderKeyHint(fmt.Sprintf("  1-3 or %s/%s to select", key("up"), key("down"))) + "\n")
+		output.WriteString(RenderKeyHint("  "+key("select")+" to confirm") + "\n")
+		output.WriteString(RenderKeyHint("  "+key("back")+" to go back") + "\n")
+
+	case ModeGroupSelection:
 		output.WriteString(RenderKeyHint("  Type to filter groups") + "\n")
-		output.WriteString(RenderKeyHint("  Up/Down to navigate") + "\n")
-		output.WriteString(RenderKeyHint("  Enter to select") + "\n")
+		output.WriteString(RenderKeyHint(fmt.Sprintf("  %s/%s to navigate", key("up"), key("down"))) + "\n")
+		output.WriteString(RenderKeyHint("  "+key("select")+" to select") + "\n")
 		output.WriteString(RenderKeyHint("  Backspace to delete filter character") + "\n")
-		output.WriteString(RenderKeyHint("  Esc to go back") + "\n")
-		output.WriteString(RenderKeyHint("  Ctrl+C to quit") + "\n")
+		output.WriteString(RenderKeyHint("  "+key("back")+" to go back") + "\n")
 	}
+	output.WriteString(RenderKeyHint("  Ctrl+C to quit") + "\n")
 
 	return output.String()
 }
@@ -256,54 +295,28 @@ func handleInsertionChoice(data *GroupInsertionData, msg string) GroupInsertionU
 
 	case "down":
 		// Move selection down
-		if data.SelectedPosition < 1 {
+		if data.SelectedPosition < len(insertionOptions)-1 {
 			data.SelectedPosition++
 		}
 		return GroupInsertionUpdateResult{Screen: -2}
 
 	case "1":
 		// Option 1: Add to end
-		groupID := uuid.New().String()
-		order := len(data.ExistingGroups) + 1
-
-		return GroupInsertionUpdateResult{
-			Screen:            ScreenClassification,
-			InsertedGroupID:   groupID,
-			InsertedGroupName: data.GroupName,
-			InsertedOrder:     order,
-		}
+		return addGroupToEnd(data)
 
-	case "2":
-		// Option 2: Insert after existing group
-		data.Mode = ModeGroupSelection
-		data.SelectedPosition = 0
-		data.ScrollOffset = 0
-		data.FilterQuery = ""
-		data.FilteredGroups = data.ExistingGroups
+	case "2", "3":
+		// Option 2: Insert after existing group; option 3: inside it
+		startGroupSelection(data, msg == "3")
 		return GroupInsertionUpdateResult{Screen: -2}
 
 	case "enter":
 		// Confirm current selection
 		if data.SelectedPosition == 0 {
 			// Option 1: Add to end
-			groupID := uuid.New().String()
-			order := len(data.ExistingGroups) + 1
-
-			return GroupInsertionUpdateResult{
-				Screen:            ScreenClassification,
-				InsertedGroupID:   groupID,
-				InsertedGroupName: data.GroupName,
-				InsertedOrder:     order,
-			}
-		} else {
-			// Option 2: Insert after existing group
-			data.Mode = ModeGroupSelection
-			data.SelectedPosition = 0
-			data.ScrollOffset = 0
-			data.FilterQuery = ""
-			data.FilteredGroups = data.ExistingGroups
-			return GroupInsertionUpdateResult{Screen: -2}
+			return addGroupToEnd(data)
 		}
+		startGroupSelection(data, data.SelectedPosition == 2)
+		return GroupInsertionUpdateResult{Screen: -2}
 
 	case "esc":
 		// Go back to name entry mode
@@ -320,6 +333,34 @@ func handleInsertionChoice(data *GroupInsertionData, msg string) GroupInsertionU
 	}
 }
 
+// addGroupToEnd adds the new group after the last top-level group
+func addGroupToEnd(data *GroupInsertionData) GroupInsertionUpdateResult {
+	order := 1
+	for _, g := range data.ExistingGroups {
+		if g.ParentID == "" {
+			order++
+		}
+	}
+
+	return GroupInsertionUpdateResult{
+		Screen:            ScreenClassification,
+		InsertedGroupID:   uuid.New().String(),
+		InsertedGroupName: data.GroupName,
+		InsertedOrder:     order,
+	}
+}
+
+// startGroupSelection lists the existing groups to insert after or, for a
+// sub-group, inside
+func startGroupSelection(data *GroupInsertionData, subGroup bool) {
+	data.Mode = ModeGroupSelection
+	data.SubGroup = subGroup
+	data.SelectedPosition = 0
+	data.ScrollOffset = 0
+	data.FilterQuery = ""
+	data.FilteredGroups = data.ExistingGroups
+}
+
 // handleGroupSelection handles input in group selection mode
 func handleGroupSelection(data *GroupInsertionData, msg string) GroupInsertionUpdateResult {
 	switch msg {
@@ -352,14 +393,27 @@ func handleGroupSelection(data *GroupInsertionData, msg string) GroupInsertionUp
 		if len(data.FilteredGroups) > 0 && data.SelectedPosition < len(data.FilteredGroups) {
 			selectedGroup := data.FilteredGroups[data.SelectedPosition]
 			groupID := uuid.New().String()
-			// Insert after selected group: new order = selected group order + 1
+			// Insert after selected group: new order = selected group order + 1,
+			// next to it under the same parent
 			order := selectedGroup.Order + 1
+			parentID := selectedGroup.ParentID
+			if data.SubGroup {
+				// Add as the last sub-group of the selected group
+				parentID = selectedGroup.ID
+				order = 1
+				for _, g := range data.ExistingGroups {
+					if g.ParentID == selectedGroup.ID {
+						order++
+					}
+				}
+			}
 
 			return GroupInsertionUpdateResult{
 				Screen:            ScreenClassification,
 				InsertedGroupID:   groupID,
 				InsertedGroupName: data.GroupName,
 				InsertedOrder:     order,
+				InsertedParentID:  parentID,
 			}
 		}
 		return GroupInsertionUpdateResult{Screen: -2}
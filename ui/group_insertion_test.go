@@ -94,3 +94,73 @@ func TestGroupInsertionView_NameEntry(t *testing.T) {
 
 // TODO: Rewrite tests for new 3-mode flow (ModeInsertionChoice and ModeGroupSelection)
 // The old position_selection mode tests are no longer valid with the new design
+
+func TestGroupInsertionUpdate_SubGroup(t *testing.T) {
+	appState := state.NewState("/test/dir", state.SortByName)
+	scene1 := state.NewGroup("scene 1", 1)
+	scene2 := state.NewGroup("scene 2", 2)
+	wide := state.NewSubGroup("wide", scene1.ID, 1)
+	appState.Groups = []state.Group{scene1, scene2, wide}
+
+	data := NewGroupInsertionData(appState, "clip01.mp4")
+	data.GroupName = "close"
+	GroupInsertionUpdate(data, "enter")
+	GroupInsertionUpdate(data, "3")
+	if data.Mode != ModeGroupSelection || !data.SubGroup {
+		t.Fatalf("expected parent selection, got mode %v", data.Mode)
+	}
+
+	view := GroupInsertionView(data)
+	for _, expected := range []string{`add "close" inside`, "[1.1]", "wide"} {
+		if !contains(view, expected) {
+			t.Errorf("View missing expected string: %s\nView:\n%s", expected, view)
+		}
+	}
+
+	// scene 1 is listed first, followed by its shot
+	result := GroupInsertionUpdate(data, "enter")
+	if result.InsertedParentID != scene1.ID || result.InsertedOrder != 2 {
+		t.Errorf("expected close as the second shot of scene 1, got %+v", result)
+	}
+
+	// Inserting after a shot keeps it in the same scene
+	data = NewGroupInsertionData(appState, "clip01.mp4")
+	data.GroupName = "insert"
+	GroupInsertionUpdate(data, "enter")
+	GroupInsertionUpdate(data, "2")
+	GroupInsertionUpdate(data, "down")
+	result = GroupInsertionUpdate(data, "enter")
+	if result.InsertedParentID != scene1.ID || result.InsertedOrder != 2 {
+		t.Errorf("expected insert after wide in scene 1, got %+v", result)
+	}
+
+	// Adding to the end counts top-level groups only
+	data = NewGroupInsertionData(appState, "clip01.mp4")
+	data.GroupName = "scene 3"
+	GroupInsertionUpdate(data, "enter")
+	result = GroupInsertionUpdate(data, "1")
+	if result.InsertedParentID != "" || result.InsertedOrder != 3 {
+		t.Errorf("expected scene 3 at the top level, got %+v", result)
+	}
+}
+
+func TestModel_GroupInsertedSubGroup(t *testing.T) {
+	tmpDir := t.TempDir()
+	appState := state.NewState(tmpDir, state.SortByName)
+	scene := state.NewGroup("scene 1", 1)
+	appState.Groups = []state.Group{scene}
+
+	model := NewModel(appState, tmpDir)
+	model.files = []string{"clip01.mp4"}
+	model.currentScreen = ScreenGroupInsertion
+
+	updated, _ := model.Update(GroupInserted{GroupID: "shot", GroupName: "wide", Order: 1, ParentID: scene.ID})
+	model = updated.(Model)
+
+	if got := appState.GroupNumber("shot"); got != "1.1" {
+		t.Errorf("expected the shot to be numbered 1.1, got %q", got)
+	}
+	if c, ok := appState.GetClassification("clip01.mp4"); !ok || c.GroupID != "shot" {
+		t.Errorf("expected clip01.mp4 in the shot, got %+v", c)
+	}
+}
@@ -0,0 +1,646 @@
+// ui/group_management.go
+package ui
+
+import (
+	"clip-tagger/state"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// GroupManagementMode represents what the group management screen is asking for
+type GroupManagementMode int
+
+const (
+	ModeGroupList GroupManagementMode = iota
+	ModeGroupRename
+	ModeGroupMergeTarget
+	ModeGroupSplitTake
+	ModeGroupSplitName
+	ModeGroupDeleteConfirm
+	ModeGroupTakes
+)
+
+// GroupOperation is a change requested on the group management screen
+type GroupOperation int
+
+const (
+	GroupOperationNone GroupOperation = iota
+	GroupOperationRename
+	GroupOperationMerge
+	GroupOperationSplit
+	GroupOperationDelete
+	GroupOperationMove
+	GroupOperationMoveTake
+	GroupOperationRenumberTakes
+)
+
+// GroupSummary is a group with the number of clips in it
+type GroupSummary struct {
+	Group     state.Group
+	Takes     int
+	TakeFiles []TakeSummary // In take order
+}
+
+// TakeSummary is one take of a group
+type TakeSummary struct {
+	File     string
+	Original string // Camera filename if the file was renamed
+	Take     int
+}
+
+// GroupManagementData contains the data needed to render the group management screen
+type GroupManagementData struct {
+	Groups         []GroupSummary    // In naming order, sub-groups after their parent
+	Numbers        map[string]string // Displayed group numbers, e.g. "3.2"
+	Mode           GroupManagementMode
+	SelectedIndex  int    // Group the operation applies to
+	TakeIndex      int    // Take cursor in the takes view
+	TargetIndex    int    // Merge target cursor
+	Input          string // Name or take number being typed
+	SplitTake      int    // Take chosen for a split
+	Message        string // Result or error of the last operation
+	ReturnScreen   Screen // Screen Esc returns to
+	ScrollOffset   int
+	ViewportHeight int
+	Width          int    // Terminal width, 0 if unknown
+	Keys           Keymap // Active key bindings for the hints, nil for the defaults
+}
+
+// GroupManagementUpdateResult contains the result of a group management update
+type GroupManagementUpdateResult struct {
+	Screen    Screen // -1 for quit, -2 for no screen change, >= 0 for screen transition
+	Operation GroupOperation
+	GroupID   string
+	TargetID  string // Merge target
+	Name      string // New name for rename and split
+	Take      int    // First take of the new group for split
+	Delta     int    // Direction for move
+	File      string // Take being moved
+	SortBy    state.SortBy
+}
+
+// NewGroupManagementData creates group management data from state
+func NewGroupManagementData(appState *state.State, returnScreen Screen) *GroupManagementData {
+	data := &GroupManagementData{
+		Mode:           ModeGroupList,
+		ReturnScreen:   returnScreen,
+		ViewportHeight: 10,
+	}
+	data.Refresh(appState)
+	return data
+}
+
+// Refresh reloads groups after an operation, keeping the cursor in range
+func (data *GroupManagementData) Refresh(appState *state.State) {
+	groups := appState.OrderedGroups()
+	data.Numbers = groupNumbers(appState)
+	data.Groups = make([]GroupSummary, len(groups))
+	for i, g := range groups {
+		data.Groups[i] = GroupSummary{Group: g, Takes: appState.GroupTakeCount(g.ID)}
+	}
+	for _, c := range appState.Classifications {
+		for i := range data.Groups {
+			if data.Groups[i].Group.ID == c.GroupID {
+				data.Groups[i].TakeFiles = append(data.Groups[i].TakeFiles,
+					TakeSummary{File: c.File, Original: c.Original, Take: c.TakeNumber})
+			}
+		}
+	}
+	for i := range data.Groups {
+		slices.SortFunc(data.Groups[i].TakeFiles, func(a, b TakeSummary) int { return a.Take - b.Take })
+	}
+	data.SelectedIndex = min(data.SelectedIndex, max(len(data.Groups)-1, 0))
+	data.Mode = ModeGroupList
+	data.Input = ""
+	data.scrollTo(data.SelectedIndex)
+}
+
+// SelectGroup moves the cursor to a group, e.g. after it was moved
+func (data *GroupManagementData) SelectGroup(id string) {
+	for i, g := range data.Groups {
+		if g.Group.ID == id {
+			data.SelectedIndex = i
+			data.scrollTo(i)
+			return
+		}
+	}
+}
+
+// OpenTakes switches to the takes view of the selected group with the
+// cursor on a file, or the first take if the file is not in the group
+func (data *GroupManagementData) OpenTakes(file string) {
+	data.Mode = ModeGroupTakes
+	data.TakeIndex = 0
+	selected, _ := data.selected()
+	for i, t := range selected.TakeFiles {
+		if t.File == file {
+			data.TakeIndex = i
+		}
+	}
+}
+
+// subGroupCount returns how many groups are nested inside a group
+func (data *GroupManagementData) subGroupCount(id string) int {
+	prefix := data.Numbers[id] + "."
+	count := 0
+	for _, g := range data.Groups {
+		if strings.HasPrefix(data.Numbers[g.Group.ID], prefix) {
+			count++
+		}
+	}
+	return count
+}
+
+// scrollTo keeps a row inside the viewport
+func (data *GroupManagementData) scrollTo(index int) {
+	if index < data.ScrollOffset {
+		data.ScrollOffset = index
+	}
+	if index >= data.ScrollOffset+data.ViewportHeight {
+		data.ScrollOffset = index - data.ViewportHeight + 1
+	}
+}
+
+// selected returns the group under the cursor
+func (data *GroupManagementData) selected() (GroupSummary, bool) {
+	if data.SelectedIndex < 0 || data.SelectedIndex >= len(data.Groups) {
+		return GroupSummary{}, false
+	}
+	return data.Groups[data.SelectedIndex], true
+}
+
+// GroupManagementView renders the group management screen
+func GroupManagementView(data *GroupManagementData) string {
+	var output strings.Builder
+
+	output.WriteString(groupManagementHeader())
+
+	if len(data.Groups) == 0 {
+		output.WriteString(RenderWarning("No groups yet.") + "\n\n")
+		output.WriteString(RenderKeyHint("Press "+data.Keys.Hint(KeysGroups, "back")+" to go back") + "\n")
+		return output.String()
+	}
+
+	if data.Mode == ModeGroupTakes {
+		output.WriteString(takesView(data))
+		return output.String()
+	}
+
+	// In merge mode the cursor picks the target instead
+	cursor := data.SelectedIndex
+	if data.Mode == ModeGroupMergeTarget {
+		cursor = data.TargetIndex
+	}
+
+	startIdx := data.ScrollOffset
+	endIdx := min(data.ScrollOffset+data.ViewportHeight, len(data.Groups))
+	if startIdx > 0 {
+		output.WriteString(RenderMuted("  ... (more groups above)") + "\n")
+	}
+	for i := startIdx; i < endIdx; i++ {
+		g := data.Groups[i]
+		takes := fmt.Sprintf("(%d takes)", g.Takes)
+		if g.Takes == 1 {
+			takes = "(1 take)"
+		}
+		name := g.Group.Name
+		if data.Mode == ModeGroupMergeTarget && i == data.SelectedIndex {
+			name += " " + RenderWarning("[merging]")
+		}
+		if i == cursor {
+			output.WriteString(fmt.Sprintf("%s %s %s %s\n",
+				RenderCursor(">"), groupTag(data.Numbers, g.Group), RenderHighlight(name), RenderMuted(takes)))
+		} else {
+			output.WriteString(fmt.Sprintf("  %s %s %s\n",
+				groupTag(data.Numbers, g.Group), name, RenderMuted(takes)))
+		}
+	}
+	if endIdx < len(data.Groups) {
+		output.WriteString(RenderMuted("  ... (more groups below)") + "\n")
+	}
+
+	output.WriteString(groupManagementFooter(data))
+	return output.String()
+}
+
+// groupManagementHeader renders the title above the group list
+func groupManagementHeader() string {
+	return RenderHeader("=== Manage Groups ===") + "\n\n"
+}
+
+// groupManagementFooter renders the message and the current mode's prompt
+// or instructions below the group list
+func groupManagementFooter(data *GroupManagementData) string {
+	var output strings.Builder
+	output.WriteString("\n")
+
+	if data.Message != "" {
+		output.WriteString(RenderMuted(data.Message) + "\n\n")
+	}
+
+	selected, _ := data.selected()
+	switch data.Mode {
+	case ModeGroupList:
+		key := data.Keys.hints(KeysGroups)
+		output.WriteString(RenderMuted("Instructions:") + "\n")
+		output.WriteString(RenderKeyHint(fmt.Sprintf("  %s/%s - Select group", key("up"), key("down"))) + "\n")
+		output.WriteString(RenderKeyHint(fmt.Sprintf("  %s / %s - Move group up / down", key("move_up"), key("move_down"))) + "\n")
+		output.WriteString(RenderKeyHint("  "+key("takes")+" - Reorder takes") + "\n")
+		output.WriteString(RenderKeyHint("  "+key("rename")+" - Rename") + "\n")
+		output.WriteString(RenderKeyHint("  "+key("merge")+" - Merge into another group") + "\n")
+		output.WriteString(RenderKeyHint("  "+key("split")+" - Split at a take") + "\n")
+		output.WriteString(RenderKeyHint("  "+key("delete")+" - Delete with its sub-groups (clips become unclassified)") + "\n")
+		output.WriteString(RenderKeyHint("  "+key("back")+" - Back") + "\n")
+
+	case ModeGroupRename:
+		output.WriteString(RenderHighlight(fmt.Sprintf("Rename \"%s\" to:", selected.Group.Name)) + "\n")
+		output.WriteString(fmt.Sprintf("%s %s\n\n", RenderCursor(">"), RenderSubheader(data.Input)))
+		output.WriteString(RenderKeyHint("Enter to rename, Esc to cancel") + "\n")
+
+	case ModeGroupMergeTarget:
+		output.WriteString(RenderHighlight(fmt.Sprintf("Merge \"%s\" into:", selected.Group.Name)) + "\n")
+		output.WriteString(RenderMuted("Its takes are added after the target's takes.") + "\n\n")
+		output.WriteString(RenderKeyHint("Up/Down to choose, Enter to merge, Esc to cancel") + "\n")
+
+	case ModeGroupSplitTake:
+		output.WriteString(RenderHighlight(fmt.Sprintf("Split \"%s\" starting at take (2-%d):", selected.Group.Name, selected.Takes)) + "\n")
+		output.WriteString(fmt.Sprintf("%s %s\n\n", RenderCursor(">"), RenderSubheader(data.Input)))
+		output.WriteString(RenderKeyHint("Enter to continue, Esc to cancel") + "\n")
+
+	case ModeGroupSplitName:
+		output.WriteString(RenderHighlight(fmt.Sprintf("Name for takes %d-%d of \"%s\":", data.SplitTake, selected.Takes, selected.Group.Name)) + "\n")
+		output.WriteString(fmt.Sprintf("%s %s\n\n", RenderCursor(">"), RenderSubheader(data.Input)))
+		output.WriteString(RenderKeyHint("Enter to split, Esc to cancel") + "\n")
+
+	case ModeGroupDeleteConfirm:
+		message := fmt.Sprintf("Delete \"%s\"? Its %d clip(s) become unclassified.", selected.Group.Name, selected.Takes)
+		if subGroups := data.subGroupCount(selected.Group.ID); subGroups > 0 {
+			message = fmt.Sprintf("Delete \"%s\" and its %d sub-group(s)? Their clips become unclassified.", selected.Group.Name, subGroups)
+		}
+		output.WriteString(RenderDanger(message) + "\n\n")
+		output.WriteString(RenderKeyHint("y to delete, n or Esc to cancel") + "\n")
+	}
+
+	return output.String()
+}
+
+// takesView renders the takes of the selected group
+func takesView(data *GroupManagementData) string {
+	var output strings.Builder
+	selected, _ := data.selected()
+
+	output.WriteString(RenderSubheader(fmt.Sprintf("Takes of %s", selected.Group.Name)) + "\n\n")
+	if len(selected.TakeFiles) == 0 {
+		output.WriteString(RenderWarning("No takes in this group.") + "\n\n")
+	}
+	for i, t := range selected.TakeFiles {
+		label := truncateMiddle(t.File, nameWidth(data.Width, 14))
+		if t.Original != "" {
+			label += " " + RenderMuted("(was "+t.Original+")")
+		}
+		if i == data.TakeIndex {
+			output.WriteString(fmt.Sprintf("%s %s %s\n",
+				RenderCursor(">"), RenderMuted(fmt.Sprintf("take %d", t.Take)), RenderHighlight(label)))
+		} else {
+			output.WriteString(fmt.Sprintf("  %s %s\n", RenderMuted(fmt.Sprintf("take %d", t.Take)), label))
+		}
+	}
+	output.WriteString("\n")
+
+	if data.Message != "" {
+		output.WriteString(RenderMuted(data.Message) + "\n\n")
+	}
+
+	key := data.Keys.hints(KeysTakes)
+	output.WriteString(RenderMuted("Instructions:") + "\n")
+	output.WriteString(RenderKeyHint(fmt.Sprintf("  %s/%s - Select take", key("up"), key("down"))) + "\n")
+	output.WriteString(RenderKeyHint(fmt.Sprintf("  %s / %s - Move take earlier / later", key("earlier"), key("later"))) + "\n")
+	output.WriteString(RenderKeyHint("  "+key("by_time")+" - Renumber by recording time") + "\n")
+	output.WriteString(RenderKeyHint("  "+key("by_name")+" - Renumber by camera filename") + "\n")
+	output.WriteString(RenderKeyHint("  "+key("back")+" - Back to groups") + "\n")
+	return output.String()
+}
+
+// GroupManagementUpdate handles input for the group management screen
+func GroupManagementUpdate(data *GroupManagementData, msg string) GroupManagementUpdateResult {
+	if msg == "ctrl+c" {
+		return GroupManagementUpdateResult{Screen: -1}
+	}
+
+	switch data.Mode {
+	case ModeGroupRename, ModeGroupSplitName:
+		return handleGroupNameInput(data, msg)
+	case ModeGroupSplitTake:
+		return handleSplitTakeInput(data, msg)
+	case ModeGroupMergeTarget:
+		return handleMergeTargetInput(data, msg)
+	case ModeGroupDeleteConfirm:
+		return handleDeleteConfirmInput(data, msg)
+	case ModeGroupTakes:
+		return handleTakesInput(data, msg)
+	}
+
+	selected, ok := data.selected()
+	switch msg {
+	case "up":
+		if data.SelectedIndex > 0 {
+			data.SelectedIndex--
+			data.scrollTo(data.SelectedIndex)
+		}
+	case "down":
+		if data.SelectedIndex < len(data.Groups)-1 {
+			data.SelectedIndex++
+			data.scrollTo(data.SelectedIndex)
+		}
+	case "shift+up", "K", "shift+down", "J":
+		delta := 1
+		if msg == "shift+up" || msg == "K" {
+			delta = -1
+		}
+		// Groups only move among the groups with the same parent
+		hasSibling := slices.ContainsFunc(data.Groups, func(g GroupSummary) bool {
+			return g.Group.ParentID == selected.Group.ParentID && g.Group.Order == selected.Group.Order+delta
+		})
+		if ok && hasSibling {
+			return GroupManagementUpdateResult{Screen: -2, Operation: GroupOperationMove, GroupID: selected.Group.ID, Delta: delta}
+		}
+	case "enter", "t":
+		if ok {
+			data.OpenTakes("")
+			data.Message = ""
+		}
+	case "r":
+		if ok {
+			data.Mode = ModeGroupRename
+			data.Input = selected.Group.Name
+		}
+	case "m":
+		if ok && len(data.Groups) > 1 {
+			data.Mode = ModeGroupMergeTarget
+			data.TargetIndex = 0
+			if data.TargetIndex == data.SelectedIndex {
+				data.TargetIndex = 1
+			}
+		}
+	case "s":
+		if ok && selected.Takes > 1 {
+			data.Mode = ModeGroupSplitTake
+			data.Input = ""
+		} else if ok {
+			data.Message = "A group needs at least two takes to split"
+		}
+	case "d":
+		if ok {
+			data.Mode = ModeGroupDeleteConfirm
+		}
+	case "esc", "q":
+		return GroupManagementUpdateResult{Screen: data.ReturnScreen}
+	}
+	return GroupManagementUpdateResult{Screen: -2}
+}
+
+// handleTakesInput handles the takes view of a group
+func handleTakesInput(data *GroupManagementData, msg string) GroupManagementUpdateResult {
+	selected, _ := data.selected()
+	switch msg {
+	case "up":
+		if data.TakeIndex > 0 {
+			data.TakeIndex--
+		}
+	case "down":
+		if data.TakeIndex < len(selected.TakeFiles)-1 {
+			data.TakeIndex++
+		}
+	case "shift+up", "K", "shift+down", "J":
+		delta := 1
+		if msg == "shift+up" || msg == "K" {
+			delta = -1
+		}
+		target := data.TakeIndex + delta
+		if target >= 0 && target < len(selected.TakeFiles) {
+			return GroupManagementUpdateResult{Screen: -2, Operation: GroupOperationMoveTake,
+				GroupID: selected.Group.ID, File: selected.TakeFiles[data.TakeIndex].File, Delta: delta}
+		}
+	case "t", "n":
+		if len(selected.TakeFiles) > 1 {
+			by := state.SortByRecorded
+			if msg == "n" {
+				by = state.SortByName
+			}
+			return GroupManagementUpdateResult{Screen: -2, Operation: GroupOperationRenumberTakes,
+				GroupID: selected.Group.ID, SortBy: by}
+		}
+	case "esc", "q":
+		data.Mode = ModeGroupList
+		data.Message = ""
+	}
+	return GroupManagementUpdateResult{Screen: -2}
+}
+
+// handleGroupNameInput handles typing a name for rename or split
+func handleGroupNameInput(data *GroupManagementData, msg string) GroupManagementUpdateResult {
+	selected, _ := data.selected()
+	switch msg {
+	case "enter":
+		name := strings.TrimSpace(data.Input)
+		if name == "" {
+			return GroupManagementUpdateResult{Screen: -2}
+		}
+		if data.Mode == ModeGroupSplitName {
+			return GroupManagementUpdateResult{Screen: -2, Operation: GroupOperationSplit,
+				GroupID: selected.Group.ID, Take: data.SplitTake, Name: name}
+		}
+		return GroupManagementUpdateResult{Screen: -2, Operation: GroupOperationRename,
+			GroupID: selected.Group.ID, Name: name}
+	case "esc":
+		data.Mode = ModeGroupList
+		data.Input = ""
+	case "backspace":
+		if len(data.Input) > 0 {
+			data.Input = data.Input[:len(data.Input)-1]
+		}
+	default:
+		if len(msg) == 1 || msg == " " {
+			data.Input += msg
+		}
+	}
+	return GroupManagementUpdateResult{Screen: -2}
+}
+
+// handleSplitTakeInput handles typing the take a split starts at
+func handleSplitTakeInput(data *GroupManagementData, msg string) GroupManagementUpdateResult {
+	selected, _ := data.selected()
+	switch msg {
+	case "enter":
+		take, err := strconv.Atoi(data.Input)
+		if err != nil || take < 2 || take > selected.Takes {
+			data.Message = fmt.Sprintf("Enter a take from 2 to %d", selected.Takes)
+			return GroupManagementUpdateResult{Screen: -2}
+		}
+		data.SplitTake = take
+		data.Mode = ModeGroupSplitName
+		data.Input = selected.Group.Name + " 2"
+		data.Message = ""
+	case "esc":
+		data.Mode = ModeGroupList
+		data.Input = ""
+	case "backspace":
+		if len(data.Input) > 0 {
+			data.Input = data.Input[:len(data.Input)-1]
+		}
+	default:
+		if len(msg) == 1 && msg[0] >= '0' && msg[0] <= '9' {
+			data.Input += msg
+		}
+	}
+	return GroupManagementUpdateResult{Screen: -2}
+}
+
+// handleMergeTargetInput handles choosing the group to merge into
+func handleMergeTargetInput(data *GroupManagementData, msg string) GroupManagementUpdateResult {
+	// The cursor skips the group being merged
+	step := func(delta int) {
+		for next := data.TargetIndex + delta; next >= 0 && next < len(data.Groups); next += delta {
+			if next != data.SelectedIndex {
+				data.TargetIndex = next
+				data.scrollTo(next)
+				return
+			}
+		}
+	}
+
+	switch msg {
+	case "up":
+		step(-1)
+	case "down":
+		step(1)
+	case "enter":
+		selected, _ := data.selected()
+		return GroupManagementUpdateResult{Screen: -2, Operation: GroupOperationMerge,
+			GroupID: selected.Group.ID, TargetID: data.Groups[data.TargetIndex].Group.ID}
+	case "esc":
+		data.Mode = ModeGroupList
+		data.scrollTo(data.SelectedIndex)
+	}
+	return GroupManagementUpdateResult{Screen: -2}
+}
+
+// handleDeleteConfirmInput handles confirming a delete
+func handleDeleteConfirmInput(data *GroupManagementData, msg string) GroupManagementUpdateResult {
+	switch msg {
+	case "y":
+		selected, _ := data.selected()
+		return GroupManagementUpdateResult{Screen: -2, Operation: GroupOperationDelete, GroupID: selected.Group.ID}
+	case "n", "esc":
+		data.Mode = ModeGroupList
+	}
+	return GroupManagementUpdateResult{Screen: -2}
+}
+
+// handleGroupOperation applies a group management operation to the state
+// as one undoable change
+func (m Model) handleGroupOperation(op GroupManagementUpdateResult) Model {
+	data := m.groupManagementData
+	before := m.checkpoint()
+
+	var label, message string
+	var err error
+	switch op.Operation {
+	case GroupOperationRename:
+		old := m.state.FindGroupByID(op.GroupID)
+		if old != nil {
+			label = fmt.Sprintf("rename group %s to %s", old.Name, op.Name)
+		}
+		err = m.state.RenameGroup(op.GroupID, op.Name)
+		message = "Renamed to " + strings.TrimSpace(op.Name)
+
+	case GroupOperationMerge:
+		source, target := m.state.FindGroupByID(op.GroupID), m.state.FindGroupByID(op.TargetID)
+		if source != nil && target != nil {
+			label = fmt.Sprintf("merge group %s into %s", source.Name, target.Name)
+			message = fmt.Sprintf("Merged %s into %s", source.Name, target.Name)
+		}
+		err = m.state.MergeGroups(op.GroupID, op.TargetID)
+		if err == nil && m.lastClassifiedGroupID == op.GroupID {
+			m.lastClassifiedGroupID = op.TargetID
+		}
+
+	case GroupOperationSplit:
+		var split state.Group
+		split, err = m.state.SplitGroup(op.GroupID, op.Take, op.Name)
+		label = "split group into " + split.Name
+		message = fmt.Sprintf("Takes from %d moved to %s", op.Take, split.Name)
+
+	case GroupOperationDelete:
+		if group := m.state.FindGroupByID(op.GroupID); group != nil {
+			label = "delete group " + group.Name
+		}
+		var files []string
+		files, err = m.state.DeleteGroup(op.GroupID)
+		message = fmt.Sprintf("Deleted; %d clip(s) are unclassified", len(files))
+		if err == nil && m.lastClassifiedGroupID == op.GroupID {
+			m.lastClassifiedGroupID = ""
+		}
+
+	case GroupOperationMove:
+		if group := m.state.FindGroupByID(op.GroupID); group != nil {
+			label = "move group " + group.Name
+		}
+		m.state.MoveGroup(op.GroupID, op.Delta)
+
+	case GroupOperationMoveTake:
+		label = "move take " + op.File
+		m.state.MoveTake(op.File, op.Delta)
+
+	case GroupOperationRenumberTakes:
+		if group := m.state.FindGroupByID(op.GroupID); group != nil {
+			label = fmt.Sprintf("renumber takes of %s by %s", group.Name, op.SortBy)
+		}
+		err = m.state.RenumberTakes(op.GroupID, op.SortBy)
+		message = fmt.Sprintf("Takes renumbered by %s", op.SortBy)
+		if op.SortBy == state.SortByName {
+			message = "Takes renumbered by camera filename"
+		}
+	}
+
+	takes := op.Operation == GroupOperationMoveTake || op.Operation == GroupOperationRenumberTakes
+	if err != nil {
+		if !takes {
+			data.Mode = ModeGroupList
+		}
+		data.Input = ""
+		data.Message = err.Error()
+		return m
+	}
+
+	m.state.Record(label, before)
+	m = m.autoSaveState()
+	data.Refresh(m.state)
+	data.SelectGroup(op.GroupID)
+	if takes {
+		// Stay in the takes view, following a moved take
+		index := data.TakeIndex
+		data.OpenTakes(op.File)
+		if op.File == "" {
+			data.TakeIndex = index
+		}
+	}
+	data.Message = message
+	return m
+}
+
+// leaveGroupManagement returns to the screen group management was opened
+// from, rebuilding it since names, orders and takes may have changed
+func (m Model) leaveGroupManagement(screen Screen) Model {
+	if screen == ScreenClassification && m.currentFileIndex < len(m.files) {
+		m.currentScreen = ScreenClassification
+		m.classificationData = m.newClassificationData()
+		return m
+	}
+
+	m.currentScreen = ScreenReview
+	m.reviewData = NewReviewData(m.state, m.files)
+	return m
+}
@@ -0,0 +1,250 @@
+// ui/group_management_test.go
+package ui
+
+import (
+	"clip-tagger/state"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// managementFixture returns a state with intro (2 takes) and outro (1 take)
+func managementFixture(t *testing.T) (*state.State, state.Group, state.Group) {
+	appState := state.NewState(t.TempDir(), state.SortByName)
+	intro := state.NewGroup("intro", 1)
+	outro := state.NewGroup("outro", 2)
+	appState.Groups = []state.Group{intro, outro}
+	appState.AddOrUpdateClassification("a.mp4", intro.ID)
+	appState.AddOrUpdateClassification("b.mp4", intro.ID)
+	appState.AddOrUpdateClassification("c.mp4", outro.ID)
+	return appState, intro, outro
+}
+
+func TestGroupManagementView(t *testing.T) {
+	appState, _, _ := managementFixture(t)
+	data := NewGroupManagementData(appState, ScreenClassification)
+
+	view := GroupManagementView(data)
+	for _, expected := range []string{"Manage Groups", "intro", "(2 takes)", "(1 take)", "'r' - Rename", "'d' - Delete"} {
+		if !strings.Contains(view, expected) {
+			t.Errorf("expected view to contain %q", expected)
+		}
+	}
+}
+
+func TestGroupManagementUpdate_Rename(t *testing.T) {
+	appState, intro, _ := managementFixture(t)
+	data := NewGroupManagementData(appState, ScreenClassification)
+
+	GroupManagementUpdate(data, "r")
+	if data.Mode != ModeGroupRename || data.Input != "intro" {
+		t.Fatalf("expected rename mode prefilled with the name, got %v %q", data.Mode, data.Input)
+	}
+	for _, key := range []string{"backspace", "backspace", "backspace", "backspace", "backspace", "o", "p", "e", "n"} {
+		GroupManagementUpdate(data, key)
+	}
+
+	result := GroupManagementUpdate(data, "enter")
+	if result.Operation != GroupOperationRename || result.GroupID != intro.ID || result.Name != "open" {
+		t.Errorf("unexpected result: %+v", result)
+	}
+}
+
+func TestGroupManagementUpdate_Merge(t *testing.T) {
+	appState, intro, outro := managementFixture(t)
+	data := NewGroupManagementData(appState, ScreenClassification)
+
+	GroupManagementUpdate(data, "m")
+	if data.Mode != ModeGroupMergeTarget || data.TargetIndex != 1 {
+		t.Fatalf("expected merge mode targeting the other group, got %v %d", data.Mode, data.TargetIndex)
+	}
+	// The cursor can't land on the group being merged
+	GroupManagementUpdate(data, "up")
+	if data.TargetIndex != 1 {
+		t.Errorf("expected target to skip the source group, got %d", data.TargetIndex)
+	}
+
+	result := GroupManagementUpdate(data, "enter")
+	if result.Operation != GroupOperationMerge || result.GroupID != intro.ID || result.TargetID != outro.ID {
+		t.Errorf("unexpected result: %+v", result)
+	}
+}
+
+func TestGroupManagementUpdate_Split(t *testing.T) {
+	appState, intro, outro := managementFixture(t)
+	data := NewGroupManagementData(appState, ScreenClassification)
+
+	// A single take can't be split
+	data.SelectedIndex = 1
+	GroupManagementUpdate(data, "s")
+	if data.Mode != ModeGroupList || data.Message == "" {
+		t.Errorf("expected split of %s to be refused", outro.Name)
+	}
+
+	data.SelectedIndex = 0
+	GroupManagementUpdate(data, "s")
+	GroupManagementUpdate(data, "5")
+	GroupManagementUpdate(data, "enter")
+	if data.Mode != ModeGroupSplitTake {
+		t.Fatal("expected out of range take to be refused")
+	}
+	GroupManagementUpdate(data, "backspace")
+	GroupManagementUpdate(data, "2")
+	GroupManagementUpdate(data, "enter")
+	if data.Mode != ModeGroupSplitName || data.Input != "intro 2" {
+		t.Fatalf("expected name entry with a default, got %v %q", data.Mode, data.Input)
+	}
+
+	result := GroupManagementUpdate(data, "enter")
+	if result.Operation != GroupOperationSplit || result.GroupID != intro.ID || result.Take != 2 || result.Name != "intro 2" {
+		t.Errorf("unexpected result: %+v", result)
+	}
+}
+
+func TestGroupManagementUpdate_DeleteAndMove(t *testing.T) {
+	appState, intro, _ := managementFixture(t)
+	data := NewGroupManagementData(appState, ScreenReview)
+
+	GroupManagementUpdate(data, "d")
+	if result := GroupManagementUpdate(data, "n"); result.Operation != GroupOperationNone || data.Mode != ModeGroupList {
+		t.Error("expected n to cancel the delete")
+	}
+	GroupManagementUpdate(data, "d")
+	if result := GroupManagementUpdate(data, "y"); result.Operation != GroupOperationDelete || result.GroupID != intro.ID {
+		t.Errorf("unexpected result: %+v", result)
+	}
+
+	data.Mode = ModeGroupList
+	if result := GroupManagementUpdate(data, "K"); result.Operation != GroupOperationNone {
+		t.Error("expected the first group not to move up")
+	}
+	if result := GroupManagementUpdate(data, "shift+down"); result.Operation != GroupOperationMove || result.Delta != 1 {
+		t.Errorf("unexpected result: %+v", result)
+	}
+
+	if result := GroupManagementUpdate(data, "esc"); result.Screen != ScreenReview {
+		t.Errorf("expected to return to review, got %v", result.Screen)
+	}
+}
+
+func TestGroupManagementUpdate_Takes(t *testing.T) {
+	appState, intro, _ := managementFixture(t)
+	data := NewGroupManagementData(appState, ScreenReview)
+
+	GroupManagementUpdate(data, "enter")
+	if data.Mode != ModeGroupTakes || data.TakeIndex != 0 {
+		t.Fatalf("expected takes view on the first take, got %v %d", data.Mode, data.TakeIndex)
+	}
+	view := GroupManagementView(data)
+	for _, expected := range []string{"Takes of intro", "take 1", "a.mp4", "take 2", "b.mp4", "Renumber by recording time"} {
+		if !strings.Contains(view, expected) {
+			t.Errorf("expected view to contain %q", expected)
+		}
+	}
+
+	if result := GroupManagementUpdate(data, "K"); result.Operation != GroupOperationNone {
+		t.Error("expected the first take not to move up")
+	}
+	result := GroupManagementUpdate(data, "J")
+	if result.Operation != GroupOperationMoveTake || result.File != "a.mp4" || result.Delta != 1 {
+		t.Errorf("unexpected result: %+v", result)
+	}
+	result = GroupManagementUpdate(data, "n")
+	if result.Operation != GroupOperationRenumberTakes || result.GroupID != intro.ID || result.SortBy != state.SortByName {
+		t.Errorf("unexpected result: %+v", result)
+	}
+
+	GroupManagementUpdate(data, "esc")
+	if data.Mode != ModeGroupList {
+		t.Errorf("expected esc to return to the group list, got %v", data.Mode)
+	}
+}
+
+func TestModel_GroupManagementTakes(t *testing.T) {
+	appState, _, _ := managementFixture(t)
+	model := NewModel(appState, appState.Directory)
+	model.files = []string{"a.mp4", "b.mp4", "c.mp4"}
+	model.currentFileIndex = 3
+	model.currentScreen = ScreenReview
+	model.reviewData = NewReviewData(appState, model.files)
+
+	press := func(key tea.KeyMsg) {
+		updated, cmd := model.Update(key)
+		model = updated.(Model)
+		if cmd != nil {
+			updated, _ = model.Update(cmd())
+			model = updated.(Model)
+		}
+	}
+	runes := func(s string) tea.KeyMsg { return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)} }
+
+	press(runes("g"))
+	press(tea.KeyMsg{Type: tea.KeyEnter})
+	press(tea.KeyMsg{Type: tea.KeyDown})
+	press(runes("K"))
+
+	if c, _ := appState.GetClassification("b.mp4"); c.TakeNumber != 1 {
+		t.Errorf("expected b.mp4 to become take 1, got %d", c.TakeNumber)
+	}
+	data := model.groupManagementData
+	if data.Mode != ModeGroupTakes || data.TakeIndex != 0 {
+		t.Errorf("expected the cursor to follow the moved take, got %v %d", data.Mode, data.TakeIndex)
+	}
+
+	// Back to review, where the move can be undone
+	press(tea.KeyMsg{Type: tea.KeyEsc})
+	press(tea.KeyMsg{Type: tea.KeyEsc})
+	press(runes("u"))
+	if c, _ := appState.GetClassification("b.mp4"); c.TakeNumber != 2 {
+		t.Errorf("expected the move to be undone, got take %d", c.TakeNumber)
+	}
+}
+
+func TestModel_GroupManagement(t *testing.T) {
+	appState, intro, outro := managementFixture(t)
+	model := NewModel(appState, appState.Directory)
+	model.files = []string{"a.mp4", "b.mp4", "c.mp4", "d.mp4"}
+	model.currentFileIndex = 3
+	model.currentScreen = ScreenClassification
+	model.classificationData = NewClassificationData(appState, model.files, 3, intro.ID)
+	model.lastClassifiedGroupID = intro.ID
+
+	press := func(key tea.KeyMsg) {
+		updated, cmd := model.Update(key)
+		model = updated.(Model)
+		if cmd != nil {
+			updated, _ = model.Update(cmd())
+			model = updated.(Model)
+		}
+	}
+	runes := func(s string) tea.KeyMsg { return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)} }
+
+	press(runes("g"))
+	if model.currentScreen != ScreenGroupManagement || model.groupManagementData == nil {
+		t.Fatalf("expected group management screen, got %v", model.currentScreen)
+	}
+
+	// Merge intro into outro
+	press(runes("m"))
+	press(tea.KeyMsg{Type: tea.KeyEnter})
+	if len(appState.Groups) != 1 {
+		t.Fatalf("expected groups to be merged, got %+v", appState.Groups)
+	}
+	if c, _ := appState.GetClassification("a.mp4"); c.GroupID != outro.ID || c.TakeNumber != 2 {
+		t.Errorf("expected a.mp4 to be outro take 2, got %+v", c)
+	}
+	if model.lastClassifiedGroupID != outro.ID {
+		t.Error("expected same-as-last to follow the merge")
+	}
+
+	// Back to classification, where the merge can be undone
+	press(tea.KeyMsg{Type: tea.KeyEsc})
+	if model.currentScreen != ScreenClassification || model.classificationData.PreviousGroupName != "outro" {
+		t.Fatalf("expected classification screen with updated groups, got %v", model.currentScreen)
+	}
+	press(runes("u"))
+	if len(appState.Groups) != 2 {
+		t.Errorf("expected merge to be undone, got %+v", appState.Groups)
+	}
+}
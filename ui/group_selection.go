@@ -4,6 +4,7 @@ package ui
 import (
 	"clip-tagger/state"
 	"fmt"
+	"strconv"
 	"strings"
 )
 
@@ -13,9 +14,12 @@ type GroupSelectionData struct {
 	AllGroups      []state.Group
 	FilteredGroups []state.Group
 	FilterText     string
+	Numbers        map[string]string // Displayed group numbers, e.g. "3.2"
 	SelectedIndex  int
-	ScrollOffset   int // Track scroll position
-	ViewportHeight int // Number of items to show (default: 10)
+	ScrollOffset   int    // Track scroll position
+	ViewportHeight int    // Number of items to show (default: 10)
+	Width          int    // Terminal width, 0 if unknown
+	Keys           Keymap // Active key bindings for the hints, nil for the defaults
 }
 
 // GroupSelectionUpdateResult contains the result of a group selection update
@@ -27,11 +31,13 @@ type GroupSelectionUpdateResult struct {
 
 // NewGroupSelectionData creates group selection data from state and current file
 func NewGroupSelectionData(appState *state.State, currentFile string) *GroupSelectionData {
+	groups := appState.OrderedGroups()
 	return &GroupSelectionData{
 		CurrentFile:    currentFile,
-		AllGroups:      appState.Groups,
-		FilteredGroups: appState.Groups,
+		AllGroups:      groups,
+		FilteredGroups: groups,
 		FilterText:     "",
+		Numbers:        groupNumbers(appState),
 		SelectedIndex:  0,
 		ScrollOffset:   0,
 		ViewportHeight: 10,
@@ -44,11 +50,10 @@ func filterGroups(groups []state.Group, filterText string) []state.Group {
 		return groups
 	}
 
-	lowerFilter := strings.ToLower(filterText)
 	filtered := make([]state.Group, 0)
 
 	for _, group := range groups {
-		if strings.Contains(strings.ToLower(group.Name), lowerFilter) {
+		if matchesFilter(group.Name, filterText) {
 			filtered = append(filtered, group)
 		}
 	}
@@ -56,16 +61,34 @@ func filterGroups(groups []state.Group, filterText string) []state.Group {
 	return filtered
 }
 
-// GroupSelectionView renders the group selection screen
-func GroupSelectionView(data *GroupSelectionData) string {
-	var output string
+// matchesFilter reports whether a name contains the filter text, ignoring
+// case
+func matchesFilter(name, filterText string) bool {
+	return strings.Contains(strings.ToLower(name), strings.ToLower(filterText))
+}
 
-	// Header with current file context
-	output += RenderHeader("=== Group Selection ===") + "\n\n"
-	output += fmt.Sprintf("%s %s\n\n", RenderMuted("Classifying:"), RenderSubheader(data.CurrentFile))
+// groupNumbers maps group IDs to the numbers shown for them, e.g. "3.2"
+// for the second sub-group of the third group
+func groupNumbers(appState *state.State) map[string]string {
+	numbers := make(map[string]string, len(appState.Groups))
+	for _, g := range appState.Groups {
+		numbers[g.ID] = appState.GroupNumber(g.ID)
+	}
+	return numbers
+}
 
-	// Filter input
-	output += fmt.Sprintf("%s %s\n\n", RenderMuted("Filter:"), RenderHighlight(data.FilterText))
+// groupTag renders a group's number, indented by how deeply it is nested
+func groupTag(numbers map[string]string, group state.Group) string {
+	number, ok := numbers[group.ID]
+	if !ok {
+		number = strconv.Itoa(group.Order)
+	}
+	return strings.Repeat("  ", strings.Count(number, ".")) + RenderMuted("["+number+"]")
+}
+
+// GroupSelectionView renders the group selection screen
+func GroupSelectionView(data *GroupSelectionData) string {
+	output := groupSelectionHeader(data)
 
 	// List of groups
 	if len(data.FilteredGroups) == 0 {
@@ -74,8 +97,6 @@ func GroupSelectionView(data *GroupSelectionData) string {
 			output += RenderWarning(fmt.Sprintf("No groups match '%s'.", data.FilterText)) + "\n"
 		}
 	} else {
-		output += RenderHighlight("Groups:") + "\n"
-
 		// Calculate visible window
 		startIdx := data.ScrollOffset
 		endIdx := data.ScrollOffset + data.ViewportHeight
@@ -95,11 +116,11 @@ func GroupSelectionView(data *GroupSelectionData) string {
 			if i == data.SelectedIndex {
 				output += fmt.Sprintf("%s %s %s\n",
 					RenderCursor(">"),
-					RenderMuted(fmt.Sprintf("[%d]", group.Order)),
+					groupTag(data.Numbers, group),
 					RenderHighlight(group.Name))
 			} else {
 				output += fmt.Sprintf("  %s %s\n",
-					RenderMuted(fmt.Sprintf("[%d]", group.Order)),
+					groupTag(data.Numbers, group),
 					group.Name)
 			}
 		}
@@ -110,15 +131,39 @@ func GroupSelectionView(data *GroupSelectionData) string {
 		}
 	}
 
-	output += "\n"
+	return output + groupSelectionFooter(data)
+}
+
+// groupSelectionHeader renders the current file and filter above the group
+// list
+func groupSelectionHeader(data *GroupSelectionData) string {
+	var output string
+
+	// Header with current file context
+	output += RenderHeader("=== Group Selection ===") + "\n\n"
+	output += fmt.Sprintf("%s %s\n\n", RenderMuted("Classifying:"), RenderSubheader(truncateMiddle(data.CurrentFile, nameWidth(data.Width, 13))))
+
+	// Filter input
+	output += fmt.Sprintf("%s %s\n\n", RenderMuted("Filter:"), RenderHighlight(data.FilterText))
+
+	if len(data.FilteredGroups) > 0 {
+		output += RenderHighlight("Groups:") + "\n"
+	}
+	return output
+}
+
+// groupSelectionFooter renders the instructions below the group list
+func groupSelectionFooter(data *GroupSelectionData) string {
+	output := "\n"
 
 	// Instructions
+	key := data.Keys.hints(KeysGroupSelection)
 	output += RenderMuted("Instructions:") + "\n"
 	output += RenderKeyHint("  Type to filter groups (case-insensitive)") + "\n"
-	output += RenderKeyHint("  Use arrow keys to navigate") + "\n"
-	output += RenderKeyHint("  Enter to select") + "\n"
+	output += RenderKeyHint("  "+data.Keys.navigateHint(KeysGroupSelection)) + "\n"
+	output += RenderKeyHint("  "+key("select")+" to select") + "\n"
 	output += RenderKeyHint("  Backspace to delete filter character") + "\n"
-	output += RenderKeyHint("  Esc to cancel") + "\n"
+	output += RenderKeyHint("  "+key("back")+" to cancel") + "\n"
 	output += RenderKeyHint("  Ctrl+C to quit") + "\n"
 
 	return output
@@ -0,0 +1,482 @@
+// ui/keymap.go
+package ui
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// KeyContext names a set of key bindings: a screen, or a mode of one
+type KeyContext string
+
+const (
+	KeysNone           KeyContext = "" // Keys are passed through unchanged, e.g. while typing
+	KeysStartup        KeyContext = "startup"
+	KeysClassification KeyContext = "classification"
+	KeysGroupSelection KeyContext = "group_selection"
+	KeysGroupInsertion KeyContext = "group_insertion"
+	KeysReview         KeyContext = "review"
+	KeysCompletion     KeyContext = "completion"
+	KeysGroups         KeyContext = "groups"
+	KeysTakes          KeyContext = "takes"
+	KeysMultiSelect    KeyContext = "multi_select"
+	KeysTagPicker      KeyContext = "tag_picker"
+)
+
+// Keys no binding may use: Ctrl+C always quits and ? shows the help
+const (
+	quitKey = "ctrl+c"
+	helpKey = "?"
+)
+
+// Binding is an action and the keys that trigger it
+type Binding struct {
+	Action string   // Name used in the keymap file, e.g. "preview"
+	Help   string   // Shown in the help overlay
+	Keys   []string // Key names as bubbletea reports them, e.g. "p", "shift+up", " "
+}
+
+// keyContext is a context's title and default bindings. The first default
+// key of each binding is the one its screen's update function handles;
+// rebound keys are translated to it.
+type keyContext struct {
+	Name     KeyContext
+	Title    string
+	Typing   bool // Text is typed here, so only keys that don't type a character can be bound
+	Bindings []Binding
+}
+
+// keyContexts lists every context's default bindings, in help order
+var keyContexts = []keyContext{
+	{KeysStartup, "Startup", false, []Binding{
+		{"continue", "Start classifying", []string{"enter"}},
+		{"quit", "Quit", []string{"q"}},
+	}},
+	{KeysClassification, "Classification", false, []Binding{
+		{"preview", "Preview file", []string{"p"}},
+		{"same_as_last", "Same group as the last clip", []string{"1"}},
+		{"choose_group", "Select from existing groups", []string{"2"}},
+		{"new_group", "Create new group", []string{"3"}},
+		{"accept_suggestion", "Take the suggested action", []string{"enter"}},
+		{"skip", "Skip this file", []string{"s"}},
+		{"reject", "Reject this file", []string{"r"}},
+		{"disposition", "Skipped file: keep name / reject / delete", []string{"d"}},
+		{"unclassify", "Unclassify this file", []string{"x"}},
+		{"previous", "Previous file", []string{"left"}},
+		{"next", "Next file", []string{"right"}},
+		{"manage_groups", "Manage groups", []string{"g"}},
+		{"multi_select", "Select several clips for one group", []string{"m"}},
+		{"auto_group", "Auto-group the remaining clips by time gap", []string{"a"}},
+		{"shorter_gap", "Halve the suggestion gap", []string{"["}},
+		{"longer_gap", "Double the suggestion gap", []string{"]"}},
+		{"circle", "Circle take", []string{"c"}},
+		{"rating_up", "Raise rating", []string{"+", "="}},
+		{"rating_down", "Lower rating", []string{"-"}},
+		{"notes", "Edit notes", []string{"n"}},
+		{"tags", "Tags", []string{"t"}},
+		{"filter", "Filter queue by tag", []string{"f"}},
+		{"undo", "Undo", []string{"u"}},
+		{"redo", "Redo", []string{"ctrl+r"}},
+		{"quit", "Quit", []string{"q"}},
+	}},
+	{KeysGroupSelection, "Group Selection", true, []Binding{
+		{"up", "Previous group", []string{"up"}},
+		{"down", "Next group", []string{"down"}},
+		{"select", "Select group", []string{"enter"}},
+		{"back", "Cancel", []string{"esc"}},
+	}},
+	{KeysGroupInsertion, "Group Insertion", true, []Binding{
+		{"up", "Previous option or group", []string{"up"}},
+		{"down", "Next option or group", []string{"down"}},
+		{"select", "Proceed", []string{"enter"}},
+		{"back", "Go back", []string{"esc"}},
+	}},
+	{KeysReview, "Review", false, []Binding{
+		{"up", "Previous clip", []string{"up"}},
+		{"down", "Next clip", []string{"down"}},
+		{"rename", "Proceed to rename files", []string{"enter"}},
+		{"back", "Back to classification at this clip", []string{"esc"}},
+		{"choose_group", "Move to an existing group", []string{"2"}},
+		{"new_group", "Move to a new group", []string{"3"}},
+		{"take_earlier", "Move take earlier", []string{"["}},
+		{"take_later", "Move take later", []string{"]"}},
+		{"notes", "Edit notes", []string{"n"}},
+		{"preview", "Preview file", []string{"p"}},
+		{"unskip", "Unskip", []string{"x"}},
+		{"disposition", "Skipped file: keep name / reject / delete", []string{"d"}},
+		{"revisit_skipped", "Revisit skipped files", []string{"s"}},
+		{"manage_groups", "Manage groups", []string{"g"}},
+		{"undo", "Undo", []string{"u"}},
+		{"redo", "Redo", []string{"ctrl+r"}},
+		{"quit", "Quit", []string{"q"}},
+	}},
+	{KeysCompletion, "Rename", false, []Binding{
+		{"up", "Previous mode", []string{"up"}},
+		{"down", "Next mode", []string{"down"}},
+		{"confirm", "Rename files", []string{"enter"}},
+		{"back", "Back to review", []string{"esc"}},
+		{"quit", "Quit", []string{"q"}},
+	}},
+	{KeysGroups, "Manage Groups", false, []Binding{
+		{"up", "Previous group", []string{"up"}},
+		{"down", "Next group", []string{"down"}},
+		{"move_up", "Move group up", []string{"shift+up", "K"}},
+		{"move_down", "Move group down", []string{"shift+down", "J"}},
+		{"takes", "Reorder takes", []string{"enter", "t"}},
+		{"rename", "Rename", []string{"r"}},
+		{"merge", "Merge into another group", []string{"m"}},
+		{"split", "Split at a take", []string{"s"}},
+		{"delete", "Delete with its sub-groups", []string{"d"}},
+		{"back", "Back", []string{"esc", "q"}},
+	}},
+	{KeysTakes, "Takes", false, []Binding{
+		{"up", "Previous take", []string{"up"}},
+		{"down", "Next take", []string{"down"}},
+		{"earlier", "Move take earlier", []string{"shift+up", "K"}},
+		{"later", "Move take later", []string{"shift+down", "J"}},
+		{"by_time", "Renumber by recording time", []string{"t"}},
+		{"by_name", "Renumber by camera filename", []string{"n"}},
+		{"back", "Back to groups", []string{"esc", "q"}},
+	}},
+	{KeysMultiSelect, "Multi-Select", false, []Binding{
+		{"up", "Previous clip", []string{"up"}},
+		{"down", "Next clip", []string{"down"}},
+		{"extend_up", "Extend selection up", []string{"shift+up", "K"}},
+		{"extend_down", "Extend selection down", []string{"shift+down", "J"}},
+		{"toggle", "Select / deselect clip", []string{" "}},
+		{"select_run", "Select until the next gap", []string{"r"}},
+		{"shorter_gap", "Halve the gap", []string{"["}},
+		{"longer_gap", "Double the gap", []string{"]"}},
+		{"clear", "Clear selection", []string{"c"}},
+		{"choose_group", "Assign to an existing group", []string{"enter", "2"}},
+		{"new_group", "Assign to a new group", []string{"3"}},
+		{"back", "Cancel", []string{"esc", "q"}},
+	}},
+	{KeysTagPicker, "Tags", true, []Binding{
+		{"up", "Previous tag", []string{"up"}},
+		{"down", "Next tag", []string{"down"}},
+		{"select", "Choose tag", []string{"enter"}},
+		{"back", "Done", []string{"esc"}},
+	}},
+}
+
+// findContext returns a context's defaults, or nil if there is no such context
+func findContext(name KeyContext) *keyContext {
+	for i := range keyContexts {
+		if keyContexts[i].Name == name {
+			return &keyContexts[i]
+		}
+	}
+	return nil
+}
+
+// Keymap holds the key bindings of every context, in the order of the
+// defaults
+type Keymap map[KeyContext][]Binding
+
+// DefaultKeymap returns the built-in key bindings
+func DefaultKeymap() Keymap {
+	keymap := make(Keymap, len(keyContexts))
+	for _, c := range keyContexts {
+		bindings := make([]Binding, len(c.Bindings))
+		for i, b := range c.Bindings {
+			bindings[i] = b
+			bindings[i].Keys = slices.Clone(b.Keys)
+		}
+		keymap[c.Name] = bindings
+	}
+	return keymap
+}
+
+// Bind replaces the keys of an action
+func (k Keymap) Bind(context KeyContext, action string, keys []string) error {
+	if findContext(context) == nil {
+		return fmt.Errorf("unknown screen %q", context)
+	}
+	if len(keys) == 0 {
+		return fmt.Errorf("%s: %s has no keys", context, action)
+	}
+	for i := range k[context] {
+		if k[context][i].Action == action {
+			k[context][i].Keys = slices.Clone(keys)
+			return nil
+		}
+	}
+	return fmt.Errorf("%s: unknown action %q", context, action)
+}
+
+// KeymapPath returns where the keymap file is read from: keys.json in the
+// clip-tagger folder of the user's config directory
+func KeymapPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "clip-tagger", "keys.json"), nil
+}
+
+// LoadKeymap reads key bindings over the defaults from a JSON file that maps
+// contexts to actions to keys, e.g. {"classification": {"skip": ["w"]}},
+// and validates them. A missing file gives the defaults.
+func LoadKeymap(path string) (Keymap, error) {
+	keymap := DefaultKeymap()
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return keymap, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read keymap: %w", err)
+	}
+
+	var file map[KeyContext]map[string][]string
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse keymap %s: %w", path, err)
+	}
+	// Bind in a fixed order so the same file always reports the same error
+	for _, context := range slices.Sorted(maps.Keys(file)) {
+		actions := file[context]
+		for _, action := range slices.Sorted(maps.Keys(actions)) {
+			if err := keymap.Bind(context, action, actions[action]); err != nil {
+				return nil, fmt.Errorf("keymap %s: %w", path, err)
+			}
+		}
+	}
+	if err := keymap.Validate(); err != nil {
+		return nil, fmt.Errorf("keymap %s: %w", path, err)
+	}
+	return keymap, nil
+}
+
+// Validate checks that no key triggers two actions in one context, that
+// the reserved keys are left alone, and that keys which type a character
+// aren't bound where text is typed
+func (k Keymap) Validate() error {
+	for _, c := range keyContexts {
+		owner := make(map[string]string)
+		for _, b := range k[c.Name] {
+			for _, key := range b.Keys {
+				switch {
+				case key == "":
+					return fmt.Errorf("%s: %s has an empty key", c.Name, b.Action)
+				case key == quitKey || key == helpKey:
+					return fmt.Errorf("%s: %q is reserved and can't be bound to %s", c.Name, key, b.Action)
+				case c.Typing && utf8.RuneCountInString(key) == 1:
+					return fmt.Errorf("%s: %q types text on this screen and can't be bound to %s", c.Name, key, b.Action)
+				}
+				if other, ok := owner[key]; ok && other != b.Action {
+					return fmt.Errorf("%s: %q is bound to both %s and %s", c.Name, key, other, b.Action)
+				}
+				owner[key] = b.Action
+			}
+		}
+	}
+	return nil
+}
+
+// Translate returns the key a context's update function handles for a
+// pressed key, or false if the key does nothing because its action was
+// bound to other keys. Keys outside the context's bindings, such as typed
+// text, are passed through, and a nil Keymap passes every key through.
+func (k Keymap) Translate(context KeyContext, key string) (string, bool) {
+	c := findContext(context)
+	if c == nil || k == nil || key == quitKey {
+		return key, true
+	}
+	for i, b := range k[context] {
+		if slices.Contains(b.Keys, key) {
+			return c.Bindings[i].Keys[0], true
+		}
+	}
+	for _, b := range c.Bindings {
+		if slices.Contains(b.Keys, key) {
+			return "", false
+		}
+	}
+	return key, true
+}
+
+// keyContext returns the bindings for the current screen and mode, and
+// whether text is being typed, in which case ? is typed rather than
+// showing the help
+func (m Model) keyContext() (KeyContext, bool) {
+	switch m.currentScreen {
+	case ScreenStartup:
+		return KeysStartup, false
+	case ScreenClassification:
+		if m.classificationData != nil && m.classificationData.EditingNotes {
+			return KeysNone, true
+		}
+		return KeysClassification, false
+	case ScreenGroupSelection:
+		return KeysGroupSelection, true
+	case ScreenGroupInsertion:
+		return KeysGroupInsertion, m.groupInsertionData == nil || m.groupInsertionData.Mode != ModeInsertionChoice
+	case ScreenReview:
+		if m.reviewData != nil && m.reviewData.EditingNotes {
+			return KeysNone, true
+		}
+		return KeysReview, false
+	case ScreenComplete:
+		return KeysCompletion, false
+	case ScreenGroupManagement:
+		if m.groupManagementData == nil {
+			return KeysNone, false
+		}
+		switch m.groupManagementData.Mode {
+		case ModeGroupList:
+			return KeysGroups, false
+		case ModeGroupTakes:
+			return KeysTakes, false
+		case ModeGroupRename, ModeGroupSplitTake, ModeGroupSplitName:
+			return KeysNone, true
+		}
+		return KeysNone, false
+	case ScreenBulkSelect:
+		return KeysMultiSelect, false
+	case ScreenTagPicker:
+		return KeysTagPicker, true
+	}
+	return KeysNone, false
+}
+
+// HelpView renders the help overlay for a context
+func HelpView(k Keymap, context KeyContext) string {
+	var output strings.Builder
+	title := "Keys"
+	if c := findContext(context); c != nil {
+		title = "Keys: " + c.Title
+	}
+	output.WriteString(RenderHeader("=== "+title+" ===") + "\n\n")
+	if context != KeysNone {
+		output.WriteString(RenderMuted(fmt.Sprintf("Section %q of keys.json; action names on the right", context)) + "\n\n")
+	}
+
+	for _, b := range k[context] {
+		output.WriteString(fmt.Sprintf("  %s %-42s %s\n",
+			RenderKeyHint(fmt.Sprintf("%-16s", keyNames(b.Keys))), b.Help, RenderMuted(b.Action)))
+	}
+	output.WriteString(fmt.Sprintf("  %s %s\n", RenderKeyHint(fmt.Sprintf("%-16s", "ctrl+c")), "Quit"))
+	output.WriteString("\n")
+	output.WriteString(RenderMuted("Press any key to close") + "\n")
+	return output.String()
+}
+
+// Hint names the keys bound to an action the way the screens' hints
+// write them, e.g. "'p'", "Enter" or "Shift+Up or 'K'". A nil Keymap gives
+// the default keys.
+func (k Keymap) Hint(context KeyContext, action string) string {
+	bindings, ok := k[context]
+	if !ok {
+		if c := findContext(context); c != nil {
+			bindings = c.Bindings
+		}
+	}
+	for _, b := range bindings {
+		if b.Action == action {
+			names := make([]string, len(b.Keys))
+			for i, key := range b.Keys {
+				names[i] = hintName(key)
+			}
+			return strings.Join(names, " or ")
+		}
+	}
+	return ""
+}
+
+// hints returns Hint for one context, for views that name many actions
+func (k Keymap) hints(context KeyContext) func(action string) string {
+	return func(action string) string {
+		return k.Hint(context, action)
+	}
+}
+
+// navigateHint describes a context's up and down keys, in the screens'
+// usual wording while they are the arrow keys
+func (k Keymap) navigateHint(context KeyContext) string {
+	up, down := k.Hint(context, "up"), k.Hint(context, "down")
+	if up == "Up" && down == "Down" {
+		return "Use arrow keys to navigate"
+	}
+	return up + "/" + down + " to navigate"
+}
+
+// hintName writes a key for the screens' hints: typed characters quoted,
+// named keys capitalized, e.g. 'p', Enter, Ctrl+R
+func hintName(key string) string {
+	switch key {
+	case " ":
+		return "Space"
+	case "left":
+		return "←"
+	case "right":
+		return "→"
+	}
+	if utf8.RuneCountInString(key) == 1 {
+		return "'" + key + "'"
+	}
+	parts := strings.Split(key, "+")
+	for i, part := range parts {
+		if part != "" {
+			parts[i] = strings.ToUpper(part[:1]) + part[1:]
+		}
+	}
+	return strings.Join(parts, "+")
+}
+
+// keysToScreens gives every screen the keymap its hints are built from
+func (m Model) keysToScreens() Model {
+	if data := m.startupData; data != nil {
+		data.Keys = m.keys
+	}
+	if data := m.classificationData; data != nil {
+		data.Keys = m.keys
+	}
+	if data := m.groupSelectionData; data != nil {
+		data.Keys = m.keys
+	}
+	if data := m.groupInsertionData; data != nil {
+		data.Keys = m.keys
+	}
+	if data := m.reviewData; data != nil {
+		data.Keys = m.keys
+	}
+	if data := m.completionData; data != nil {
+		data.Keys = m.keys
+	}
+	if data := m.groupManagementData; data != nil {
+		data.Keys = m.keys
+	}
+	if data := m.bulkSelectData; data != nil {
+		data.Keys = m.keys
+	}
+	if data := m.tagPickerData; data != nil {
+		data.Keys = m.keys
+	}
+	return m
+}
+
+// keyNames describes keys for the help overlay
+func keyNames(keys []string) string {
+	names := make([]string, len(keys))
+	for i, key := range keys {
+		switch key {
+		case " ":
+			names[i] = "space"
+		case "left":
+			names[i] = "←"
+		case "right":
+			names[i] = "→"
+		default:
+			names[i] = key
+		}
+	}
+	return strings.Join(names, " / ")
+}
@@ -0,0 +1,224 @@
+// ui/keymap_test.go
+package ui
+
+import (
+	"clip-tagger/state"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestDefaultKeymap_Valid(t *testing.T) {
+	if err := DefaultKeymap().Validate(); err != nil {
+		t.Fatalf("expected the defaults to be valid, got %v", err)
+	}
+
+	// Changing a copy leaves the defaults alone
+	keys := DefaultKeymap()
+	keys[KeysClassification][0].Keys[0] = "w"
+	if DefaultKeymap()[KeysClassification][0].Keys[0] != "p" {
+		t.Error("expected DefaultKeymap to return a copy")
+	}
+}
+
+func TestKeymap_Translate(t *testing.T) {
+	keys := DefaultKeymap()
+	if err := keys.Bind(KeysClassification, "preview", []string{"w", "v"}); err != nil {
+		t.Fatalf("Bind failed: %v", err)
+	}
+
+	tests := []struct {
+		context KeyContext
+		key     string
+		want    string
+		ok      bool
+	}{
+		{KeysClassification, "w", "p", true},
+		{KeysClassification, "v", "p", true},
+		{KeysClassification, "p", "", false}, // Rebound away
+		{KeysClassification, "2", "2", true}, // Unchanged binding
+		{KeysClassification, "ctrl+c", "ctrl+c", true},
+		{KeysClassification, "z", "z", true}, // Unbound keys pass through
+		{KeysReview, "p", "p", true},         // Other contexts keep their keys
+		{KeysNone, "w", "w", true},
+		{KeysGroups, "K", "shift+up", true},
+	}
+	for _, tt := range tests {
+		got, ok := keys.Translate(tt.context, tt.key)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("Translate(%s, %q) = %q, %v; want %q, %v", tt.context, tt.key, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestKeymap_Hint(t *testing.T) {
+	keys := DefaultKeymap()
+	if err := keys.Bind(KeysClassification, "skip", []string{"w", "ctrl+s"}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		keys    Keymap
+		context KeyContext
+		action  string
+		want    string
+	}{
+		{keys, KeysClassification, "skip", "'w' or Ctrl+S"},
+		{keys, KeysClassification, "redo", "Ctrl+R"},
+		{keys, KeysClassification, "previous", "←"},
+		{keys, KeysGroups, "move_up", "Shift+Up or 'K'"},
+		{keys, KeysMultiSelect, "toggle", "Space"},
+		{nil, KeysClassification, "skip", "'s'"}, // Defaults without a keymap
+		{keys, KeysReview, "scrub", ""},
+	}
+	for _, tt := range tests {
+		if got := tt.keys.Hint(tt.context, tt.action); got != tt.want {
+			t.Errorf("Hint(%s, %s) = %q, want %q", tt.context, tt.action, got, tt.want)
+		}
+	}
+}
+
+func TestKeymap_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		context KeyContext
+		action  string
+		keys    []string
+		wantErr string
+	}{
+		{"conflict", KeysClassification, "skip", []string{"p"}, `"p" is bound to both preview and skip`},
+		{"reserved help", KeysReview, "quit", []string{"?"}, "reserved"},
+		{"reserved quit", KeysReview, "quit", []string{"ctrl+c"}, "reserved"},
+		{"typed text", KeysGroupSelection, "up", []string{"k"}, "types text"},
+		{"same action twice", KeysReview, "up", []string{"up", "up"}, ""},
+		{"control key while typing", KeysTagPicker, "up", []string{"ctrl+p"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys := DefaultKeymap()
+			if err := keys.Bind(tt.context, tt.action, tt.keys); err != nil {
+				t.Fatalf("Bind failed: %v", err)
+			}
+			err := keys.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("expected no error, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	keys := DefaultKeymap()
+	if err := keys.Bind("timeline", "up", []string{"k"}); err == nil {
+		t.Error("expected an error for an unknown screen")
+	}
+	if err := keys.Bind(KeysReview, "scrub", []string{"k"}); err == nil {
+		t.Error("expected an error for an unknown action")
+	}
+	if err := keys.Bind(KeysReview, "up", nil); err == nil {
+		t.Error("expected an error for an action without keys")
+	}
+}
+
+func TestLoadKeymap(t *testing.T) {
+	dir := t.TempDir()
+
+	// No file: the defaults
+	keys, err := LoadKeymap(filepath.Join(dir, "missing.json"))
+	if err != nil {
+		t.Fatalf("expected the defaults without a file, got %v", err)
+	}
+	if got, _ := keys.Translate(KeysClassification, "s"); got != "s" {
+		t.Errorf("expected the default skip key, got %q", got)
+	}
+
+	path := filepath.Join(dir, "keys.json")
+	write := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write(`{"classification": {"skip": ["w"], "reject": ["e"]}, "review": {"up": ["up", "k"]}}`)
+	keys, err = LoadKeymap(path)
+	if err != nil {
+		t.Fatalf("LoadKeymap failed: %v", err)
+	}
+	if got, _ := keys.Translate(KeysClassification, "w"); got != "s" {
+		t.Errorf("expected w to skip, got %q", got)
+	}
+	if got, _ := keys.Translate(KeysReview, "k"); got != "up" {
+		t.Errorf("expected k to move up, got %q", got)
+	}
+
+	write(`{"classification": {"skip": ["p"]}}`)
+	if _, err := LoadKeymap(path); err == nil || !strings.Contains(err.Error(), path) {
+		t.Errorf("expected a conflict error naming the file, got %v", err)
+	}
+
+	write(`{"classification": {"skip": "w"}}`)
+	if _, err := LoadKeymap(path); err == nil {
+		t.Error("expected an error for keys that aren't a list")
+	}
+}
+
+func TestModel_RemappedKeysAndHelp(t *testing.T) {
+	appState := state.NewState(t.TempDir(), state.SortByName)
+	model := NewModel(appState, appState.Directory)
+	model.files = []string{"a.mp4", "b.mp4"}
+	model.currentScreen = ScreenClassification
+	model.classificationData = NewClassificationData(appState, model.files, 0, "")
+
+	keys := DefaultKeymap()
+	if err := keys.Bind(KeysClassification, "skip", []string{"w"}); err != nil {
+		t.Fatal(err)
+	}
+	model.SetKeymap(keys)
+
+	press := func(key string) {
+		t.Helper()
+		updated, _ := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)})
+		model = updated.(Model)
+	}
+
+	// The hints name the keys in use
+	view := model.View()
+	if !strings.Contains(view, "'w' - Skip this file") || strings.Contains(view, "'s' - Skip") {
+		t.Errorf("expected the hints to show the rebound key, got:\n%s", view)
+	}
+
+	press("s")
+	if appState.IsSkipped("a.mp4") {
+		t.Error("expected s to do nothing once skip is rebound")
+	}
+	press("w")
+	if !appState.IsSkipped("a.mp4") || model.currentFileIndex != 1 {
+		t.Errorf("expected w to skip a.mp4, got index %d", model.currentFileIndex)
+	}
+
+	// The help lists the keys in use and any key closes it
+	press("?")
+	view = model.View()
+	if !strings.Contains(view, "Keys: Classification") || !strings.Contains(view, "w ") {
+		t.Errorf("expected the help overlay with the rebound key, got:\n%s", view)
+	}
+	press("w")
+	if !strings.Contains(model.View(), "? - All keys") || model.currentFileIndex != 1 {
+		t.Errorf("expected the key to close the help without skipping, got index %d", model.currentFileIndex)
+	}
+
+	// While typing notes ? is text
+	press("n")
+	press("?")
+	if model.showHelp || model.classificationData.NotesInput != "?" {
+		t.Errorf("expected ? to be typed, got %q", model.classificationData.NotesInput)
+	}
+}
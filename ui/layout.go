@@ -0,0 +1,129 @@
+// ui/layout.go
+package ui
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Size is the terminal size from the last tea.WindowSizeMsg. It is zero
+// until the first one arrives, and screens keep their default layout.
+type Size struct {
+	Width  int
+	Height int
+}
+
+const (
+	minViewport  = 3   // Fewest list rows shown on a short terminal
+	minNameWidth = 16  // Names are never truncated below this
+	wideWidth    = 120 // From this width, review shows details next to the list
+)
+
+// listChrome is the lines around a list that its screen's header and
+// footer don't draw: both scroll indicators and the key help hint
+const listChrome = 3
+
+// rows returns how many list rows fit between a screen's rendered header
+// and footer. Both are measured as drawn, so notices, hints and warnings
+// take their rows from the list.
+func (s Size) rows(header, footer string) int {
+	return max(s.Height-lipgloss.Height(header+footer)-listChrome, minViewport)
+}
+
+// nameWidth returns the room left for a name on a line of width with
+// reserved columns used by everything else, or 0 (no limit) if the width
+// is unknown
+func nameWidth(width, reserved int) int {
+	if width <= 0 {
+		return 0
+	}
+	return max(width-reserved, minNameWidth)
+}
+
+// truncateMiddle shortens s to width columns by replacing its middle with
+// an ellipsis, keeping the start and the extension of long filenames
+// visible. A width of 0 or less leaves s unchanged.
+func truncateMiddle(s string, width int) string {
+	runes := []rune(s)
+	if width <= 0 || len(runes) <= width {
+		return s
+	}
+	if width == 1 {
+		return "…"
+	}
+	tail := (width - 1) / 2
+	head := width - 1 - tail
+	return string(runes[:head]) + "…" + string(runes[len(runes)-tail:])
+}
+
+// scrollInto returns the scroll offset that keeps index inside a viewport
+// of height rows
+func scrollInto(index, offset, height int) int {
+	if index < offset {
+		return max(index, 0)
+	}
+	if index >= offset+height {
+		return index - height + 1
+	}
+	return offset
+}
+
+// sideBySide renders two blocks as columns, the left one leftWidth wide
+func sideBySide(left string, leftWidth int, right string) string {
+	left = lipgloss.NewStyle().Width(leftWidth).Render(strings.TrimSuffix(left, "\n"))
+	return lipgloss.JoinHorizontal(lipgloss.Top, left, strings.TrimSuffix(right, "\n")) + "\n"
+}
+
+// fit sizes every screen to the terminal: lists get the rows left over by
+// their screen, and names are truncated to the width
+func (m Model) fit() Model {
+	if m.size.Width == 0 && m.size.Height == 0 {
+		return m
+	}
+	width := m.size.Width
+
+	if data := m.classificationData; data != nil {
+		data.Width = width
+	}
+	if data := m.reviewData; data != nil {
+		data.Width = width
+		data.ViewportHeight = m.size.rows(reviewHeader(data), reviewFooter(data))
+		data.ScrollOffset = scrollInto(data.SelectedIndex, data.ScrollOffset, data.ViewportHeight)
+	}
+	if data := m.groupSelectionData; data != nil {
+		data.Width = width
+		data.ViewportHeight = m.size.rows(groupSelectionHeader(data), groupSelectionFooter(data))
+		data.ScrollOffset = scrollInto(data.SelectedIndex, data.ScrollOffset, data.ViewportHeight)
+	}
+	if data := m.groupInsertionData; data != nil {
+		data.Width = width
+		data.ViewportHeight = m.size.rows(groupInsertionHeader(data), groupInsertionFooter(data))
+		if data.Mode == ModeGroupSelection {
+			data.ScrollOffset = scrollInto(data.SelectedPosition, data.ScrollOffset, data.ViewportHeight)
+		}
+	}
+	if data := m.groupManagementData; data != nil {
+		data.Width = width
+		data.ViewportHeight = m.size.rows(groupManagementHeader(), groupManagementFooter(data))
+		if data.Mode == ModeGroupMergeTarget {
+			data.scrollTo(data.TargetIndex)
+		} else {
+			data.scrollTo(data.SelectedIndex)
+		}
+	}
+	if data := m.tagPickerData; data != nil {
+		data.Width = width
+		data.ViewportHeight = m.size.rows(tagPickerHeader(data), tagPickerFooter(data))
+		data.ScrollOffset = scrollInto(data.SelectedIndex, data.ScrollOffset, data.ViewportHeight)
+	}
+	if data := m.bulkSelectData; data != nil {
+		data.Width = width
+		data.ViewportHeight = m.size.rows(bulkSelectHeader(data), bulkSelectFooter(data))
+		data.scrollToCursor()
+	}
+	if data := m.completionData; data != nil {
+		data.Width = width
+	}
+	return m
+}
@@ -0,0 +1,187 @@
+// ui/layout_test.go
+package ui
+
+import (
+	"clip-tagger/state"
+	"fmt"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+func TestTruncateMiddle(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		width int
+		want  string
+	}{
+		{"fits", "C0001.MP4", 9, "C0001.MP4"},
+		{"no limit", "C0001.MP4", 0, "C0001.MP4"},
+		{"keeps both ends", "day1/interview/C0001.MP4", 11, "day1/…1.MP4"},
+		{"one column", "C0001.MP4", 1, "…"},
+		{"runes", "äöüäöüäöü.mp4", 7, "äöü…mp4"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := truncateMiddle(tt.input, tt.width)
+			if got != tt.want {
+				t.Errorf("truncateMiddle(%q, %d) = %q, want %q", tt.input, tt.width, got, tt.want)
+			}
+			if tt.width > 0 && len([]rune(got)) > tt.width {
+				t.Errorf("expected at most %d runes, got %q", tt.width, got)
+			}
+		})
+	}
+}
+
+// layoutModel returns a model with many groups, takes with long names and
+// a skipped clip, on the review screen
+func layoutModel(t *testing.T) Model {
+	t.Helper()
+	appState := state.NewState(t.TempDir(), state.SortByName)
+	var files []string
+	for i := range 40 {
+		group := state.NewGroup(fmt.Sprintf("scene %d", i+1), i+1)
+		appState.Groups = append(appState.Groups, group)
+		file := fmt.Sprintf("day1/card-a/interview-with-a-very-long-name-%02d.MP4", i)
+		appState.AddOrUpdateClassification(file, group.ID)
+		files = append(files, file)
+	}
+	appState.Skip("day1/card-a/broken.MP4")
+	files = append(files, "day1/card-a/broken.MP4")
+
+	model := NewModel(appState, appState.Directory)
+	model.files = files
+	model.currentFileIndex = len(files)
+	model.currentScreen = ScreenReview
+	model.reviewData = NewReviewData(appState, files)
+	return model
+}
+
+func TestModel_WindowSizeFitsScreens(t *testing.T) {
+	screens := []struct {
+		screen Screen
+		setup  func(m *Model)
+	}{
+		{ScreenReview, func(m *Model) { m.reviewData.Notice = "Undid: move take" }},
+		{ScreenGroupSelection, func(m *Model) {
+			m.groupSelectionData = NewGroupSelectionData(m.state, m.files[0])
+		}},
+		{ScreenGroupInsertion, func(m *Model) {
+			m.groupInsertionData = NewGroupInsertionData(m.state, m.files[0])
+			m.groupInsertionData.Mode = ModeGroupSelection
+			m.groupInsertionData.SubGroup = true
+		}},
+		{ScreenReview, func(m *Model) { m.reviewData.EditingNotes = true }},
+		{ScreenGroupManagement, func(m *Model) {
+			m.groupManagementData = NewGroupManagementData(m.state, ScreenReview)
+			m.groupManagementData.Message = "Renamed scene 1"
+		}},
+		{ScreenGroupManagement, func(m *Model) {
+			m.groupManagementData = NewGroupManagementData(m.state, ScreenReview)
+			m.groupManagementData.Mode = ModeGroupMergeTarget
+		}},
+		{ScreenTagPicker, func(m *Model) {
+			m.state.SetTags(m.files[0], []string{"b-roll"})
+			for i := range 30 {
+				m.state.SetTags(m.files[i+1], []string{fmt.Sprintf("tag %d", i)})
+			}
+			m.tagPickerData = NewTagPickerData(m.state, TagPickerFilter, m.files[0], nil, "b-roll")
+		}},
+		{ScreenBulkSelect, func(m *Model) {
+			m.bulkSelectData = NewBulkSelectData(m.state, m.files, 0)
+		}},
+	}
+
+	for _, tt := range screens {
+		for _, size := range []tea.WindowSizeMsg{{Width: 80, Height: 30}, {Width: 140, Height: 40}} {
+			t.Run(fmt.Sprintf("%d at %dx%d", tt.screen, size.Width, size.Height), func(t *testing.T) {
+				model := layoutModel(t)
+				tt.setup(&model)
+				model.currentScreen = tt.screen
+
+				updated, _ := model.Update(size)
+				model = updated.(Model)
+				// Scroll to the middle, where both scroll indicators show
+				for range 20 {
+					updated, _ = model.Update(tea.KeyMsg{Type: tea.KeyDown})
+					model = updated.(Model)
+				}
+
+				view := model.View()
+				if height := lipgloss.Height(view); height > size.Height {
+					t.Errorf("expected the view to fit %d lines, got %d:\n%s", size.Height, height, view)
+				}
+				for _, line := range strings.Split(view, "\n") {
+					if width := lipgloss.Width(line); width > size.Width {
+						t.Errorf("expected lines to fit %d columns, got %d: %q", size.Width, width, line)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestModel_WindowSizeMeasuresChrome(t *testing.T) {
+	model := layoutModel(t)
+	updated, _ := model.Update(tea.WindowSizeMsg{Width: 80, Height: 30})
+	model = updated.(Model)
+	rows := model.reviewData.ViewportHeight
+
+	// A notice takes its lines from the list instead of pushing the
+	// instructions off the screen
+	model.reviewData.Notice = "Undid: move take"
+	updated, _ = model.Update(tea.KeyMsg{Type: tea.KeyDown})
+	model = updated.(Model)
+	if got := model.reviewData.ViewportHeight; got != rows-2 {
+		t.Errorf("expected %d rows with a notice, got %d", rows-2, got)
+	}
+	if height := lipgloss.Height(model.View()); height > 30 {
+		t.Errorf("expected the view to fit 30 lines, got %d", height)
+	}
+
+	model.reviewData.Notice = ""
+	updated, _ = model.Update(tea.KeyMsg{Type: tea.KeyDown})
+	model = updated.(Model)
+	if got := model.reviewData.ViewportHeight; got != rows {
+		t.Errorf("expected %d rows again without the notice, got %d", rows, got)
+	}
+}
+
+func TestReviewView_WideLayout(t *testing.T) {
+	model := layoutModel(t)
+	model.state.Annotate(model.files[0], state.Annotation{Circled: true, Notes: "focus buzz at end"})
+	model.reviewData = NewReviewData(model.state, model.files)
+
+	updated, _ := model.Update(tea.WindowSizeMsg{Width: 80, Height: 30})
+	model = updated.(Model)
+	view := model.View()
+	if strings.Contains(view, "Clip details") || strings.Contains(view, "focus buzz at end") || !strings.Contains(view, "circled") {
+		t.Errorf("expected shortened notes in the list on a narrow terminal, got:\n%s", view)
+	}
+
+	updated, _ = model.Update(tea.WindowSizeMsg{Width: 160, Height: 30})
+	model = updated.(Model)
+	view = model.View()
+	for _, expected := range []string{"Clip details", "circled", "focus buzz at end", model.files[0]} {
+		if !strings.Contains(view, expected) {
+			t.Errorf("expected the details to contain %q, got:\n%s", expected, view)
+		}
+	}
+}
+
+func TestModel_NoWindowSizeKeepsDefaults(t *testing.T) {
+	model := layoutModel(t)
+	updated, _ := model.Update(tea.KeyMsg{Type: tea.KeyDown})
+	model = updated.(Model)
+	if model.reviewData.ViewportHeight != 10 || model.reviewData.Width != 0 {
+		t.Errorf("expected the default layout, got height %d and width %d",
+			model.reviewData.ViewportHeight, model.reviewData.Width)
+	}
+	if !strings.Contains(model.View(), model.files[0]) {
+		t.Error("expected names not to be truncated")
+	}
+}
@@ -1,7 +1,10 @@
 // ui/messages.go
 package ui
 
-import "clip-tagger/state"
+import (
+	"clip-tagger/scanner"
+	"clip-tagger/state"
+)
 
 // TransitionToScreen is a message to transition to a different screen
 type TransitionToScreen struct {
@@ -20,8 +23,7 @@ type ErrorMsg struct {
 
 // StartupInitialized is sent when startup screen is initialized
 type StartupInitialized struct {
-	ScannedFiles []string
-	MergeResult  *state.MergeResult
+	Scanned []scanner.FileInfo // Scanned files in sort order; Update merges them with the state
 }
 
 // ClassificationInitialized is sent when classification screen is initialized
@@ -50,7 +52,22 @@ type GroupInsertionInitialized struct {
 type GroupInserted struct {
 	GroupID   string
 	GroupName string
-	Order     int
+	Order     int    // Position among the groups with the same parent
+	ParentID  string // Parent group for a sub-group, "" at the top level
+}
+
+// GroupManagementInitialized is sent when group management screen is initialized
+type GroupManagementInitialized struct {
+	ReturnScreen Screen // Screen to go back to when done
+}
+
+// BulkSelectInitialized is sent when multi-select screen is initialized
+type BulkSelectInitialized struct {
+}
+
+// TagPickerInitialized is sent when tag picker screen is initialized
+type TagPickerInitialized struct {
+	Mode TagPickerMode
 }
 
 // CompletionInitialized is sent when completion screen is initialized
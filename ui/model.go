@@ -6,6 +6,8 @@ import (
 	"clip-tagger/scanner"
 	"clip-tagger/state"
 	"fmt"
+	"path/filepath"
+	"slices"
 
 	tea "github.com/charmbracelet/bubbletea"
 )
@@ -20,6 +22,9 @@ const (
 	ScreenGroupInsertion
 	ScreenReview
 	ScreenComplete
+	ScreenGroupManagement
+	ScreenTagPicker
+	ScreenBulkSelect
 )
 
 // String returns the string representation of a Screen
@@ -37,6 +42,12 @@ func (s Screen) String() string {
 		return "review"
 	case ScreenComplete:
 		return "complete"
+	case ScreenGroupManagement:
+		return "group_management"
+	case ScreenTagPicker:
+		return "tag_picker"
+	case ScreenBulkSelect:
+		return "bulk_select"
 	default:
 		return "unknown"
 	}
@@ -54,11 +65,23 @@ type Model struct {
 	groupInsertionData *GroupInsertionData
 	reviewData         *ReviewData
 	completionData     *CompletionData
+	groupManagementData *GroupManagementData
+	tagPickerData      *TagPickerData
+	bulkSelectData     *BulkSelectData
 	files                 []string // List of files being classified
 	currentFileIndex      int      // Current file index in files list
 	lastClassifiedGroupID string   // Most recently classified group ID (for "Same as Last")
+	tagFilter             string   // Only clips with this tag are visited, "" for all
+	revisitSkipped        bool     // Only skipped clips are visited
+	bulkFiles             []string // Clips selected for one group, while it is chosen
+	reviewFile            string   // Clip moved from the review screen, while its group is chosen
+	size                  Size     // Terminal size, zero until reported
+	keys                  Keymap   // Key bindings of every screen
+	showHelp              bool     // The key help overlay is shown
 	actionCounter         int      // Counter for periodic auto-saves
 	actionsPerSave        int      // Number of actions before auto-save (default: 5)
+	lockHolder            *state.LockInfo // Set when another session holds the directory lock
+	staleLock             *state.LockInfo // Abandoned lock taken over at startup
 }
 
 // NewModel creates a new Model with the given state and directory
@@ -78,68 +101,129 @@ func NewModel(appState *state.State, directory string) Model {
 		currentFileIndex:   appState.CurrentIndex,
 		actionCounter:      0,
 		actionsPerSave:     5, // Default: save every 5 actions
+		keys:               DefaultKeymap(),
 	}
 }
 
+// SetKeymap replaces the default key bindings, e.g. with ones loaded by
+// LoadKeymap
+func (m *Model) SetKeymap(keys Keymap) {
+	m.keys = keys
+	*m = m.keysToScreens()
+}
+
+// SetLockStatus records the session lock outcome for the startup screen.
+// With a holder set the model is read-only: nothing is scanned or saved.
+func (m *Model) SetLockStatus(holder, stale *state.LockInfo) {
+	m.lockHolder = holder
+	m.staleLock = stale
+}
+
 // Init initializes the model
 func (m Model) Init() tea.Cmd {
+	// Another session owns the directory; only explain that
+	if m.lockHolder != nil {
+		return func() tea.Msg { return StartupInitialized{} }
+	}
+
 	// Return a command to initialize the startup screen
 	return func() tea.Msg {
 		// Scan directory for video files
-		scan := scanner.NewScanner(m.directory)
+		scan := scanner.NewScannerWithOptions(m.directory, scanner.Options{
+			Recursive: m.state.Recursive,
+			Include:   m.state.Include,
+			Exclude:   m.state.ScanExcludes(),
+
+			ReadMetadata: true,
+			Cache:        m.state,
+		})
 		result, err := scan.Scan(scanner.SortBy(m.state.SortBy))
 		if err != nil {
 			return ErrorMsg{Err: fmt.Sprintf("Failed to scan directory: %v", err)}
 		}
 
-		// Extract filenames from scan result
-		scannedFiles := make([]string, len(result.Files))
-		for i, f := range result.Files {
-			scannedFiles[i] = f.Name
-		}
-
-		// If state has classifications, merge with scanned files
-		var mergeResult *state.MergeResult
-		if len(m.state.Classifications) > 0 {
-			mergeResult = state.MergeFiles(m.state, scannedFiles)
-
-			// Auto-repair: Try to match missing files to renamed files
-			if mergeResult != nil && len(mergeResult.MissingFiles) > 0 {
-				repairedCount := m.state.RepairRenamedFiles(scannedFiles)
-				if repairedCount > 0 {
-					// Save repaired state
-					statePath := state.StateFilePath(m.state.Directory)
-					_ = m.state.Save(statePath) // Ignore error - repair is best-effort
+		return StartupInitialized{Scanned: result.Files}
+	}
+}
 
-					// Re-run merge to get updated missing files list
-					mergeResult = state.MergeFiles(m.state, scannedFiles)
-				}
-			}
+// mergeScan caches the scanned files' metadata and merges them with the
+// saved classifications, repairing files renamed outside the session. It
+// runs on the event loop, as the scan command must not write to the state
+// while views read it. Returns the file names in sort order, the merge
+// result (nil for a new session) and how many files fell back to another
+// sort key.
+func (m Model) mergeScan(scanned []scanner.FileInfo) ([]string, *state.MergeResult, int) {
+	// Cache container metadata so later sessions don't re-parse unchanged files
+	fallbackCount := 0
+	scannedFiles := make([]string, len(scanned))
+	for i, f := range scanned {
+		if f.Metadata != nil {
+			m.state.CacheMetadata(f.Name, f.Size, f.ModifiedTime, *f.Metadata)
 		}
+		if !scanner.HasSortKey(f, scanner.SortBy(m.state.SortBy)) {
+			fallbackCount++
+		}
+		scannedFiles[i] = f.Name
+	}
 
-		return StartupInitialized{
-			ScannedFiles: scannedFiles,
-			MergeResult:  mergeResult,
+	// If state has classifications, merge with scanned files
+	var mergeResult *state.MergeResult
+	if len(m.state.Classifications) > 0 && m.lockHolder == nil {
+		mergeResult = state.MergeFiles(m.state, scannedFiles)
+
+		// Auto-repair: Try to match missing files to renamed files
+		if len(mergeResult.MissingFiles) > 0 {
+			repairedCount := m.state.RepairRenamedFiles(scannedFiles)
+			if repairedCount > 0 {
+				// Save repaired state
+				statePath := state.StateFilePath(m.state.Directory)
+				_ = m.state.Save(statePath) // Ignore error - repair is best-effort
+
+				// Re-run merge to get updated missing files list
+				mergeResult = state.MergeFiles(m.state, scannedFiles)
+			}
 		}
 	}
+	return scannedFiles, mergeResult, fallbackCount
 }
 
-// Update handles messages and updates the model
+// Update handles messages and updates the model. Screens are fitted to the
+// terminal and given the keymap after every message, so ones created by it
+// fit and show the bound keys too.
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if size, ok := msg.(tea.WindowSizeMsg); ok {
+		m.size = Size{Width: size.Width, Height: size.Height}
+		return m.fit(), nil
+	}
+	updated, cmd := m.update(msg)
+	return updated.(Model).fit().keysToScreens(), cmd
+}
+
+// update handles a message for the current screen
+func (m Model) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		// Handle startup screen keys
-		if m.currentScreen == ScreenStartup && m.startupData != nil {
-			var keyMsg string
-			switch msg.Type {
-			case tea.KeyEnter:
-				keyMsg = "enter"
-			case tea.KeyCtrlC:
-				keyMsg = "ctrl+c"
-			default:
-				keyMsg = msg.String()
+		// Any key closes the help overlay
+		if m.showHelp {
+			if msg.String() == quitKey {
+				return m, tea.Quit
 			}
+			m.showHelp = false
+			return m, nil
+		}
+		context, typing := m.keyContext()
+		if msg.String() == helpKey && context != KeysNone && !typing {
+			m.showHelp = true
+			return m, nil
+		}
+		// Rebound keys are translated to the keys the screens handle
+		keyMsg, ok := m.keys.Translate(context, msg.String())
+		if !ok {
+			return m, nil
+		}
 
+		// Handle startup screen keys
+		if m.currentScreen == ScreenStartup && m.startupData != nil {
 			screen := StartupUpdate(m.startupData, keyMsg)
 			if screen == -1 {
 				return m, tea.Quit
@@ -161,14 +245,6 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		// Handle classification screen keys
 		if m.currentScreen == ScreenClassification && m.classificationData != nil {
-			var keyMsg string
-			switch msg.Type {
-			case tea.KeyCtrlC:
-				keyMsg = "ctrl+c"
-			default:
-				keyMsg = msg.String()
-			}
-
 			result := ClassificationUpdate(m.classificationData, keyMsg)
 			if result.Screen == -1 {
 				return m, tea.Quit
@@ -194,6 +270,28 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 						}
 					}
 				}
+				// If transitioning to group management, initialize it
+				if result.Screen == ScreenGroupManagement {
+					return m, func() tea.Msg {
+						return GroupManagementInitialized{ReturnScreen: ScreenClassification}
+					}
+				}
+				// If transitioning to multi-select, initialize it
+				if result.Screen == ScreenBulkSelect {
+					return m, func() tea.Msg {
+						return BulkSelectInitialized{}
+					}
+				}
+				// If transitioning to the tag picker, initialize it
+				if result.Screen == ScreenTagPicker {
+					mode := TagPickerAssign
+					if result.Action == ClassificationActionFilterTag {
+						mode = TagPickerFilter
+					}
+					return m, func() tea.Msg {
+						return TagPickerInitialized{Mode: mode}
+					}
+				}
 				return m, nil
 			}
 			// result.Screen == -2 means no screen change
@@ -208,13 +306,70 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 			// Handle "Same as Last" action
 			if result.Action == ClassificationActionSameAsLast {
+				before := m.checkpoint()
+				label := "classify " + m.classificationData.CurrentFile
 				m = m.handleClassificationSameAsLast()
+				m.state.Record(label, before)
 				m = m.incrementActionAndMaybeSave()
 				return m, nil
 			}
-			// Handle "Skip" action
-			if result.Action == ClassificationActionSkip {
-				m = m.handleClassificationSkip()
+			// Handle "Skip" and "Reject" actions
+			if result.Action == ClassificationActionSkip || result.Action == ClassificationActionReject {
+				before := m.checkpoint()
+				label := "skip " + m.classificationData.CurrentFile
+				if result.Action == ClassificationActionReject {
+					label = "reject " + m.classificationData.CurrentFile
+				}
+				m = m.handleClassificationSkip(result.Action == ClassificationActionReject)
+				m.state.Record(label, before)
+				m = m.incrementActionAndMaybeSave()
+				return m, nil
+			}
+			// Handle undo and redo
+			if result.Action == ClassificationActionUndo || result.Action == ClassificationActionRedo {
+				m = m.handleUndo(result.Action == ClassificationActionRedo)
+				return m, nil
+			}
+			// Handle navigation between files
+			if result.Action == ClassificationActionPrevious {
+				m = m.handleClassificationNavigate(-1)
+				return m, nil
+			}
+			if result.Action == ClassificationActionNext {
+				m = m.handleClassificationNavigate(1)
+				return m, nil
+			}
+			// Handle the suggestion gap
+			if result.Action == ClassificationActionSetGap {
+				m.state.SuggestGap = m.classificationData.SuggestGap
+				m.classificationData.suggest(m.state, m.files, m.currentFileIndex)
+				m = m.autoSaveState()
+				return m, nil
+			}
+			// Handle auto-grouping the remaining clips
+			if result.Action == ClassificationActionPrefill {
+				before := m.checkpoint()
+				m = m.handleClassificationPrefill()
+				m.state.Record("auto-group by time gap", before)
+				m = m.autoSaveState()
+				return m, nil
+			}
+			// Handle what happens to a skipped file
+			if result.Action == ClassificationActionDisposition {
+				m = m.handleDisposition()
+				return m, nil
+			}
+			// Handle rating, circle and notes
+			if result.Action == ClassificationActionAnnotate {
+				m = m.handleClassificationAnnotate()
+				return m, nil
+			}
+			// Handle "Unclassify" action
+			if result.Action == ClassificationActionUnclassify {
+				before := m.checkpoint()
+				label := "unclassify " + m.classificationData.CurrentFile
+				m = m.handleClassificationUnclassify()
+				m.state.Record(label, before)
 				m = m.incrementActionAndMaybeSave()
 				return m, nil
 			}
@@ -222,31 +377,21 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		// Handle group selection screen keys
 		if m.currentScreen == ScreenGroupSelection && m.groupSelectionData != nil {
-			var keyMsg string
-			switch msg.Type {
-			case tea.KeyCtrlC:
-				keyMsg = "ctrl+c"
-			case tea.KeyEnter:
-				keyMsg = "enter"
-			case tea.KeyEsc:
-				keyMsg = "esc"
-			case tea.KeyUp:
-				keyMsg = "up"
-			case tea.KeyDown:
-				keyMsg = "down"
-			case tea.KeyBackspace:
-				keyMsg = "backspace"
-			case tea.KeySpace:
-				keyMsg = " "
-			default:
-				keyMsg = msg.String()
-			}
-
 			result := GroupSelectionUpdate(m.groupSelectionData, keyMsg)
 			if result.Screen == -1 {
 				return m, tea.Quit
 			} else if result.Screen >= 0 {
 				m.currentScreen = result.Screen
+				// Cancelling a multi-select assignment keeps the selection
+				if result.SelectedGroupID == "" && m.bulkFiles != nil {
+					m.bulkFiles = nil
+					m.currentScreen = ScreenBulkSelect
+				}
+				// Cancelling a move from the review screen returns there
+				if result.SelectedGroupID == "" && m.reviewFile != "" {
+					m.reviewFile = ""
+					m.currentScreen = ScreenReview
+				}
 				// If a group was selected, send GroupSelected message
 				if result.SelectedGroupID != "" {
 					return m, func() tea.Msg {
@@ -263,31 +408,21 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		// Handle group insertion screen keys
 		if m.currentScreen == ScreenGroupInsertion && m.groupInsertionData != nil {
-			var keyMsg string
-			switch msg.Type {
-			case tea.KeyCtrlC:
-				keyMsg = "ctrl+c"
-			case tea.KeyEnter:
-				keyMsg = "enter"
-			case tea.KeyEsc:
-				keyMsg = "esc"
-			case tea.KeyUp:
-				keyMsg = "up"
-			case tea.KeyDown:
-				keyMsg = "down"
-			case tea.KeyBackspace:
-				keyMsg = "backspace"
-			case tea.KeySpace:
-				keyMsg = " "
-			default:
-				keyMsg = msg.String()
-			}
-
 			result := GroupInsertionUpdate(m.groupInsertionData, keyMsg)
 			if result.Screen == -1 {
 				return m, tea.Quit
 			} else if result.Screen >= 0 {
 				m.currentScreen = result.Screen
+				// Cancelling a multi-select assignment keeps the selection
+				if result.InsertedGroupID == "" && m.bulkFiles != nil {
+					m.bulkFiles = nil
+					m.currentScreen = ScreenBulkSelect
+				}
+				// Cancelling a move from the review screen returns there
+				if result.InsertedGroupID == "" && m.reviewFile != "" {
+					m.reviewFile = ""
+					m.currentScreen = ScreenReview
+				}
 				// If a group was inserted, send GroupInserted message
 				if result.InsertedGroupID != "" {
 					return m, func() tea.Msg {
@@ -295,6 +430,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 							GroupID:   result.InsertedGroupID,
 							GroupName: result.InsertedGroupName,
 							Order:     result.InsertedOrder,
+							ParentID:  result.InsertedParentID,
 						}
 					}
 				}
@@ -305,74 +441,146 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		// Handle review screen keys
 		if m.currentScreen == ScreenReview && m.reviewData != nil {
-			var keyMsg string
-			switch msg.Type {
-			case tea.KeyCtrlC:
-				keyMsg = "ctrl+c"
-			case tea.KeyEnter:
-				keyMsg = "enter"
-			case tea.KeyEsc:
-				keyMsg = "esc"
-			case tea.KeyUp:
-				keyMsg = "up"
-			case tea.KeyDown:
-				keyMsg = "down"
-			default:
-				keyMsg = msg.String()
-			}
-
 			result := ReviewUpdate(m.reviewData, keyMsg)
+			if result.Undo || result.Redo {
+				m = m.handleUndo(result.Redo)
+				return m, nil
+			}
+			switch result.Action {
+			case ReviewActionRevisitSkipped:
+				m = m.handleRevisitSkipped()
+				return m, nil
+			case ReviewActionDisposition:
+				m = m.setDisposition(result.File, result.Disposition)
+				m = m.refreshReview(result.File)
+				return m, nil
+			case ReviewActionPreview:
+				err := preview.OpenFile(filepath.Join(m.state.Directory, result.File))
+				if err != nil {
+					m.err = fmt.Sprintf("Failed to preview file: %v", err)
+				}
+				return m, nil
+			case ReviewActionMoveTake, ReviewActionUnskip, ReviewActionNotes:
+				m = m.handleReviewEdit(result)
+				return m, nil
+			}
 			if result.Screen == -1 {
 				return m, tea.Quit
 			} else if result.Screen >= 0 {
 				m.currentScreen = result.Screen
-				// If going back to classification, need to reinitialize classification data
+				// If going back to classification, continue at the selected file
 				if result.Screen == ScreenClassification {
-					// Reset to first file to allow re-classification
-					m.currentFileIndex = 0
+					index := max(slices.Index(m.files, result.File), 0)
+					m.currentFileIndex = index
+					m.state.CurrentIndex = index
+					m.tagFilter = ""
+					m.revisitSkipped = false
 					return m, func() tea.Msg {
 						return ClassificationInitialized{
 							Files:     m.files,
-							FileIndex: 0,
+							FileIndex: index,
 						}
 					}
 				}
+				// If moving the selected file to a group, choose or create it
+				if result.Screen == ScreenGroupSelection {
+					m.reviewFile = result.File
+					return m, func() tea.Msg {
+						return GroupSelectionInitialized{CurrentFile: result.File}
+					}
+				}
+				if result.Screen == ScreenGroupInsertion {
+					m.reviewFile = result.File
+					return m, func() tea.Msg {
+						return GroupInsertionInitialized{CurrentFile: result.File}
+					}
+				}
 				// If transitioning to completion screen, initialize it
 				if result.Screen == ScreenComplete {
 					return m, func() tea.Msg {
 						return CompletionInitialized{}
 					}
 				}
+				// If transitioning to group management, initialize it
+				if result.Screen == ScreenGroupManagement {
+					return m, func() tea.Msg {
+						return GroupManagementInitialized{ReturnScreen: ScreenReview}
+					}
+				}
 				return m, nil
 			}
 			// result.Screen == -2 means no screen change, continue
 		}
 
-		// Handle completion screen keys
-		if m.currentScreen == ScreenComplete && m.completionData != nil {
-			var keyMsg string
-			switch msg.Type {
-			case tea.KeyCtrlC:
-				keyMsg = "ctrl+c"
-			case tea.KeyEnter:
-				keyMsg = "enter"
-			case tea.KeyEsc:
-				keyMsg = "esc"
-			case tea.KeyUp:
-				keyMsg = "up"
-			case tea.KeyDown:
-				keyMsg = "down"
-			default:
-				keyMsg = msg.String()
+		// Handle group management screen keys
+		if m.currentScreen == ScreenGroupManagement && m.groupManagementData != nil {
+			result := GroupManagementUpdate(m.groupManagementData, keyMsg)
+			if result.Screen == -1 {
+				return m, tea.Quit
+			}
+			if result.Operation != GroupOperationNone {
+				m = m.handleGroupOperation(result)
+				return m, nil
+			}
+			if result.Screen >= 0 {
+				m = m.leaveGroupManagement(result.Screen)
+				return m, nil
+			}
+			// result.Screen == -2 means no screen change, continue
+		}
+
+		// Handle multi-select screen keys
+		if m.currentScreen == ScreenBulkSelect && m.bulkSelectData != nil {
+			result := BulkSelectUpdate(m.bulkSelectData, keyMsg)
+			if result.Screen == -1 {
+				return m, tea.Quit
+			} else if result.Screen >= 0 {
+				m.currentScreen = result.Screen
+				m.bulkFiles = result.Files
+				label := fmt.Sprintf("%d selected clips", len(result.Files))
+				if result.Screen == ScreenGroupSelection {
+					return m, func() tea.Msg {
+						return GroupSelectionInitialized{CurrentFile: label}
+					}
+				}
+				if result.Screen == ScreenGroupInsertion {
+					return m, func() tea.Msg {
+						return GroupInsertionInitialized{CurrentFile: label}
+					}
+				}
+				return m, nil
+			}
+			// result.Screen == -2 means no screen change, continue
+		}
+
+		// Handle tag picker screen keys
+		if m.currentScreen == ScreenTagPicker && m.tagPickerData != nil {
+			result := TagPickerUpdate(m.tagPickerData, keyMsg)
+			if result.Screen == -1 {
+				return m, tea.Quit
+			} else if result.Screen >= 0 {
+				m.currentScreen = result.Screen
+				if result.TagsDone {
+					m = m.handleTagsChosen(result.Tags)
+				}
+				if result.FilterSet {
+					m = m.handleTagFilter(result.Filter)
+				}
+				return m, nil
 			}
+			// result.Screen == -2 means no screen change, continue
+		}
 
+		// Handle completion screen keys
+		if m.currentScreen == ScreenComplete && m.completionData != nil {
+			alreadyExecuted := m.completionData.ExecutionResult != nil
 			result := CompletionUpdate(m.completionData, keyMsg)
 
-			// If completion execution succeeded, update state with new filenames
-			if m.completionData.ExecutionResult != nil && m.completionData.ExecutionResult.Success {
+			// If completion execution just succeeded, update state with new filenames
+			if !alreadyExecuted && m.completionData.ExecutionResult != nil && m.completionData.ExecutionResult.Success {
 				updateStateAfterRename(
 					m.state,
-					m.completionData.Renames,
+					m.completionData.operations(),
 					m.completionData.ExecutionResult.Mode,
 					m.completionData.OutputDirectory,
 				)
@@ -395,23 +603,19 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 
 	case StartupInitialized:
-		m.startupData = NewStartupData(m.state, msg.ScannedFiles, msg.MergeResult)
+		scannedFiles, mergeResult, fallbackCount := m.mergeScan(msg.Scanned)
+		m.startupData = NewStartupData(m.state, scannedFiles, mergeResult)
+		m.startupData.FallbackCount = fallbackCount
+		m.startupData.LockHolder = m.lockHolder
+		m.startupData.StaleLock = m.staleLock
 		// Store files for classification
-		m.files = msg.ScannedFiles
+		m.files = scannedFiles
 
-		// Find first unclassified file in the entire list
+		// Find first unclassified file in the entire list; skipped files
+		// come back through the review screen
 		// Start from 0 since file order may have changed after rescan/resort
 		m.currentFileIndex = 0
-		for m.currentFileIndex < len(m.files) {
-			currentFile := m.files[m.currentFileIndex]
-			_, classified := m.state.GetClassification(currentFile)
-			if !classified {
-				// Found an unclassified file
-				break
-			}
-			// This file is classified, move to next
-			m.currentFileIndex++
-		}
+		m.findNextUnclassifiedFile()
 
 		// Update state's CurrentIndex to match
 		m.state.CurrentIndex = m.currentFileIndex
@@ -427,6 +631,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	case ClassificationInitialized:
 		m.classificationData = NewClassificationData(m.state, msg.Files, msg.FileIndex, m.lastClassifiedGroupID)
+		m.setFilterProgress(m.classificationData)
 		return m, nil
 
 	case GroupSelectionInitialized:
@@ -435,7 +640,18 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	case GroupSelected:
 		// Group was selected, handle classification
-		m = m.handleGroupSelected(msg.GroupID)
+		before := m.checkpoint()
+		label := "classify " + m.currentFile() + " as " + msg.GroupName
+		if len(m.bulkFiles) > 0 {
+			label = fmt.Sprintf("classify %d clips as %s", len(m.bulkFiles), msg.GroupName)
+			m = m.handleBulkAssigned(msg.GroupID)
+		} else if m.reviewFile != "" {
+			label = "move " + m.reviewFile + " to " + msg.GroupName
+			m = m.handleReviewAssigned(msg.GroupID)
+		} else {
+			m = m.handleGroupSelected(msg.GroupID)
+		}
+		m.state.Record(label, before)
 		// Auto-save state after group selection (immediate save)
 		m = m.autoSaveState()
 		return m, nil
@@ -448,22 +664,54 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		// Group was inserted, add to state and handle classification
 		// Create the group with the specified order
 		newGroup := state.Group{
-			ID:    msg.GroupID,
-			Name:  msg.GroupName,
-			Order: msg.Order,
+			ID:       msg.GroupID,
+			Name:     msg.GroupName,
+			Order:    msg.Order,
+			ParentID: msg.ParentID,
 		}
 
-		// Insert group at the correct position and renumber
-		insertGroupAtPosition(&m.state.Groups, newGroup, msg.Order)
+		// Insert group at the correct position and renumber; both steps
+		// are undone together
+		before := m.checkpoint()
+		label := "create group " + msg.GroupName + " for " + m.currentFile()
+		if len(m.bulkFiles) > 0 {
+			label = fmt.Sprintf("create group %s for %d clips", msg.GroupName, len(m.bulkFiles))
+		} else if m.reviewFile != "" {
+			label = "create group " + msg.GroupName + " for " + m.reviewFile
+		}
+		if err := m.state.InsertGroup(newGroup); err != nil {
+			m.err = fmt.Sprintf("Failed to create group: %v", err)
+			return m, nil
+		}
 
 		// Handle classification with the new group
-		m = m.handleGroupInserted(msg.GroupID, msg.GroupName, msg.Order)
+		if len(m.bulkFiles) > 0 {
+			m = m.handleBulkAssigned(msg.GroupID)
+		} else if m.reviewFile != "" {
+			m = m.handleReviewAssigned(msg.GroupID)
+		} else {
+			m = m.handleGroupInserted(msg.GroupID, msg.GroupName, msg.Order)
+		}
+		m.state.Record(label, before)
 
 		// Auto-save state after group insertion (immediate save)
 		m = m.autoSaveState()
 
 		return m, nil
 
+	case GroupManagementInitialized:
+		m.groupManagementData = NewGroupManagementData(m.state, msg.ReturnScreen)
+		return m, nil
+
+	case BulkSelectInitialized:
+		m.bulkSelectData = NewBulkSelectData(m.state, m.files, m.currentFileIndex)
+		return m, nil
+
+	case TagPickerInitialized:
+		m.tagPickerData = NewTagPickerData(m.state, msg.Mode, m.classificationData.CurrentFile,
+			m.classificationData.Tags, m.tagFilter)
+		return m, nil
+
 	case CompletionInitialized:
 		m.completionData = NewCompletionData(m.state)
 		return m, nil
@@ -488,29 +736,6 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
-// insertGroupAtPosition inserts a group at the specified order position and renumbers all groups
-func insertGroupAtPosition(groups *[]state.Group, newGroup state.Group, order int) {
-	// Find the insertion index based on order
-	insertIndex := 0
-	for i, g := range *groups {
-		if g.Order >= order {
-			insertIndex = i
-			break
-		}
-		insertIndex = i + 1
-	}
-
-	// Insert the group at the correct position
-	*groups = append(*groups, state.Group{})
-	copy((*groups)[insertIndex+1:], (*groups)[insertIndex:])
-	(*groups)[insertIndex] = newGroup
-
-	// Renumber all groups to maintain sequential order
-	for i := range *groups {
-		(*groups)[i].Order = i + 1
-	}
-}
-
 // autoSaveState saves the current state to disk and handles errors gracefully
 // This is called after key state-changing actions:
 // - GroupSelected: After a group is selected
@@ -519,6 +744,10 @@ func insertGroupAtPosition(groups *[]state.Group, newGroup state.Group, order in
 // - ClassificationActionSkip: After skipping a file
 // - Screen transitions: When leaving classification screen
 func (m Model) autoSaveState() Model {
+	// Never write over the state of the session holding the lock
+	if m.lockHolder != nil {
+		return m
+	}
 	statePath := state.StateFilePath(m.directory)
 	err := m.state.Save(statePath)
 	if err != nil {
@@ -544,6 +773,19 @@ func (m Model) View() string {
 	if m.err != "" {
 		return fmt.Sprintf("Error: %s\n\nPress Ctrl+C to quit", m.err)
 	}
+	context, typing := m.keyContext()
+	if m.showHelp {
+		return HelpView(m.keys, context)
+	}
+	view := m.screenView()
+	if context != KeysNone && !typing {
+		view += RenderMuted("? - All keys") + "\n"
+	}
+	return view
+}
+
+// screenView renders the current screen
+func (m Model) screenView() string {
 
 	// Render screens based on current screen
 	switch m.currentScreen {
@@ -577,6 +819,21 @@ func (m Model) View() string {
 			return CompletionView(m.completionData)
 		}
 		return "Loading completion...\n\nPress Ctrl+C to quit"
+	case ScreenGroupManagement:
+		if m.groupManagementData != nil {
+			return GroupManagementView(m.groupManagementData)
+		}
+		return "Loading groups...\n\nPress Ctrl+C to quit"
+	case ScreenBulkSelect:
+		if m.bulkSelectData != nil {
+			return BulkSelectView(m.bulkSelectData)
+		}
+		return "Loading clips...\n\nPress Ctrl+C to quit"
+	case ScreenTagPicker:
+		if m.tagPickerData != nil {
+			return TagPickerView(m.tagPickerData)
+		}
+		return "Loading tags...\n\nPress Ctrl+C to quit"
 	default:
 		return "Unknown Screen\n\nPress Ctrl+C to quit"
 	}
@@ -2,10 +2,13 @@
 package ui
 
 import (
+	"clip-tagger/metadata"
+	"clip-tagger/scanner"
 	"clip-tagger/state"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 )
@@ -59,6 +62,44 @@ func TestModel_Init(t *testing.T) {
 	}
 }
 
+func TestModel_StartupCachesMetadataInUpdate(t *testing.T) {
+	appState := state.NewState(t.TempDir(), state.SortByName)
+	model := NewModel(appState, appState.Directory)
+
+	modTime := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	msg := StartupInitialized{
+		Scanned: []scanner.FileInfo{
+			{Name: "a.mp4", Size: 42, ModifiedTime: modTime, Metadata: &metadata.Metadata{Width: 1920}},
+		},
+	}
+	updated, _ := model.Update(msg)
+	model = updated.(Model)
+
+	md, ok := model.state.GetMetadata("a.mp4")
+	if !ok || md.Width != 1920 {
+		t.Errorf("expected the scanned metadata to be cached, got %v, %v", md, ok)
+	}
+}
+
+func TestModel_StartupRepairsInUpdate(t *testing.T) {
+	appState := state.NewState(t.TempDir(), state.SortByName)
+	group := state.NewGroup("intro", 1)
+	appState.Groups = []state.Group{group}
+	appState.AddOrUpdateClassification("C0001.MP4", group.ID)
+	model := NewModel(appState, appState.Directory)
+
+	// The clip was renamed by an earlier session whose state wasn't saved
+	updated, _ := model.Update(StartupInitialized{Scanned: []scanner.FileInfo{{Name: "[01_01] intro.MP4"}}})
+	model = updated.(Model)
+
+	if c := appState.Classifications[0]; c.File != "[01_01] intro.MP4" {
+		t.Errorf("expected the classification to follow the renamed file, got %s", c.File)
+	}
+	if model.startupData.MissingFilesCount != 0 || !state.StateExists(appState.Directory) {
+		t.Errorf("expected the repaired state to be merged and saved, got %d missing", model.startupData.MissingFilesCount)
+	}
+}
+
 func TestModel_Update_ScreenTransitions(t *testing.T) {
 	appState := state.NewState("/test/dir", state.SortByModifiedTime)
 	model := NewModel(appState, "/test/dir")
@@ -5,8 +5,12 @@ import (
 	"clip-tagger/renamer"
 	"clip-tagger/state"
 	"fmt"
+	"path"
 	"path/filepath"
-	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
 )
 
 // RenameItem represents a single file rename operation for display
@@ -14,22 +18,51 @@ type RenameItem struct {
 	OriginalName string
 	NewName      string
 	IsSkipped    bool
-	ChangeType   string // "new", "updated", "moved", or ""
+	Disposition  state.Disposition // What happens to a skipped file
+	ChangeType   string            // "new", "updated", "moved", or ""
+	Annotation   state.Annotation
 }
 
+// ReviewAction represents an edit made on the review screen
+type ReviewAction int
+
+const (
+	ReviewActionNone           ReviewAction = iota
+	ReviewActionRevisitSkipped              // Classify the skipped files again
+	ReviewActionDisposition                 // Change what happens to a skipped file
+	ReviewActionMoveTake                    // Move a take earlier or later in its group
+	ReviewActionUnskip                      // Return a skipped file to the queue
+	ReviewActionPreview                     // Open the file in the system player
+	ReviewActionNotes                       // Replace a take's notes
+)
+
 // ReviewData contains the data needed to render the review screen
 type ReviewData struct {
 	ClassifiedCount int
 	SkippedCount    int
 	RenameItems     []RenameItem
+	RejectFolder    string // Where rejected clips go, "" for the trash
 	SelectedIndex   int
 	ScrollOffset    int
-	ViewportHeight  int // Number of items to show in viewport
+	ViewportHeight  int    // Number of items to show in viewport
+	Width           int    // Terminal width, 0 if unknown
+	Keys            Keymap // Active key bindings for the hints, nil for the defaults
+	Notice          string // Result of the last edit
+	EditingNotes    bool   // Notes of the selected take are being typed
+	NotesInput      string
 }
 
 // ReviewUpdateResult contains the result of a review update
 type ReviewUpdateResult struct {
 	Screen Screen // -1 for quit, -2 for no screen change, >= 0 for screen transition
+	Undo   bool   // Revert the last classification change
+	Redo   bool   // Reapply the last undone change
+
+	Action      ReviewAction
+	File        string            // Selected file the action or screen change applies to
+	Disposition state.Disposition // ReviewActionDisposition: the new disposition
+	Delta       int               // ReviewActionMoveTake: -1 for earlier, 1 for later
+	Notes       string            // ReviewActionNotes: the new notes
 }
 
 // NewReviewData creates review data from state and file list
@@ -38,53 +71,77 @@ func NewReviewData(appState *state.State, files []string) *ReviewData {
 		ClassifiedCount: len(appState.Classifications),
 		SkippedCount:    len(appState.Skipped),
 		RenameItems:     []RenameItem{},
+		RejectFolder:    appState.RejectFolder(),
 		SelectedIndex:   0,
 		ScrollOffset:    0,
 		ViewportHeight:  10, // Default viewport height
 	}
 
 	// Build rename items for classified files
+	tmpl := appState.Template()
+	numbers := appState.NameNumbers()
 	for _, classification := range appState.Classifications {
-		group := appState.FindGroupByID(classification.GroupID)
-		if group == nil {
+		targetPath, ok := appState.TargetPath(classification, numbers)
+		if !ok {
 			// Skip if group not found (shouldn't happen)
 			continue
 		}
-
 		originalPath := filepath.Join(appState.Directory, classification.File)
-		targetPath := renamer.GenerateTargetPath(
-			appState.Directory,
-			originalPath,
-			group.Order,
-			classification.TakeNumber,
-			group.Name,
-		)
 
-		changeType := detectChangeType(originalPath, targetPath)
+		changeType := detectChangeType(tmpl, originalPath, targetPath)
 
 		data.RenameItems = append(data.RenameItems, RenameItem{
 			OriginalName: classification.File,
-			NewName:      filepath.Base(targetPath),
+			NewName:      renamer.RelativePath(appState.Directory, targetPath),
 			IsSkipped:    false,
 			ChangeType:   changeType,
+			Annotation:   classification.Annotation,
 		})
 	}
 
 	// Add skipped files
 	for _, skipped := range appState.Skipped {
-		data.RenameItems = append(data.RenameItems, RenameItem{
+		item := RenameItem{
 			OriginalName: skipped,
-			NewName:      skipped, // No change for skipped files
 			IsSkipped:    true,
 			ChangeType:   "",
-		})
+		}
+		item.setDisposition(appState.Disposition(skipped), data.RejectFolder)
+		data.RenameItems = append(data.RenameItems, item)
 	}
 
 	return data
 }
 
-// detectChangeType determines what kind of change this rename represents
-func detectChangeType(originalPath, newPath string) string {
+// setDisposition changes what happens to a skipped file and where it ends
+// up. Rejects go to rejectFolder, or the trash when it is "".
+func (item *RenameItem) setDisposition(d state.Disposition, rejectFolder string) {
+	item.Disposition = d
+	item.NewName = item.OriginalName // No change for skipped files
+	if d == state.DispositionReject && rejectFolder != "" {
+		item.NewName = path.Join(rejectFolder, item.OriginalName)
+	}
+}
+
+// skippedLabel renders the tag shown after a skipped file
+func skippedLabel(item RenameItem) string {
+	switch item.Disposition {
+	case state.DispositionReject:
+		destination := item.NewName
+		if destination == item.OriginalName {
+			destination = "trash"
+		}
+		return RenderMuted("->") + " " + destination + " " + RenderWarning("[REJECT]")
+	case state.DispositionDelete:
+		return RenderDanger("[DELETE]")
+	default:
+		return RenderWarning("[SKIPPED]")
+	}
+}
+
+// detectChangeType determines what kind of change this rename represents,
+// reading group and take back out of both names with the session template
+func detectChangeType(tmpl *renamer.Template, originalPath, newPath string) string {
 	originalName := filepath.Base(originalPath)
 	newName := filepath.Base(newPath)
 
@@ -93,41 +150,91 @@ func detectChangeType(originalPath, newPath string) string {
 		return ""
 	}
 
-	// Pattern to match [XX_YY] format
-	pattern := regexp.MustCompile(`^\[(\d+)_(\d+)\]`)
-
-	// Check if original has the pattern
-	originalMatches := pattern.FindStringSubmatch(originalName)
-	newMatches := pattern.FindStringSubmatch(newName)
-
-	// If original doesn't have pattern, it's a new file being classified
-	if len(originalMatches) == 0 {
+	// If original doesn't follow the template, it's a new file being classified
+	original, ok := tmpl.Parse(originalName)
+	if !ok {
 		return "new"
 	}
 
-	// If both have patterns, compare positions
-	if len(originalMatches) == 3 && len(newMatches) == 3 {
-		oldGroup := originalMatches[1]
-		oldTake := originalMatches[2]
-		newGroup := newMatches[1]
-		newTake := newMatches[2]
+	renamed, ok := tmpl.Parse(newName)
+	if !ok {
+		return ""
+	}
 
-		// If group changed, it's moved
-		if oldGroup != newGroup {
+	// If group changed, it's moved. Templates without a group number can
+	// only tell groups apart by name.
+	if tmpl.Uses("seq") || tmpl.Uses("order") {
+		if !slices.Equal(original.Sequence(), renamed.Sequence()) {
 			return "moved"
 		}
+	} else if original.GroupName != renamed.GroupName {
+		return "moved"
+	}
 
-		// If take changed within same group, it's updated
-		if oldTake != newTake {
-			return "updated"
-		}
+	// If take changed within same group, it's updated
+	if original.Take != renamed.Take {
+		return "updated"
 	}
 
 	return ""
 }
 
+// selected returns the selected item, or false if the list is empty
+func (data *ReviewData) selected() (RenameItem, bool) {
+	if data.SelectedIndex < 0 || data.SelectedIndex >= len(data.RenameItems) {
+		return RenameItem{}, false
+	}
+	return data.RenameItems[data.SelectedIndex], true
+}
+
+// selectFile selects a file's item, keeping the selection where it was if
+// the file is no longer listed, and scrolls it into view
+func (data *ReviewData) selectFile(file string) {
+	for i, item := range data.RenameItems {
+		if item.OriginalName == file {
+			data.SelectedIndex = i
+		}
+	}
+	data.SelectedIndex = max(min(data.SelectedIndex, len(data.RenameItems)-1), 0)
+	if data.SelectedIndex < data.ScrollOffset {
+		data.ScrollOffset = data.SelectedIndex
+	}
+	if data.SelectedIndex >= data.ScrollOffset+data.ViewportHeight {
+		data.ScrollOffset = data.SelectedIndex - data.ViewportHeight + 1
+	}
+}
+
 // ReviewView renders the review screen
 func ReviewView(data *ReviewData) string {
+	output := reviewHeader(data)
+
+	// If no items to show
+	if len(data.RenameItems) == 0 {
+		output += RenderWarning("No changes to review.") + "\n\n"
+		output += RenderKeyHint(fmt.Sprintf("Press %s to go back, %s to quit",
+			data.Keys.Hint(KeysReview, "back"), data.Keys.Hint(KeysReview, "quit"))) + "\n"
+		return output
+	}
+
+	// On wide terminals the selected clip's details go next to the list
+	listWidth := data.Width
+	wide := data.Width >= wideWidth
+	if wide {
+		listWidth = data.Width * 3 / 5
+	}
+	list := reviewList(data, listWidth, !wide)
+	if wide {
+		item, _ := data.selected()
+		output += sideBySide(list, listWidth, reviewDetails(item, data.Width-listWidth))
+	} else {
+		output += list
+	}
+
+	return output + reviewFooter(data)
+}
+
+// reviewHeader renders the title and summary above the rename list
+func reviewHeader(data *ReviewData) string {
 	var output string
 
 	// Header
@@ -143,58 +250,97 @@ func ReviewView(data *ReviewData) string {
 		classifiedText,
 		RenderWarning(fmt.Sprintf("%d", data.SkippedCount)))
 
-	// If no items to show
-	if len(data.RenameItems) == 0 {
-		output += RenderWarning("No changes to review.") + "\n\n"
-		output += RenderKeyHint("Press Esc to go back, q to quit") + "\n"
+	return output
+}
+
+// reviewFooter renders the notes input, notice and instructions below the
+// rename list
+func reviewFooter(data *ReviewData) string {
+	var output string
+
+	if data.EditingNotes {
+		item, _ := data.selected()
+		output += "\n" + fmt.Sprintf("%s %s\n", RenderHighlight("Notes for"), RenderSubheader(truncateMiddle(item.OriginalName, nameWidth(data.Width, 12))))
+		output += fmt.Sprintf("%s %s\n\n", RenderCursor(">"), RenderSubheader(data.NotesInput))
+		output += RenderKeyHint("Enter to save, Esc to cancel") + "\n"
 		return output
 	}
 
+	if data.Notice != "" {
+		output += "\n" + RenderMuted(data.Notice) + "\n"
+	}
+
+	// Instructions, named by the keys they are bound to
+	key := data.Keys.hints(KeysReview)
+	output += "\n"
+	output += RenderMuted("Navigation:") + "\n"
+	output += RenderKeyHint(fmt.Sprintf("  %s/%s - Navigate list", key("up"), key("down"))) + "\n"
+	output += RenderKeyHint("  "+key("rename")+" - Proceed to rename files") + "\n"
+	output += RenderKeyHint("  "+key("back")+" - Return to classification at the selected clip") + "\n"
+	output += RenderKeyHint(fmt.Sprintf("  %s / %s - Undo / redo the last change", key("undo"), key("redo"))) + "\n"
+	if data.SkippedCount > 0 {
+		output += RenderKeyHint("  "+key("revisit_skipped")+" - Revisit skipped files") + "\n"
+	}
+	output += RenderKeyHint("  "+key("manage_groups")+" - Manage groups") + "\n"
+	output += RenderKeyHint("  "+key("quit")+" - Quit") + "\n"
+
+	output += RenderMuted("Selected clip:") + "\n"
+	output += RenderKeyHint(fmt.Sprintf("  %s / %s - Move to an existing / new group", key("choose_group"), key("new_group"))) + "\n"
+	output += RenderKeyHint(fmt.Sprintf("  %s / %s - Move take earlier / later", key("take_earlier"), key("take_later"))) + "\n"
+	output += RenderKeyHint(fmt.Sprintf("  %s - Edit notes, %s - Preview", key("notes"), key("preview"))) + "\n"
+	if data.SkippedCount > 0 {
+		output += RenderKeyHint("  "+key("unskip")+" - Unskip (back to the queue)") + "\n"
+		output += RenderKeyHint("  "+key("disposition")+" - Keep name / reject / mark for deletion") + "\n"
+	}
+
+	return output
+}
+
+// reviewList renders the visible rename items. Names are truncated to fit
+// width, and annotations are only shown when withNotes is set.
+func reviewList(data *ReviewData, width int, withNotes bool) string {
+	var output string
+
 	// Calculate visible window
 	startIdx := data.ScrollOffset
-	endIdx := data.ScrollOffset + data.ViewportHeight
-	if endIdx > len(data.RenameItems) {
-		endIdx = len(data.RenameItems)
-	}
+	endIdx := min(data.ScrollOffset+data.ViewportHeight, len(data.RenameItems))
 
 	// Show scroll indicator if needed
 	if data.ScrollOffset > 0 {
 		output += RenderMuted("  ... (more items above)") + "\n"
 	}
 
+	// Two names share a line with the cursor, arrow and change tag
+	names := nameWidth(width, 20) / 2
+
 	// Display rename items in viewport
 	for i := startIdx; i < endIdx; i++ {
 		item := data.RenameItems[i]
 
 		// Show rename or skip
+		var line string
 		if item.IsSkipped {
-			if i == data.SelectedIndex {
-				output += fmt.Sprintf("%s %s %s\n",
-					RenderCursor(">"),
-					RenderMuted(item.OriginalName),
-					RenderWarning("[SKIPPED]"))
-			} else {
-				output += fmt.Sprintf("  %s %s\n",
-					RenderMuted(item.OriginalName),
-					RenderWarning("[SKIPPED]"))
-			}
+			line = fmt.Sprintf("%s %s", RenderMuted(truncateMiddle(item.OriginalName, names)), skippedLabel(item))
 		} else {
-			line := fmt.Sprintf("%s %s %s",
-				RenderMuted(item.OriginalName),
+			line = fmt.Sprintf("%s %s %s",
+				RenderMuted(truncateMiddle(item.OriginalName, names)),
 				RenderMuted("->"),
-				item.NewName)
+				truncateMiddle(item.NewName, names))
 
 			// Add change tag if applicable
 			if item.ChangeType != "" {
 				line += " " + RenderTag(item.ChangeType, item.ChangeType)
 			}
-
-			if i == data.SelectedIndex {
-				output += fmt.Sprintf("%s %s\n", RenderCursor(">"), line)
-			} else {
-				output += fmt.Sprintf("  %s\n", line)
+			if withNotes {
+				line += reviewNotes(item.Annotation, width-2-lipgloss.Width(line))
 			}
 		}
+
+		if i == data.SelectedIndex {
+			output += fmt.Sprintf("%s %s\n", RenderCursor(">"), line)
+		} else {
+			output += fmt.Sprintf("  %s\n", line)
+		}
 	}
 
 	// Show scroll indicator if needed
@@ -202,19 +348,59 @@ func ReviewView(data *ReviewData) string {
 		output += RenderMuted("  ... (more items below)") + "\n"
 	}
 
-	// Instructions
-	output += "\n"
-	output += RenderMuted("Navigation:") + "\n"
-	output += RenderKeyHint("  Up/Down - Navigate list") + "\n"
-	output += RenderKeyHint("  Enter - Proceed to rename files") + "\n"
-	output += RenderKeyHint("  Esc - Return to classification (make more edits)") + "\n"
-	output += RenderKeyHint("  q - Quit") + "\n"
+	return output
+}
+
+// reviewNotes renders an annotation after a rename, with the notes
+// shortened to fit room columns if the width is known
+func reviewNotes(a state.Annotation, room int) string {
+	if a.IsZero() {
+		return ""
+	}
+	if a.Notes != "" && room > 0 {
+		rest := a
+		rest.Notes = ""
+		// Two spaces before the summary and before the quoted notes
+		a.Notes = truncateMiddle(a.Notes, max(room-lipgloss.Width(annotationSummary(rest))-6, 1))
+	}
+	return "  " + annotationSummary(a)
+}
 
+// reviewDetails renders the selected clip's details for the wide layout
+func reviewDetails(item RenameItem, width int) string {
+	names := nameWidth(width, 12)
+	var output string
+	output += RenderSubheader("Clip details") + "\n"
+	output += fmt.Sprintf("%s %s\n", RenderMuted("File:"), truncateMiddle(item.OriginalName, names))
+	if item.IsSkipped {
+		output += fmt.Sprintf("%s %s\n", RenderMuted("Skipped:"), RenderWarning(item.Disposition.Description()))
+		if item.NewName != "" && item.NewName != item.OriginalName {
+			output += fmt.Sprintf("%s %s\n", RenderMuted("Moves to:"), truncateMiddle(item.NewName, names))
+		}
+		return output
+	}
+	output += fmt.Sprintf("%s %s\n", RenderMuted("Renamed:"), truncateMiddle(item.NewName, names))
+	if item.ChangeType != "" {
+		output += fmt.Sprintf("%s %s\n", RenderMuted("Change:"), RenderTag(item.ChangeType, item.ChangeType))
+	}
+	rating := item.Annotation
+	rating.Notes = ""
+	if summary := annotationSummary(rating); summary != "" {
+		output += fmt.Sprintf("%s %s\n", RenderMuted("Take:"), summary)
+	}
+	if item.Annotation.Notes != "" {
+		output += fmt.Sprintf("%s %s\n", RenderMuted("Notes:"), item.Annotation.Notes)
+	}
 	return output
 }
 
 // ReviewUpdate handles input for the review screen
 func ReviewUpdate(data *ReviewData, msg string) ReviewUpdateResult {
+	if data.EditingNotes {
+		return handleReviewNotesInput(data, msg)
+	}
+
+	item, ok := data.selected()
 	switch msg {
 	case "up":
 		// Move selection up
@@ -245,8 +431,77 @@ func ReviewUpdate(data *ReviewData, msg string) ReviewUpdateResult {
 		return ReviewUpdateResult{Screen: ScreenComplete}
 
 	case "esc":
-		// Go back to classification screen
-		return ReviewUpdateResult{Screen: ScreenClassification}
+		// Go back to classification screen, at the selected file
+		return ReviewUpdateResult{Screen: ScreenClassification, File: item.OriginalName}
+
+	case "g":
+		return ReviewUpdateResult{Screen: ScreenGroupManagement}
+
+	case "s":
+		if data.SkippedCount == 0 {
+			return ReviewUpdateResult{Screen: -2}
+		}
+		return ReviewUpdateResult{Screen: -2, Action: ReviewActionRevisitSkipped}
+
+	case "2", "3":
+		// Move the selected file to another group, or classify a skipped one
+		if !ok {
+			return ReviewUpdateResult{Screen: -2}
+		}
+		screen := ScreenGroupSelection
+		if msg == "3" {
+			screen = ScreenGroupInsertion
+		}
+		return ReviewUpdateResult{Screen: screen, File: item.OriginalName}
+
+	case "[", "]":
+		if !ok || item.IsSkipped {
+			return ReviewUpdateResult{Screen: -2}
+		}
+		delta := 1
+		if msg == "[" {
+			delta = -1
+		}
+		return ReviewUpdateResult{Screen: -2, Action: ReviewActionMoveTake, File: item.OriginalName, Delta: delta}
+
+	case "n":
+		if ok && !item.IsSkipped {
+			data.EditingNotes = true
+			data.NotesInput = item.Annotation.Notes
+		}
+		return ReviewUpdateResult{Screen: -2}
+
+	case "p":
+		if !ok {
+			return ReviewUpdateResult{Screen: -2}
+		}
+		return ReviewUpdateResult{Screen: -2, Action: ReviewActionPreview, File: item.OriginalName}
+
+	case "x":
+		if !ok || !item.IsSkipped {
+			return ReviewUpdateResult{Screen: -2}
+		}
+		return ReviewUpdateResult{Screen: -2, Action: ReviewActionUnskip, File: item.OriginalName}
+
+	case "d":
+		// Cycle what happens to the selected skipped file
+		if !ok || !item.IsSkipped {
+			return ReviewUpdateResult{Screen: -2}
+		}
+		selected := &data.RenameItems[data.SelectedIndex]
+		selected.setDisposition(item.Disposition.Next(), data.RejectFolder)
+		return ReviewUpdateResult{
+			Screen:      -2,
+			Action:      ReviewActionDisposition,
+			File:        item.OriginalName,
+			Disposition: selected.Disposition,
+		}
+
+	case "u":
+		return ReviewUpdateResult{Screen: -2, Undo: true}
+
+	case "ctrl+r":
+		return ReviewUpdateResult{Screen: -2, Redo: true}
 
 	case "q", "ctrl+c":
 		// Quit
@@ -257,3 +512,32 @@ func ReviewUpdate(data *ReviewData, msg string) ReviewUpdateResult {
 		return ReviewUpdateResult{Screen: -2}
 	}
 }
+
+// handleReviewNotesInput handles typing notes for the selected take
+func handleReviewNotesInput(data *ReviewData, msg string) ReviewUpdateResult {
+	switch msg {
+	case "ctrl+c":
+		return ReviewUpdateResult{Screen: -1}
+	case "enter":
+		data.EditingNotes = false
+		item, _ := data.selected()
+		return ReviewUpdateResult{
+			Screen: -2,
+			Action: ReviewActionNotes,
+			File:   item.OriginalName,
+			Notes:  strings.TrimSpace(data.NotesInput),
+		}
+	case "esc":
+		data.EditingNotes = false
+	case "backspace":
+		if len(data.NotesInput) > 0 {
+			runes := []rune(data.NotesInput)
+			data.NotesInput = string(runes[:len(runes)-1])
+		}
+	default:
+		if len(msg) == 1 || msg == " " {
+			data.NotesInput += msg
+		}
+	}
+	return ReviewUpdateResult{Screen: -2}
+}
@@ -0,0 +1,87 @@
+// ui/review_logic.go
+package ui
+
+import (
+	"fmt"
+)
+
+// handleReviewEdit applies a change to the selected file on the review
+// screen as an undoable change and rebuilds the rename list
+func (m Model) handleReviewEdit(result ReviewUpdateResult) Model {
+	before := m.checkpoint()
+	var label, notice string
+
+	switch result.Action {
+	case ReviewActionMoveTake:
+		if !m.state.MoveTake(result.File, result.Delta) {
+			return m
+		}
+		label = "move take " + result.File
+		c, _ := m.state.GetClassification(result.File)
+		notice = fmt.Sprintf("%s is now take %d", result.File, c.TakeNumber)
+
+	case ReviewActionUnskip:
+		if !m.state.Unskip(result.File) {
+			return m
+		}
+		label = "unskip " + result.File
+		notice = result.File + " is back in the queue"
+
+	case ReviewActionNotes:
+		c, ok := m.state.GetClassification(result.File)
+		if !ok || c.Notes == result.Notes {
+			return m
+		}
+		annotation := c.Annotation
+		annotation.Notes = result.Notes
+		if err := m.state.Annotate(result.File, annotation); err != nil {
+			m.err = fmt.Sprintf("Failed to save notes: %v", err)
+			return m
+		}
+		label = "annotate " + result.File
+		notice = "Saved notes for " + result.File
+
+	default:
+		return m
+	}
+
+	m.state.Record(label, before)
+	m = m.autoSaveState()
+	m = m.refreshReview(result.File)
+	m.reviewData.Notice = notice
+	return m
+}
+
+// handleReviewAssigned moves the file chosen on the review screen to a
+// group and returns to review with the rename list rebuilt
+func (m Model) handleReviewAssigned(groupID string) Model {
+	file := m.reviewFile
+	m.reviewFile = ""
+	m.state.AssignClassification(file, groupID, m.files)
+	// Track for "Same as Last"
+	m.lastClassifiedGroupID = groupID
+
+	m.currentScreen = ScreenReview
+	m = m.refreshReview(file)
+	c, _ := m.state.GetClassification(file)
+	m.reviewData.Notice = fmt.Sprintf("%s is now %s take %d", file, m.state.GroupFullName(groupID), c.TakeNumber)
+	return m
+}
+
+// refreshReview rebuilds the review screen after the state changed,
+// keeping the selection on file (or with "" the selected file) and the
+// scroll position where possible
+func (m Model) refreshReview(file string) Model {
+	data := NewReviewData(m.state, m.files)
+	if m.reviewData != nil {
+		if item, ok := m.reviewData.selected(); ok && file == "" {
+			file = item.OriginalName
+		}
+		data.SelectedIndex = m.reviewData.SelectedIndex
+		data.ScrollOffset = m.reviewData.ScrollOffset
+		data.ViewportHeight = m.reviewData.ViewportHeight
+	}
+	data.selectFile(file)
+	m.reviewData = data
+	return m
+}
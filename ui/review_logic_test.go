@@ -0,0 +1,117 @@
+// ui/review_logic_test.go
+package ui
+
+import (
+	"clip-tagger/state"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// reviewModel returns a model on the review screen with a.mp4 and b.mp4 as
+// intro takes 1 and 2 and c.mp4 skipped
+func reviewModel(t *testing.T) (Model, state.Group, state.Group) {
+	t.Helper()
+	appState := state.NewState(t.TempDir(), state.SortByName)
+	intro := state.NewGroup("intro", 1)
+	outro := state.NewGroup("outro", 2)
+	appState.Groups = []state.Group{intro, outro}
+	appState.AddOrUpdateClassification("a.mp4", intro.ID)
+	appState.AddOrUpdateClassification("b.mp4", intro.ID)
+	appState.Skip("c.mp4")
+
+	model := NewModel(appState, appState.Directory)
+	model.files = []string{"a.mp4", "b.mp4", "c.mp4"}
+	model.currentFileIndex = len(model.files)
+	model.currentScreen = ScreenReview
+	model.reviewData = NewReviewData(appState, model.files)
+	return model, intro, outro
+}
+
+func TestReviewLogic_EditSelected(t *testing.T) {
+	model, intro, _ := reviewModel(t)
+	send := func(msg tea.Msg) {
+		t.Helper()
+		updated, cmd := model.Update(msg)
+		model = updated.(Model)
+		if cmd != nil {
+			if next := cmd(); next != nil {
+				updated, _ = model.Update(next)
+				model = updated.(Model)
+			}
+		}
+	}
+	press := func(keys string) {
+		for _, key := range keys {
+			send(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{key}})
+		}
+	}
+	take := func(file string) int {
+		c, _ := model.state.GetClassification(file)
+		return c.TakeNumber
+	}
+
+	// Swap the takes; the list follows the change
+	press("]")
+	if take("a.mp4") != 2 || take("b.mp4") != 1 {
+		t.Errorf("expected a.mp4 to move after b.mp4, got takes %d and %d", take("a.mp4"), take("b.mp4"))
+	}
+	if item := model.reviewData.RenameItems[0]; item.NewName != "[01_02] intro.mp4" {
+		t.Errorf("expected the rename list to be rebuilt, got %+v", item)
+	}
+
+	// Undo stays on review
+	press("u")
+	if take("a.mp4") != 1 || model.currentScreen != ScreenReview {
+		t.Errorf("expected the swap undone on review, got take %d on %v", take("a.mp4"), model.currentScreen)
+	}
+
+	// Notes are typed in place
+	press("n")
+	press("soft")
+	send(tea.KeyMsg{Type: tea.KeyEnter})
+	if c, _ := model.state.GetClassification("a.mp4"); c.Notes != "soft" {
+		t.Errorf("expected notes to be saved, got %q", c.Notes)
+	}
+
+	// Cancelling a move returns to review
+	press("2")
+	if model.currentScreen != ScreenGroupSelection {
+		t.Fatalf("expected group selection, got %v", model.currentScreen)
+	}
+	send(tea.KeyMsg{Type: tea.KeyEsc})
+	if model.currentScreen != ScreenReview || model.reviewFile != "" {
+		t.Fatalf("expected to return to review, got %v", model.currentScreen)
+	}
+
+	// Moving a.mp4 keeps it selected and renumbers intro
+	press("2")
+	press("outro")
+	send(tea.KeyMsg{Type: tea.KeyEnter})
+	if c, _ := model.state.GetClassification("a.mp4"); c.GroupID == intro.ID || c.TakeNumber != 1 {
+		t.Errorf("expected a.mp4 to move to outro, got %+v", c)
+	}
+	if take("b.mp4") != 1 {
+		t.Errorf("expected b.mp4 to become intro take 1, got %d", take("b.mp4"))
+	}
+	if item, _ := model.reviewData.selected(); model.currentScreen != ScreenReview || item.OriginalName != "a.mp4" {
+		t.Errorf("expected review with a.mp4 selected, got %v with %+v", model.currentScreen, item)
+	}
+
+	// Unskip the skipped file
+	send(tea.KeyMsg{Type: tea.KeyDown})
+	if item, _ := model.reviewData.selected(); item.OriginalName != "c.mp4" {
+		t.Fatalf("expected c.mp4 after a.mp4, got %s", item.OriginalName)
+	}
+	press("x")
+	if model.state.IsSkipped("c.mp4") {
+		t.Error("expected c.mp4 to be unskipped")
+	}
+
+	// Esc returns to classification at the selected file
+	send(tea.KeyMsg{Type: tea.KeyEsc})
+	item := model.files[model.currentFileIndex]
+	if model.currentScreen != ScreenClassification || item != model.classificationData.CurrentFile {
+		t.Errorf("expected classification at the selected file, got %v at %s", model.currentScreen, item)
+	}
+}
@@ -2,6 +2,7 @@
 package ui
 
 import (
+	"clip-tagger/renamer"
 	"clip-tagger/state"
 	"path/filepath"
 	"strings"
@@ -285,6 +286,9 @@ func TestReviewUpdate_EscKey(t *testing.T) {
 	if result.Screen != ScreenClassification {
 		t.Errorf("expected Esc to transition to ScreenClassification, got screen %d", result.Screen)
 	}
+	if result.File != "file1.mp4" {
+		t.Errorf("expected Esc to return to the selected file, got %q", result.File)
+	}
 }
 
 func TestReviewUpdate_QuitKeys(t *testing.T) {
@@ -308,6 +312,7 @@ func TestReviewUpdate_QuitKeys(t *testing.T) {
 }
 
 func TestDetectChangeType(t *testing.T) {
+	tmpl := renamer.MustParseTemplate(renamer.DefaultTemplate)
 	tests := []struct {
 		name         string
 		originalPath string
@@ -348,7 +353,7 @@ func TestDetectChangeType(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := detectChangeType(tt.originalPath, tt.newPath)
+			result := detectChangeType(tmpl, tt.originalPath, tt.newPath)
 			if result != tt.expected {
 				t.Errorf("detectChangeType(%s, %s) = %s, want %s",
 					filepath.Base(tt.originalPath), filepath.Base(tt.newPath),
@@ -358,6 +363,52 @@ func TestDetectChangeType(t *testing.T) {
 	}
 }
 
+func TestDetectChangeType_CustomTemplate(t *testing.T) {
+	tmpl := renamer.MustParseTemplate("{date:20060102}_{seq:03}-{take:02}_{group|slug}")
+
+	tests := []struct {
+		originalPath string
+		newPath      string
+		expected     string
+	}{
+		{"/dir/C0001.MP4", "/dir/20260112_001-01_intro.MP4", "new"},
+		{"/dir/20260112_002-01_intro.MP4", "/dir/20260112_001-01_intro.MP4", "moved"},
+		{"/dir/20260112_001-01_intro.MP4", "/dir/20260112_001-02_intro.MP4", "updated"},
+		{"/dir/20260112_01-01_intro.MP4", "/dir/20260112_001-01_intro.MP4", ""},
+	}
+
+	for _, tt := range tests {
+		result := detectChangeType(tmpl, tt.originalPath, tt.newPath)
+		if result != tt.expected {
+			t.Errorf("detectChangeType(%s, %s) = %s, want %s",
+				filepath.Base(tt.originalPath), filepath.Base(tt.newPath), result, tt.expected)
+		}
+	}
+}
+
+func TestDetectChangeType_NestedGroups(t *testing.T) {
+	tmpl := renamer.MustParseTemplate(renamer.DefaultTemplate)
+
+	tests := []struct {
+		originalPath string
+		newPath      string
+		expected     string
+	}{
+		{"/dir/[01_02] scene.mp4", "/dir/[01_01_01] wide.mp4", "moved"},
+		{"/dir/[01_01_01] wide.mp4", "/dir/[01_02_01] wide.mp4", "moved"},
+		{"/dir/[01_01_01] wide.mp4", "/dir/[01_01_02] wide.mp4", "updated"},
+		{"/dir/[1_1_1] wide.mp4", "/dir/[01_01_01] wide.mp4", ""},
+	}
+
+	for _, tt := range tests {
+		result := detectChangeType(tmpl, tt.originalPath, tt.newPath)
+		if result != tt.expected {
+			t.Errorf("detectChangeType(%s, %s) = %s, want %s",
+				filepath.Base(tt.originalPath), filepath.Base(tt.newPath), result, tt.expected)
+		}
+	}
+}
+
 func TestReviewData_WithEmptyState(t *testing.T) {
 	appState := state.NewState("/test/dir", state.SortByModifiedTime)
 	files := []string{"file1.mp4", "file2.mp4"}
@@ -415,3 +466,114 @@ func TestReviewData_ScrollWindow(t *testing.T) {
 		t.Error("ScrollOffset should not be negative")
 	}
 }
+
+func TestReviewUpdate_UndoRedo(t *testing.T) {
+	data := &ReviewData{ViewportHeight: 10}
+
+	if result := ReviewUpdate(data, "u"); !result.Undo || result.Screen != -2 {
+		t.Errorf("expected undo without screen change, got %+v", result)
+	}
+	if result := ReviewUpdate(data, "ctrl+r"); !result.Redo || result.Screen != -2 {
+		t.Errorf("expected redo without screen change, got %+v", result)
+	}
+}
+
+func TestReviewView_ShowsAnnotation(t *testing.T) {
+	appState := state.NewState("/test/dir", state.SortByModifiedTime)
+	group := state.NewGroup("Scene 1", 1)
+	appState.Groups = []state.Group{group}
+	appState.AddOrUpdateClassification("clip.mp4", group.ID)
+	appState.Annotate("clip.mp4", state.Annotation{Rating: 3, Circled: true, Notes: "focus buzz at end"})
+
+	data := NewReviewData(appState, []string{"clip.mp4"})
+	view := ReviewView(data)
+	for _, expected := range []string{"★★★☆☆", "circled", "focus buzz at end"} {
+		if !strings.Contains(view, expected) {
+			t.Errorf("expected view to contain %q", expected)
+		}
+	}
+}
+
+func TestReviewUpdate_Disposition(t *testing.T) {
+	appState := state.NewState("/test/dir", state.SortByName)
+	group := state.NewGroup("intro", 1)
+	appState.Groups = []state.Group{group}
+	appState.AddOrUpdateClassification("a.mp4", group.ID)
+	appState.Skip("day1/b.mp4")
+
+	data := NewReviewData(appState, []string{"a.mp4", "day1/b.mp4"})
+
+	// Only skipped files have a disposition
+	if result := ReviewUpdate(data, "d"); result.Action != ReviewActionNone {
+		t.Error("expected no disposition for a classified file")
+	}
+
+	ReviewUpdate(data, "down")
+	result := ReviewUpdate(data, "d")
+	if result.Action != ReviewActionDisposition || result.File != "day1/b.mp4" || result.Disposition != state.DispositionReject {
+		t.Fatalf("expected the skipped file to be rejected, got %+v", result)
+	}
+	if item := data.RenameItems[1]; item.NewName != "_rejected/day1/b.mp4" {
+		t.Errorf("expected the rejects path, got %s", item.NewName)
+	}
+	if view := ReviewView(data); !strings.Contains(view, "[REJECT]") {
+		t.Errorf("expected the view to show the reject, got:\n%s", view)
+	}
+
+	ReviewUpdate(data, "d")
+	if item := data.RenameItems[1]; item.Disposition != state.DispositionDelete || item.NewName != "day1/b.mp4" {
+		t.Errorf("expected the file to be marked for deletion in place, got %+v", item)
+	}
+
+	if result := ReviewUpdate(data, "s"); result.Action != ReviewActionRevisitSkipped {
+		t.Error("expected 's' to revisit skipped files")
+	}
+}
+
+func TestReviewUpdate_EditKeys(t *testing.T) {
+	appState := state.NewState("/test/dir", state.SortByName)
+	group := state.NewGroup("intro", 1)
+	appState.Groups = []state.Group{group}
+	appState.AddOrUpdateClassification("a.mp4", group.ID)
+	appState.Annotate("a.mp4", state.Annotation{Notes: "soft"})
+	appState.Skip("b.mp4")
+
+	data := NewReviewData(appState, []string{"a.mp4", "b.mp4"})
+
+	if result := ReviewUpdate(data, "3"); result.Screen != ScreenGroupInsertion || result.File != "a.mp4" {
+		t.Errorf("expected '3' to create a group for a.mp4, got %+v", result)
+	}
+	if result := ReviewUpdate(data, "["); result.Action != ReviewActionMoveTake || result.Delta != -1 {
+		t.Errorf("expected '[' to move the take earlier, got %+v", result)
+	}
+	if result := ReviewUpdate(data, "x"); result.Action != ReviewActionNone {
+		t.Errorf("expected no unskip for a classified file, got %+v", result)
+	}
+	if result := ReviewUpdate(data, "p"); result.Action != ReviewActionPreview || result.File != "a.mp4" {
+		t.Errorf("expected 'p' to preview a.mp4, got %+v", result)
+	}
+
+	// Notes start from the current ones; keys go to the input
+	ReviewUpdate(data, "n")
+	if !data.EditingNotes || data.NotesInput != "soft" {
+		t.Fatalf("expected to edit the notes, got %+v", data)
+	}
+	for _, key := range []string{" ", "q", "backspace", "x"} {
+		if result := ReviewUpdate(data, key); result.Screen != -2 || result.Action != ReviewActionNone {
+			t.Fatalf("expected %q to be typed, got %+v", key, result)
+		}
+	}
+	result := ReviewUpdate(data, "enter")
+	if result.Action != ReviewActionNotes || result.Notes != "soft x" || data.EditingNotes {
+		t.Errorf("expected the notes to be saved, got %+v", result)
+	}
+
+	// Skipped files can be unskipped but have no take
+	ReviewUpdate(data, "down")
+	if result := ReviewUpdate(data, "]"); result.Action != ReviewActionNone {
+		t.Errorf("expected no take move for a skipped file, got %+v", result)
+	}
+	if result := ReviewUpdate(data, "x"); result.Action != ReviewActionUnskip || result.File != "b.mp4" {
+		t.Errorf("expected 'x' to unskip b.mp4, got %+v", result)
+	}
+}
@@ -2,6 +2,7 @@
 package ui
 
 import (
+	"clip-tagger/renamer"
 	"clip-tagger/state"
 	"fmt"
 )
@@ -15,6 +16,12 @@ type StartupData struct {
 	NewFilesCount     int
 	MissingFilesCount int
 	SortBy            state.SortBy
+	Recursive         bool
+	Layout            renamer.Layout
+	FallbackCount     int             // Files placed by the fallback order (no birth time, embedded time or timecode)
+	LockHolder        *state.LockInfo // Another session holds the directory lock
+	StaleLock         *state.LockInfo // An abandoned lock was taken over
+	Keys              Keymap          // Active key bindings for the hints, nil for the defaults
 }
 
 // NewStartupData creates startup data from state and scanned files
@@ -22,6 +29,8 @@ func NewStartupData(appState *state.State, scannedFiles []string, mergeResult *s
 	data := &StartupData{
 		TotalFiles: len(scannedFiles),
 		SortBy:     appState.SortBy,
+		Recursive:  appState.Recursive,
+		Layout:     appState.Layout,
 	}
 
 	// Determine if this is a resume session
@@ -68,6 +77,22 @@ func StartupView(data *StartupData) string {
 	// Header
 	output += RenderHeader("=== clip-tagger ===") + "\n\n"
 
+	// Another session owns the directory; nothing else can be done here
+	if data.LockHolder != nil {
+		output += RenderDanger("This directory is open in another clip-tagger session") + "\n"
+		if data.LockHolder.PID != 0 {
+			output += fmt.Sprintf("  %s\n", RenderMuted(data.LockHolder.String()))
+		}
+		output += "\nClose that session, or run with --force-unlock if it is no longer running.\n\n"
+		output += RenderKeyHint("Press "+data.Keys.Hint(KeysStartup, "quit")+" or Ctrl+C to quit") + "\n"
+		return output
+	}
+
+	if data.StaleLock != nil {
+		output += RenderWarning(fmt.Sprintf("Took over a lock left by a session that is no longer running (%s)",
+			data.StaleLock)) + "\n\n"
+	}
+
 	// Session status
 	if data.IsResume {
 		output += fmt.Sprintf("Found existing session (%s classified, %s remaining)\n",
@@ -97,15 +122,43 @@ func StartupView(data *StartupData) string {
 
 	// Sorting information
 	output += fmt.Sprintf("%s %s\n", RenderMuted("Sorted by:"), data.SortBy)
+	if data.FallbackCount > 0 {
+		switch data.SortBy {
+		case state.SortByCreatedTime:
+			if data.FallbackCount == data.TotalFiles {
+				output += fmt.Sprintf("  %s\n", RenderWarning(
+					"This filesystem doesn't record creation times; created sort is the same as modified"))
+			} else {
+				output += fmt.Sprintf("  %s\n", RenderWarning(fmt.Sprintf(
+					"%d file(s) have no creation time and are placed by modified time", data.FallbackCount)))
+			}
+		case state.SortByRecorded:
+			output += fmt.Sprintf("  %s\n", RenderWarning(fmt.Sprintf(
+				"%d file(s) have no embedded recording time and are placed by modified time", data.FallbackCount)))
+		case state.SortByTimecode:
+			output += fmt.Sprintf("  %s\n", RenderWarning(fmt.Sprintf(
+				"%d file(s) have no embedded timecode and are listed last, by recording time", data.FallbackCount)))
+		}
+	}
+
+	// Recursive scan information
+	if data.Recursive {
+		layout := data.Layout
+		if layout == "" {
+			layout = renamer.LayoutFlatten
+		}
+		output += fmt.Sprintf("%s recursive (%s)\n", RenderMuted("Scan:"), layout)
+	}
 
 	// Instructions
 	output += "\n"
+	key := data.Keys.hints(KeysStartup)
 	if data.IsResume {
-		output += RenderKeyHint("Press Enter to continue classification") + "\n"
+		output += RenderKeyHint("Press "+key("continue")+" to continue classification") + "\n"
 	} else {
-		output += RenderKeyHint("Press Enter to start classification") + "\n"
+		output += RenderKeyHint("Press "+key("continue")+" to start classification") + "\n"
 	}
-	output += RenderKeyHint("Press 'q' or Ctrl+C to quit") + "\n"
+	output += RenderKeyHint("Press "+key("quit")+" or Ctrl+C to quit") + "\n"
 
 	return output
 }
@@ -115,6 +168,9 @@ func StartupView(data *StartupData) string {
 func StartupUpdate(data *StartupData, msg string) Screen {
 	switch msg {
 	case "enter":
+		if data.LockHolder != nil {
+			return -2 // Locked by another session
+		}
 		return ScreenClassification
 	case "q", "ctrl+c":
 		return -1 // Signal to quit
@@ -261,3 +261,76 @@ func hasSubstring(s, substr string) bool {
 	}
 	return false
 }
+
+func TestStartupView_MetadataSortFallback(t *testing.T) {
+	appState := state.NewState("/test/dir", state.SortByRecorded)
+	data := NewStartupData(appState, []string{"file1.mp4", "file2.mov"}, nil)
+
+	if contains(StartupView(data), "no embedded recording time") {
+		t.Error("expected no fallback warning when every file has metadata")
+	}
+
+	data.FallbackCount = 1
+	if !contains(StartupView(data), "1 file(s) have no embedded recording time") {
+		t.Error("expected fallback warning for recorded sort")
+	}
+
+	data.SortBy = state.SortByTimecode
+	if !contains(StartupView(data), "1 file(s) have no embedded timecode") {
+		t.Error("expected fallback warning for timecode sort")
+	}
+
+	data.SortBy = state.SortByName
+	if contains(StartupView(data), "have no embedded") {
+		t.Error("expected no fallback warning for name sort")
+	}
+}
+
+func TestStartupView_CreatedSortFallback(t *testing.T) {
+	appState := state.NewState("/test/dir", state.SortByCreatedTime)
+	data := NewStartupData(appState, []string{"file1.mp4", "file2.mov"}, nil)
+
+	data.FallbackCount = 2
+	if !contains(StartupView(data), "created sort is the same as modified") {
+		t.Error("expected warning when no file has a creation time")
+	}
+
+	data.FallbackCount = 1
+	if !contains(StartupView(data), "1 file(s) have no creation time") {
+		t.Error("expected per-file fallback warning")
+	}
+}
+
+func TestStartupView_Locked(t *testing.T) {
+	appState := state.NewState("/test/dir", state.SortByModifiedTime)
+	data := NewStartupData(appState, nil, nil)
+	data.LockHolder = &state.LockInfo{PID: 4242, Hostname: "edit-bay"}
+
+	view := StartupView(data)
+	if !contains(view, "open in another clip-tagger session") || !contains(view, "PID 4242 on edit-bay") {
+		t.Errorf("expected lock holder in view, got %q", view)
+	}
+	if !contains(view, "--force-unlock") {
+		t.Error("expected view to mention --force-unlock")
+	}
+	if contains(view, "Press Enter") {
+		t.Error("expected no Enter instruction while locked")
+	}
+
+	if screen := StartupUpdate(data, "enter"); screen != -2 {
+		t.Errorf("expected enter to be ignored while locked, got %d", screen)
+	}
+	if screen := StartupUpdate(data, "q"); screen != -1 {
+		t.Errorf("expected q to quit, got %d", screen)
+	}
+}
+
+func TestStartupView_StaleLock(t *testing.T) {
+	appState := state.NewState("/test/dir", state.SortByModifiedTime)
+	data := NewStartupData(appState, []string{"file1.mp4"}, nil)
+	data.StaleLock = &state.LockInfo{PID: 99, Hostname: "laptop"}
+
+	if !contains(StartupView(data), "Took over a lock") {
+		t.Error("expected stale lock note")
+	}
+}
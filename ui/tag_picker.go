@@ -0,0 +1,289 @@
+// ui/tag_picker.go
+package ui
+
+import (
+	"clip-tagger/state"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// TagPickerMode says what choosing a tag does
+type TagPickerMode int
+
+const (
+	TagPickerAssign TagPickerMode = iota // Toggle tags on the current file
+	TagPickerFilter                      // Limit the classification queue to one tag
+)
+
+// TagRow is one line of the tag picker
+type TagRow struct {
+	Tag   string
+	New   bool // Create Tag from the filter text
+	Clear bool // Stop filtering the queue
+}
+
+// TagPickerData contains the data needed to render the tag picker screen
+type TagPickerData struct {
+	Mode           TagPickerMode
+	CurrentFile    string
+	AllTags        []string
+	Tags           []string // Tags of the current file, in assign mode
+	Filter         string   // Active queue filter, in filter mode
+	FilterText     string
+	Rows           []TagRow
+	SelectedIndex  int
+	ScrollOffset   int
+	ViewportHeight int
+	Width          int    // Terminal width, 0 if unknown
+	Keys           Keymap // Active key bindings for the hints, nil for the defaults
+}
+
+// TagPickerUpdateResult contains the result of a tag picker update
+type TagPickerUpdateResult struct {
+	Screen    Screen   // -1 for quit, -2 for no screen change, >= 0 for screen transition
+	Tags      []string // Assign mode: the file's tags when done
+	TagsDone  bool
+	Filter    string // Filter mode: the chosen tag, "" to stop filtering
+	FilterSet bool
+}
+
+// NewTagPickerData creates tag picker data for the current file. tags are
+// the file's tags and filter the active queue filter.
+func NewTagPickerData(appState *state.State, mode TagPickerMode, currentFile string, tags []string, filter string) *TagPickerData {
+	data := &TagPickerData{
+		Mode:           mode,
+		CurrentFile:    currentFile,
+		AllTags:        appState.AllTags(),
+		Tags:           slices.Clone(tags),
+		Filter:         filter,
+		ViewportHeight: 10,
+	}
+	// Tags picked for a file that isn't classified yet aren't in the state
+	for _, tag := range tags {
+		if !slices.ContainsFunc(data.AllTags, func(t string) bool { return strings.EqualFold(t, tag) }) {
+			data.AllTags = append(data.AllTags, tag)
+		}
+	}
+	data.refreshRows()
+	return data
+}
+
+// filterTags filters tags with the same matching as filterGroups
+func filterTags(tags []string, filterText string) []string {
+	if filterText == "" {
+		return tags
+	}
+
+	filtered := make([]string, 0)
+	for _, tag := range tags {
+		if matchesFilter(tag, filterText) {
+			filtered = append(filtered, tag)
+		}
+	}
+	return filtered
+}
+
+// refreshRows rebuilds the list after the filter text changes
+func (data *TagPickerData) refreshRows() {
+	data.Rows = nil
+	if data.Mode == TagPickerFilter && data.Filter != "" && data.FilterText == "" {
+		data.Rows = append(data.Rows, TagRow{Clear: true})
+	}
+	for _, tag := range filterTags(data.AllTags, data.FilterText) {
+		data.Rows = append(data.Rows, TagRow{Tag: tag})
+	}
+
+	// Offer typed text as a new tag unless it already exists
+	text := strings.TrimSpace(data.FilterText)
+	if data.Mode == TagPickerAssign && text != "" &&
+		!slices.ContainsFunc(data.AllTags, func(t string) bool { return strings.EqualFold(t, text) }) {
+		data.Rows = append(data.Rows, TagRow{Tag: text, New: true})
+	}
+
+	data.SelectedIndex = 0
+	data.ScrollOffset = 0
+}
+
+// hasTag reports whether the current file has a tag, ignoring case
+func (data *TagPickerData) hasTag(tag string) bool {
+	return slices.ContainsFunc(data.Tags, func(t string) bool { return strings.EqualFold(t, tag) })
+}
+
+// toggle adds or removes the tag of a row
+func (data *TagPickerData) toggle(row TagRow) {
+	if row.New {
+		data.AllTags = append(data.AllTags, row.Tag)
+		data.Tags = append(data.Tags, row.Tag)
+		data.FilterText = ""
+		data.refreshRows()
+		return
+	}
+	if data.hasTag(row.Tag) {
+		data.Tags = slices.DeleteFunc(data.Tags, func(t string) bool { return strings.EqualFold(t, row.Tag) })
+	} else {
+		data.Tags = append(data.Tags, row.Tag)
+	}
+}
+
+// TagPickerView renders the tag picker screen
+func TagPickerView(data *TagPickerData) string {
+	output := tagPickerHeader(data)
+
+	if len(data.Rows) == 0 {
+		if len(data.AllTags) == 0 {
+			output += RenderWarning("No tags yet.") + "\n"
+		} else {
+			output += RenderWarning(fmt.Sprintf("No tags match '%s'.", data.FilterText)) + "\n"
+		}
+	} else {
+		startIdx := data.ScrollOffset
+		endIdx := min(data.ScrollOffset+data.ViewportHeight, len(data.Rows))
+
+		if data.ScrollOffset > 0 {
+			output += RenderMuted("  ... (more items above)") + "\n"
+		}
+
+		for i := startIdx; i < endIdx; i++ {
+			row := data.Rows[i]
+			var label string
+			switch {
+			case row.Clear:
+				label = "All clips (no filter)"
+			case row.New:
+				label = fmt.Sprintf("New tag %q", row.Tag)
+			case data.Mode == TagPickerAssign && data.hasTag(row.Tag):
+				label = "[x] " + row.Tag
+			case data.Mode == TagPickerAssign:
+				label = "[ ] " + row.Tag
+			default:
+				label = row.Tag
+			}
+
+			if i == data.SelectedIndex {
+				output += fmt.Sprintf("%s %s\n", RenderCursor(">"), RenderHighlight(label))
+			} else {
+				output += fmt.Sprintf("  %s\n", label)
+			}
+		}
+
+		if endIdx < len(data.Rows) {
+			output += RenderMuted("  ... (more items below)") + "\n"
+		}
+	}
+
+	return output + tagPickerFooter(data)
+}
+
+// tagPickerHeader renders the title, file or filter and the filter input
+// above the tag list
+func tagPickerHeader(data *TagPickerData) string {
+	var output string
+
+	if data.Mode == TagPickerFilter {
+		output += RenderHeader("=== Filter Queue by Tag ===") + "\n\n"
+		if data.Filter != "" {
+			output += fmt.Sprintf("%s %s\n\n", RenderMuted("Filtering:"), RenderSubheader(data.Filter))
+		}
+	} else {
+		output += RenderHeader("=== Tags ===") + "\n\n"
+		output += fmt.Sprintf("%s %s\n\n", RenderMuted("Tagging:"), RenderSubheader(truncateMiddle(data.CurrentFile, nameWidth(data.Width, 9))))
+	}
+
+	// Filter input
+	output += fmt.Sprintf("%s %s\n\n", RenderMuted("Filter:"), RenderHighlight(data.FilterText))
+
+	if len(data.Rows) > 0 {
+		output += RenderHighlight("Tags:") + "\n"
+	}
+	return output
+}
+
+// tagPickerFooter renders the instructions below the tag list
+func tagPickerFooter(data *TagPickerData) string {
+	output := "\n"
+
+	// Instructions
+	key := data.Keys.hints(KeysTagPicker)
+	output += RenderMuted("Instructions:") + "\n"
+	if data.Mode == TagPickerFilter {
+		output += RenderKeyHint("  Type to filter tags (case-insensitive)") + "\n"
+		output += RenderKeyHint("  "+data.Keys.navigateHint(KeysTagPicker)) + "\n"
+		output += RenderKeyHint("  "+key("select")+" to only show clips with the tag") + "\n"
+		output += RenderKeyHint("  "+key("back")+" to cancel") + "\n"
+	} else {
+		output += RenderKeyHint("  Type to filter tags, or to name a new tag") + "\n"
+		output += RenderKeyHint("  "+data.Keys.navigateHint(KeysTagPicker)) + "\n"
+		output += RenderKeyHint("  "+key("select")+" to add or remove the tag") + "\n"
+		output += RenderKeyHint("  "+key("back")+" when done") + "\n"
+	}
+	output += RenderKeyHint("  Ctrl+C to quit") + "\n"
+
+	return output
+}
+
+// TagPickerUpdate handles input for the tag picker screen
+func TagPickerUpdate(data *TagPickerData, msg string) TagPickerUpdateResult {
+	switch msg {
+	case "up":
+		if data.SelectedIndex > 0 {
+			data.SelectedIndex--
+			if data.SelectedIndex < data.ScrollOffset {
+				data.ScrollOffset = data.SelectedIndex
+			}
+		}
+		return TagPickerUpdateResult{Screen: -2}
+
+	case "down":
+		if data.SelectedIndex < len(data.Rows)-1 {
+			data.SelectedIndex++
+			if data.SelectedIndex >= data.ScrollOffset+data.ViewportHeight {
+				data.ScrollOffset = data.SelectedIndex - data.ViewportHeight + 1
+			}
+		}
+		return TagPickerUpdateResult{Screen: -2}
+
+	case "enter":
+		if data.SelectedIndex >= len(data.Rows) {
+			return TagPickerUpdateResult{Screen: -2}
+		}
+		row := data.Rows[data.SelectedIndex]
+		if data.Mode == TagPickerFilter {
+			return TagPickerUpdateResult{
+				Screen:    ScreenClassification,
+				Filter:    row.Tag,
+				FilterSet: true,
+			}
+		}
+		data.toggle(row)
+		return TagPickerUpdateResult{Screen: -2}
+
+	case "esc":
+		if data.Mode == TagPickerFilter {
+			return TagPickerUpdateResult{Screen: ScreenClassification}
+		}
+		return TagPickerUpdateResult{
+			Screen:   ScreenClassification,
+			Tags:     data.Tags,
+			TagsDone: true,
+		}
+
+	case "ctrl+c":
+		return TagPickerUpdateResult{Screen: -1}
+
+	case "backspace":
+		if len(data.FilterText) > 0 {
+			runes := []rune(data.FilterText)
+			data.FilterText = string(runes[:len(runes)-1])
+			data.refreshRows()
+		}
+		return TagPickerUpdateResult{Screen: -2}
+
+	default:
+		if len(msg) == 1 || msg == " " {
+			data.FilterText += msg
+			data.refreshRows()
+		}
+		return TagPickerUpdateResult{Screen: -2}
+	}
+}
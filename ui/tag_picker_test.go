@@ -0,0 +1,230 @@
+// ui/tag_picker_test.go
+package ui
+
+import (
+	"clip-tagger/state"
+	"reflect"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func tagFixture(t *testing.T) *state.State {
+	appState := state.NewState(t.TempDir(), state.SortByName)
+	group := state.NewGroup("intro", 1)
+	appState.Groups = []state.Group{group}
+	for _, f := range []string{"file1.mp4", "file2.mp4", "file3.mp4", "file4.mp4"} {
+		appState.AddOrUpdateClassification(f, group.ID)
+	}
+	appState.SetTags("file1.mp4", []string{"b-roll"})
+	appState.SetTags("file2.mp4", []string{"drone", "needs audio fix"})
+	appState.SetTags("file4.mp4", []string{"Drone"})
+	return appState
+}
+
+func TestTagPickerUpdate_Assign(t *testing.T) {
+	appState := tagFixture(t)
+	data := NewTagPickerData(appState, TagPickerAssign, "file1.mp4", []string{"b-roll"}, "")
+
+	if len(data.Rows) != 3 {
+		t.Fatalf("expected every tag listed, got %+v", data.Rows)
+	}
+
+	// Filtering uses the same matching as the group list
+	for _, key := range []string{"D", "r"} {
+		TagPickerUpdate(data, key)
+	}
+	if len(data.Rows) != 2 || data.Rows[0].Tag != "drone" || !data.Rows[1].New {
+		t.Fatalf("expected drone and a new tag row, got %+v", data.Rows)
+	}
+	TagPickerUpdate(data, "enter")
+	if !data.hasTag("drone") {
+		t.Errorf("expected drone to be added, got %v", data.Tags)
+	}
+
+	// Typed text that isn't a tag yet is offered as a new tag
+	TagPickerUpdate(data, "backspace")
+	TagPickerUpdate(data, "backspace")
+	for _, key := range []string{"t", "a", "l", "e", "n", "t"} {
+		TagPickerUpdate(data, key)
+	}
+	if len(data.Rows) != 1 || !data.Rows[0].New {
+		t.Fatalf("expected a new tag row, got %+v", data.Rows)
+	}
+	TagPickerUpdate(data, "enter")
+	if data.FilterText != "" || !data.hasTag("talent") {
+		t.Errorf("expected talent to be created and added, got %v", data.Tags)
+	}
+
+	// Enter on a tag the file has removes it
+	data.SelectedIndex = 0
+	if data.Rows[0].Tag != "b-roll" {
+		t.Fatalf("unexpected first row %+v", data.Rows[0])
+	}
+	TagPickerUpdate(data, "enter")
+
+	result := TagPickerUpdate(data, "esc")
+	if result.Screen != ScreenClassification || !result.TagsDone {
+		t.Fatalf("expected esc to finish, got %+v", result)
+	}
+	if !reflect.DeepEqual(result.Tags, []string{"drone", "talent"}) {
+		t.Errorf("unexpected tags: %v", result.Tags)
+	}
+}
+
+func TestTagPickerUpdate_Filter(t *testing.T) {
+	appState := tagFixture(t)
+	data := NewTagPickerData(appState, TagPickerFilter, "file1.mp4", nil, "drone")
+
+	if !data.Rows[0].Clear {
+		t.Fatalf("expected a row to stop filtering, got %+v", data.Rows)
+	}
+	for _, key := range []string{"n", "e", "e", "d"} {
+		TagPickerUpdate(data, key)
+	}
+	if len(data.Rows) != 1 || data.Rows[0].New {
+		t.Fatalf("expected only existing tags when filtering, got %+v", data.Rows)
+	}
+	result := TagPickerUpdate(data, "enter")
+	if !result.FilterSet || result.Filter != "needs audio fix" || result.Screen != ScreenClassification {
+		t.Errorf("unexpected result: %+v", result)
+	}
+
+	if result := TagPickerUpdate(data, "esc"); result.FilterSet || result.Screen != ScreenClassification {
+		t.Errorf("expected esc to cancel, got %+v", result)
+	}
+}
+
+func TestTagPickerView(t *testing.T) {
+	appState := tagFixture(t)
+	data := NewTagPickerData(appState, TagPickerAssign, "file1.mp4", []string{"b-roll"}, "")
+	view := TagPickerView(data)
+	for _, expected := range []string{"file1.mp4", "[x] b-roll", "[ ] drone"} {
+		if !strings.Contains(view, expected) {
+			t.Errorf("expected view to contain %q", expected)
+		}
+	}
+}
+
+func TestModel_Tags(t *testing.T) {
+	appState := tagFixture(t)
+	model := NewModel(appState, appState.Directory)
+	model.files = []string{"file1.mp4", "file2.mp4", "file3.mp4", "file4.mp4", "file5.mp4"}
+	model.currentScreen = ScreenClassification
+	model.classificationData = NewClassificationData(appState, model.files, 2, "")
+	model.currentFileIndex = 2
+	send := func(msg tea.Msg) {
+		t.Helper()
+		updated, cmd := model.Update(msg)
+		model = updated.(Model)
+		if cmd != nil {
+			if next := cmd(); next != nil {
+				updated, _ = model.Update(next)
+				model = updated.(Model)
+			}
+		}
+	}
+	press := func(keys ...string) {
+		t.Helper()
+		for _, key := range keys {
+			send(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)})
+		}
+	}
+
+	// Tag file3.mp4 with a new tag
+	press("t")
+	if model.currentScreen != ScreenTagPicker {
+		t.Fatalf("expected tag picker, got %v", model.currentScreen)
+	}
+	press("d", "r", "o", "n", "e")
+	send(tea.KeyMsg{Type: tea.KeyEnter})
+	send(tea.KeyMsg{Type: tea.KeyEsc})
+	if !appState.HasTag("file3.mp4", "drone") {
+		t.Fatal("expected file3.mp4 to be tagged drone")
+	}
+	if model.currentScreen != ScreenClassification || !reflect.DeepEqual(model.classificationData.Tags, []string{"drone"}) {
+		t.Errorf("expected classification screen to show the tags, got %v", model.classificationData.Tags)
+	}
+	press("u")
+	if appState.HasTag("file3.mp4", "drone") {
+		t.Error("expected tagging to be undone")
+	}
+	press("ctrl+r")
+
+	// Filter the queue to drone clips; the current file has the tag so it stays
+	press("f")
+	press("d", "r")
+	send(tea.KeyMsg{Type: tea.KeyEnter})
+	if model.tagFilter != "drone" || model.currentFileIndex != 2 {
+		t.Fatalf("expected drone filter on file3.mp4, got %q at %d", model.tagFilter, model.currentFileIndex)
+	}
+	if data := model.classificationData; data.FilterPosition != 2 || data.FilterTotal != 3 {
+		t.Errorf("expected clip 2 of 3, got %d of %d", data.FilterPosition, data.FilterTotal)
+	}
+
+	// Navigation skips clips without the tag
+	send(tea.KeyMsg{Type: tea.KeyRight})
+	if model.currentFileIndex != 3 {
+		t.Errorf("expected file4.mp4, got index %d", model.currentFileIndex)
+	}
+	send(tea.KeyMsg{Type: tea.KeyLeft})
+	send(tea.KeyMsg{Type: tea.KeyLeft})
+	if model.currentFileIndex != 1 {
+		t.Errorf("expected file2.mp4, got index %d", model.currentFileIndex)
+	}
+	send(tea.KeyMsg{Type: tea.KeyLeft})
+	if model.currentFileIndex != 1 {
+		t.Errorf("expected to stay on the first drone clip, got index %d", model.currentFileIndex)
+	}
+
+	// Classifying moves to the next tagged clip, and past the last one the
+	// filter ends
+	press("1")
+	if model.currentFileIndex != 2 {
+		t.Errorf("expected file3.mp4 next, got index %d", model.currentFileIndex)
+	}
+	press("1", "1")
+	if model.tagFilter != "" || model.currentFileIndex != 4 {
+		t.Errorf("expected the filter to end on file5.mp4, got %q at %d", model.tagFilter, model.currentFileIndex)
+	}
+}
+
+func TestModel_Tags_PendingUntilClassified(t *testing.T) {
+	appState := tagFixture(t)
+	model := NewModel(appState, appState.Directory)
+	model.files = []string{"file1.mp4", "file5.mp4"}
+	model.currentFileIndex = 1
+	model.currentScreen = ScreenClassification
+	model.classificationData = NewClassificationData(appState, model.files, 1, "")
+
+	model = model.handleTagsChosen([]string{"b-roll"})
+	if _, ok := appState.GetClassification("file5.mp4"); ok {
+		t.Fatal("expected file5.mp4 to stay unclassified")
+	}
+	updated, _ := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("1")})
+	model = updated.(Model)
+	if !appState.HasTag("file5.mp4", "b-roll") {
+		t.Error("expected tags to be saved when the file is classified")
+	}
+}
+
+func TestModel_PendingAnnotationError(t *testing.T) {
+	appState := tagFixture(t)
+	model := NewModel(appState, appState.Directory)
+	model.files = []string{"file1.mp4", "file5.mp4"}
+	model.currentFileIndex = 1
+	model.currentScreen = ScreenClassification
+	model.classificationData = NewClassificationData(appState, model.files, 1, "")
+
+	// A rating the state refuses is reported once the file is classified
+	model.classificationData.Annotation.Rating = state.MaxRating + 1
+	updated, _ := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("1")})
+	model = updated.(Model)
+	if _, ok := appState.GetClassification("file5.mp4"); !ok {
+		t.Fatal("expected file5.mp4 to be classified")
+	}
+	if !strings.Contains(model.err, "Failed to save notes") {
+		t.Errorf("expected notes error, got %q", model.err)
+	}
+}